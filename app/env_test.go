@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEnvDuration(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"90m":  90 * time.Minute,
+		"0s":   time.Hour,
+		"-1h":  time.Hour,
+		"soon": time.Hour,
+	} {
+		t.Setenv("TEST_DURATION", value)
+		assert.Equal(t, expected, getEnvDuration("TEST_DURATION", time.Hour), value)
+	}
+	assert.Equal(t, time.Hour, getEnvDuration("TEST_UNSET_DURATION", time.Hour))
+}
+
+func TestGetEnvInt(t *testing.T) {
+	for value, expected := range map[string]int{
+		"5":    5,
+		"0":    1000,
+		"-1":   1000,
+		"many": 1000,
+	} {
+		t.Setenv("TEST_INT", value)
+		assert.Equal(t, expected, getEnvInt("TEST_INT", 1000), value)
+	}
+	assert.Equal(t, 1000, getEnvInt("TEST_UNSET_INT", 1000))
+}
+
+func TestGetEnvList(t *testing.T) {
+	t.Setenv("TEST_LIST", " 10.0.0.1, ,10.1.0.0/16 ")
+	assert.Equal(t, []string{"10.0.0.1", "10.1.0.0/16"}, getEnvList("TEST_LIST"))
+	assert.Nil(t, getEnvList("TEST_UNSET_LIST"))
+}
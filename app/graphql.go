@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/99designs/gqlgen/graphql/handler"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/graphqlapi"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	_taskGraphqlDelivery "github.com/Yu-Qi/restful_api/usecases/task/delivery/graphql"
+)
+
+// limits of the GraphQL operations when GRAPHQL_MAX_DEPTH and GRAPHQL_MAX_COMPLEXITY are unset,
+// the complexity allows a full page of tasks with every field
+const (
+	defaultGraphQLMaxDepth      = 10
+	defaultGraphQLMaxComplexity = 2000
+)
+
+// newGraphQLServer creates the GraphQL server of the tasks, the callers are authenticated by the middlewares of
+// its routes and the scopes are checked by the type of the operations
+func newGraphQLServer() *handler.Server {
+	srv := graphqlapi.NewServer(_taskGraphqlDelivery.Schema(),
+		getEnvInt("GRAPHQL_MAX_DEPTH", defaultGraphQLMaxDepth),
+		getEnvInt("GRAPHQL_MAX_COMPLEXITY", defaultGraphQLMaxComplexity),
+	)
+	srv.Use(graphqlapi.RequireScope{ReadScope: auth.ScopeTasksRead, WriteScope: auth.ScopeTasksWrite})
+	return srv
+}
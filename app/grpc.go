@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net"
+
+	"google.golang.org/grpc"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/grpcapi"
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	customlog "github.com/Yu-Qi/restful_api/pkg/custom_log"
+	"github.com/Yu-Qi/restful_api/pkg/ratelimit"
+	_roleUsecase "github.com/Yu-Qi/restful_api/usecases/role/usecase"
+	_taskGrpcDelivery "github.com/Yu-Qi/restful_api/usecases/task/delivery/grpc"
+)
+
+// defaultGRPCPort is the port of the gRPC server when GRPC_PORT is unset
+const defaultGRPCPort = "9090"
+
+// newGRPCServer creates the gRPC server of the tasks, the callers are authenticated like the ones of the http api.
+// The usecases must be initialized by newRouter first.
+func newGRPCServer(authSchemes []middleware.AuthScheme) *grpc.Server {
+	stages := []grpcapi.Stage{grpcapi.RequestContext}
+	if authSchemes != nil {
+		stages = append(stages,
+			grpcapi.LimitFailedAuth(ratelimit.NewInMemoryStore(), authFailureLimit(), grpcapi.Authenticate(authSchemes...)),
+			grpcapi.ResolveRole(_roleUsecase.ResolveRole),
+		)
+	}
+	stages = append(stages, grpcapi.RequireScope(auth.ScopeTasksRead, auth.ScopeTasksWrite, _taskGrpcDelivery.ReadMethods()...))
+
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(grpcapi.UnaryInterceptor(stages...)),
+		grpc.StreamInterceptor(grpcapi.StreamInterceptor(stages...)),
+	)
+	_taskGrpcDelivery.NewTaskServer(s)
+	return s
+}
+
+func serveGRPC(s *grpc.Server, port string) {
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		customlog.Fatalf("listen grpc port: %v", err)
+	}
+	if err = s.Serve(listener); err != nil {
+		customlog.Fatalf("serve grpc: %v", err)
+	}
+}
@@ -3,35 +3,232 @@ package main
 import (
 	"context"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Yu-Qi/restful_api/domain"
 	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/api/openapi"
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	customlog "github.com/Yu-Qi/restful_api/pkg/custom_log"
+	"github.com/Yu-Qi/restful_api/pkg/eventbus"
+	"github.com/Yu-Qi/restful_api/pkg/idempotency"
+	"github.com/Yu-Qi/restful_api/pkg/ratelimit"
+	_apiKeyHttpDelivery "github.com/Yu-Qi/restful_api/usecases/apikey/delivery/http"
+	_apiKeyUsecase "github.com/Yu-Qi/restful_api/usecases/apikey/usecase"
+	_roleHttpDelivery "github.com/Yu-Qi/restful_api/usecases/role/delivery/http"
+	_roleUsecase "github.com/Yu-Qi/restful_api/usecases/role/usecase"
+	_taskGraphqlDelivery "github.com/Yu-Qi/restful_api/usecases/task/delivery/graphql"
 	_taskHttpDelivery "github.com/Yu-Qi/restful_api/usecases/task/delivery/http"
 	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+	_webhookHttpDelivery "github.com/Yu-Qi/restful_api/usecases/webhook/delivery/http"
+	_webhookUsecase "github.com/Yu-Qi/restful_api/usecases/webhook/usecase"
 
+	_apiKeyRepo "github.com/Yu-Qi/restful_api/usecases/apikey/repository/in_memory"
+	_roleRepo "github.com/Yu-Qi/restful_api/usecases/role/repository/in_memory"
 	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_webhookRepo "github.com/Yu-Qi/restful_api/usecases/webhook/repository/in_memory"
 
+	"github.com/gin-contrib/requestid"
 	"github.com/gin-gonic/gin"
 )
 
+// taskEventsSubscriberBuffer is how many task events a subscriber can fall behind before it is dropped
+const taskEventsSubscriberBuffer = 64
+
+// defaultTaskCreateLimit keeps a client from growing the in-memory store unbounded
+const defaultTaskCreateLimit = "60/1m"
+
+// defaultAuthFailureLimit is how many refused credentials a client ip may send before it has to wait
+const defaultAuthFailureLimit = "20/1m"
+
 func main() {
 	ctx := context.Background()
+	authSchemes := newAuthSchemes()
+	r, _ := newRouter(ctx, authSchemes, nil)
+	go serveGRPC(newGRPCServer(authSchemes), getEnv("GRPC_PORT", defaultGRPCPort))
+	appPort := os.Getenv("APP_PORT")
+	_ = r.Run(":" + appPort)
+}
+
+// newRouter creates the engine serving the api and the OpenAPI document of its routes, the requests are validated
+// against the document and so are the responses when onInvalidResponse is set.
+// The api is anonymous without authSchemes.
+func newRouter(ctx context.Context, authSchemes []middleware.AuthScheme,
+	onInvalidResponse func(c *gin.Context, violations []*response.FieldError)) (*gin.Engine, *openapi.Spec) {
 	r := gin.New()
+	// the client ip keys the rate limits, it is only read from the forwarding headers of the trusted proxies
+	if err := r.SetTrustedProxies(getEnvList("TRUSTED_PROXIES")); err != nil {
+		customlog.Fatalf("set trusted proxies: %v", err)
+	}
+	// let the request context set by the middlewares reach the lower layers through *gin.Context
+	r.ContextWithFallback = true
+	spec := newOpenAPISpec()
+	validator := spec.Validator(r)
 	r.Use(
 		middleware.HandlePanic,
+		requestid.New(),
+		middleware.RequestContext,
+		middleware.LimitBody(int64(getEnvInt("MAX_BODY_SIZE", int(middleware.DefaultMaxBodySize)))),
+		middleware.Compress(getEnvInt("COMPRESS_MIN_SIZE", middleware.DefaultCompressMinSize)),
 	)
+	if onInvalidResponse != nil {
+		r.Use(middleware.ValidateOpenAPIResponse(validator, onInvalidResponse))
+	}
 
-	registerV1API(r, ctx)
-	appPort := os.Getenv("APP_PORT")
-	_ = r.Run(":" + appPort)
+	registerV1API(r, ctx, authSchemes, validator)
+	spec.Serve(r)
+	return r, spec
+}
 
+// newAuthSchemes creates the schemes authenticating the callers from the jwt keys of the environment,
+// none when no key is configured
+func newAuthSchemes() []middleware.AuthScheme {
+	jwtConfig := &auth.JWTConfig{
+		HS256Secret:        []byte(os.Getenv("JWT_HS256_SECRET")),
+		RS256PublicKeyFile: os.Getenv("JWT_RS256_PUBLIC_KEY_FILE"),
+		JWKSFile:           os.Getenv("JWT_JWKS_FILE"),
+		Issuer:             os.Getenv("JWT_ISSUER"),
+		Audience:           os.Getenv("JWT_AUDIENCE"),
+	}
+	if jwtConfig.IsEmpty() {
+		customlog.Warning("no jwt key is configured, the api is anonymous")
+		return nil
+	}
+	verifier, err := auth.NewJWTVerifier(jwtConfig)
+	if err != nil {
+		customlog.Fatalf("load jwt keys: %v", err)
+	}
+	// api keys are issued to the users authenticated by jwt
+	return []middleware.AuthScheme{
+		middleware.BearerScheme(verifier),
+		middleware.APIKeyScheme(_apiKeyUsecase.VerifyAPIKey),
+	}
 }
 
-func registerV1API(r *gin.Engine, ctx context.Context) {
+func registerV1API(r *gin.Engine, ctx context.Context, authSchemes []middleware.AuthScheme, validator *openapi.Validator) {
+	var authMiddlewares []gin.HandlerFunc
+	if authSchemes != nil {
+		authMiddlewares = append(authMiddlewares,
+			middleware.LimitFailedAuth(ratelimit.NewInMemoryStore(), authFailureLimit()),
+			middleware.Authenticate(authSchemes...),
+			middleware.ResolveRole(_roleUsecase.ResolveRole),
+		)
+	}
+	rateLimitRules, err := ratelimit.ParseRules(getEnv("RATE_LIMITS", ""))
+	if err != nil {
+		customlog.Fatalf("parse rate limits: %v", err)
+	}
+	// the requests are validated once their caller is known, the anonymous bodies are not read
+	authMiddlewares = append(authMiddlewares,
+		middleware.RateLimit(ratelimit.NewInMemoryStore(), rateLimitRules),
+		middleware.ValidateOpenAPIRequest(validator),
+	)
+
+	// role
+	_roleUsecase.Init(_roleUsecase.InitParam{
+		RoleRepo: _roleRepo.NewInMemoryRoleRepo(),
+	})
+	_roleHttpDelivery.NewRoleHandler(r.Group("", authMiddlewares...))
+
+	// api key
+	_apiKeyUsecase.Init(_apiKeyUsecase.InitParam{
+		APIKeyRepo: _apiKeyRepo.NewInMemoryAPIKeyRepo(),
+	})
+	_apiKeyHttpDelivery.NewAPIKeyHandler(r.Group("", authMiddlewares...))
 
 	// task
-	taskRepo := _taskRepo.NewInMemoryTaskRepo()
+	taskEvents := eventbus.New[*domain.TaskEvent](getEnvInt("TASK_EVENTS_REPLAY_SIZE", 1000), taskEventsSubscriberBuffer)
+	taskRepo := _taskRepo.NewInMemoryTaskRepo(_taskRepo.WithEventPublisher(taskEvents.Publish))
+	taskCreateLimit, err := ratelimit.ParseLimit(getEnv("TASK_CREATE_LIMIT", defaultTaskCreateLimit))
+	if err != nil {
+		customlog.Fatalf("parse task create limit: %v", err)
+	}
 	_taskUsecase.Init(_taskUsecase.InitParam{
-		TaskRepo: taskRepo,
+		TaskRepo:      taskRepo,
+		TaskEvents:    taskEvents,
+		CreateLimiter: ratelimit.NewInMemoryStore(),
+		CreateLimit:   taskCreateLimit,
 	})
-	_taskHttpDelivery.NewTaskHandler(r.Group(""))
+	_taskUsecase.StartTrashPurger(ctx,
+		getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+		getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+	)
+	_taskHttpDelivery.NewTaskHandler(r.Group("",
+		append(authMiddlewares,
+			middleware.RequireScope(auth.ScopeTasksRead, auth.ScopeTasksWrite),
+			middleware.Idempotency(idempotency.NewInMemoryStore(), getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour)),
+		)...,
+	))
+
+	_taskGraphqlDelivery.NewTaskHandler(r.Group("", authMiddlewares...), newGraphQLServer())
+
+	// webhook
+	_webhookUsecase.Init(_webhookUsecase.InitParam{
+		WebhookRepo: _webhookRepo.NewInMemoryWebhookRepo(),
+	})
+	_webhookUsecase.StartDispatcher(ctx, taskEvents, getEnvInt("WEBHOOK_WORKERS", 4))
+	_webhookUsecase.StartDeliveryPurger(ctx,
+		getEnvDuration("WEBHOOK_DELIVERY_RETENTION", 7*24*time.Hour),
+		getEnvDuration("WEBHOOK_DELIVERY_PURGE_INTERVAL", time.Hour),
+	)
+	_webhookHttpDelivery.NewWebhookHandler(r.Group("", authMiddlewares...))
+}
+
+// authFailureLimit reads the refused credentials a client ip may send from the environment
+func authFailureLimit() ratelimit.Limit {
+	limit, err := ratelimit.ParseLimit(getEnv("AUTH_FAILURE_LIMIT", defaultAuthFailureLimit))
+	if err != nil {
+		customlog.Fatalf("parse auth failure limit: %v", err)
+	}
+	return limit
+}
+
+// getEnv reads a variable from the environment, fallback is used when unset
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// getEnvList reads a comma separated list from the environment, nil when unset or empty
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvInt reads a positive integer from the environment, fallback is used when unset or invalid
+func getEnvInt(key string, fallback int) int {
+	raw, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value <= 0 {
+		customlog.Warningf("%s=%q is not a positive integer, using %d", key, raw, fallback)
+		return fallback
+	}
+	return value
+}
+
+// getEnvDuration reads a positive duration like "720h" from the environment, fallback is used when unset or invalid
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	raw, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	duration, err := time.ParseDuration(raw)
+	if err != nil || duration <= 0 {
+		customlog.Warningf("%s=%q is not a positive duration, using %s", key, raw, fallback)
+		return fallback
+	}
+	return duration
 }
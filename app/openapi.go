@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/openapi"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	_apiKeyHttpDelivery "github.com/Yu-Qi/restful_api/usecases/apikey/delivery/http"
+	_roleHttpDelivery "github.com/Yu-Qi/restful_api/usecases/role/delivery/http"
+	_taskGraphqlDelivery "github.com/Yu-Qi/restful_api/usecases/task/delivery/graphql"
+	_taskHttpDelivery "github.com/Yu-Qi/restful_api/usecases/task/delivery/http"
+	_webhookHttpDelivery "github.com/Yu-Qi/restful_api/usecases/webhook/delivery/http"
+)
+
+// newOpenAPISpec documents the routes of the handlers
+func newOpenAPISpec() *openapi.Spec {
+	spec := openapi.NewSpec(openapi.Info{
+		Title:   "Restful api",
+		Version: "1.0.0",
+		Description: "Tasks partitioned by tenant, the X-Tenant-ID header picks the tenant of a request " +
+			"when the token claims none.",
+	})
+	spec.Add("tasks", _taskHttpDelivery.OpenAPIRoutes()...)
+	spec.Add("api keys", _apiKeyHttpDelivery.OpenAPIRoutes()...)
+	spec.Add("roles", _roleHttpDelivery.OpenAPIRoutes()...)
+	spec.Add("webhooks", _webhookHttpDelivery.OpenAPIRoutes()...)
+	spec.Add("graphql", _taskGraphqlDelivery.OpenAPIRoutes()...)
+
+	spec.Enum(domain.TaskStatus(0), int(domain.TaskStatusIncomplete), int(domain.TaskStatusCompleted))
+	spec.Enum(domain.TaskAction(""), string(domain.TaskActionCreate), string(domain.TaskActionUpdate),
+		string(domain.TaskActionDelete), string(domain.TaskActionRestore), string(domain.TaskActionPurge),
+		string(domain.TaskActionRevert))
+	spec.Enum(domain.RecurrenceFrequency(""), string(domain.RecurrenceFrequencyDaily),
+		string(domain.RecurrenceFrequencyWeekly), string(domain.RecurrenceFrequencyMonthly))
+	spec.Enum(domain.WebhookEvent(""), string(domain.WebhookEventTaskCreated), string(domain.WebhookEventTaskUpdated),
+		string(domain.WebhookEventTaskDeleted), string(domain.WebhookEventTaskCompleted))
+	spec.Enum(domain.WebhookDeliveryStatus(""), string(domain.WebhookDeliveryPending),
+		string(domain.WebhookDeliveryDelivered), string(domain.WebhookDeliveryDead))
+	spec.Enum(auth.Role(""), string(auth.RoleViewer), string(auth.RoleEditor), string(auth.RoleAdmin))
+	return spec
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/media"
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/api/openapi"
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+	"github.com/gin-gonic/gin"
+)
+
+func TestOpenAPISuite(t *testing.T) {
+	suite.Run(t, new(openAPISuite))
+}
+
+type openAPISuite struct {
+	suite.Suite
+	Router *gin.Engine
+	Spec   *openapi.Spec
+	cancel context.CancelFunc
+}
+
+func (s *openAPISuite) SetupSuite() {
+	var ctx context.Context
+	ctx, s.cancel = context.WithCancel(context.Background())
+	// every response of the suite must match the document
+	s.Router, s.Spec = newRouter(ctx, nil, func(c *gin.Context, violations []*response.FieldError) {
+		for _, violation := range violations {
+			s.Failf("response does not match the document", "%s %s %d %s.%s: %s", c.Request.Method, c.FullPath(),
+				c.Writer.Status(), violation.In, violation.Field, violation.Message)
+		}
+	})
+}
+
+func (s *openAPISuite) TearDownSuite() {
+	s.cancel()
+}
+
+// TestDrift fails when a route is registered without being documented or the other way around
+func (s *openAPISuite) TestDrift() {
+	undocumented, unregistered := s.Spec.Drift(s.Router.Routes())
+	s.Empty(undocumented, "routes missing from the OpenAPI document, see OpenAPIRoutes of their handler")
+	s.Empty(unregistered, "documented routes which are not registered")
+}
+
+func (s *openAPISuite) get(path string) *httptest.ResponseRecorder {
+	return s.request("GET", path, "")
+}
+
+func (s *openAPISuite) request(method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(method, path, strings.NewReader(body))
+	s.NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	s.Router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *openAPISuite) TestDocument() {
+	w := s.get(openapi.DocumentPath)
+	s.Equal(http.StatusOK, w.Code)
+	doc := openapi.Document{}
+	s.NoError(json.Unmarshal(w.Body.Bytes(), &doc))
+	s.Equal(openapi.Version, doc.OpenAPI)
+
+	operations := 0
+	for _, item := range doc.Paths {
+		operations += len(item)
+	}
+	s.Equal(len(s.Router.Routes()), operations)
+
+	put := doc.Paths["/v1/tasks/{id}"]["put"]
+	s.Equal("UpdateTask", put.OperationID)
+	s.Equal("id", put.Parameters[0].Name)
+	s.Equal("path", put.Parameters[0].In)
+	s.Equal(openapi.SchemaType{"integer"}, put.Parameters[0].Schema.Type)
+	params := doc.Components.Schemas["createTaskParams"]
+	s.ElementsMatch([]string{"name", "status"}, params.Required)
+	s.Equal([]interface{}{float64(0), float64(1), nil}, params.Properties["status"].Enum)
+	s.Contains(put.RequestBody.Content, media.MIMEProtobuf)
+	s.Equal("#/components/schemas/Task", put.Responses["200"].Content[media.MIMEJSON].Schema.Properties["data"].Ref)
+
+	list := doc.Paths["/v1/tasks"]["get"]
+	s.Contains(list.Responses, "304")
+	s.Contains(list.Responses["200"].Content, "application/x-ndjson")
+	s.Equal("blocked", list.Parameters[0].Name)
+	s.Equal("query", list.Parameters[0].In)
+
+	errorResp := doc.Components.Schemas["ErrorResp"]
+	s.Contains(errorResp.Properties["code"].Enum, float64(code.ParamIncorrect))
+	s.Contains(errorResp.Properties["code"].Description, "1000: ParamIncorrect")
+}
+
+func (s *openAPISuite) TestUI() {
+	w := s.get(openapi.UIPath + "/")
+	s.Equal(http.StatusOK, w.Code)
+	s.Contains(w.Header().Get("Content-Type"), "text/html")
+	w = s.get(openapi.UIPath + "/swagger-initializer.js")
+	s.Equal(http.StatusOK, w.Code)
+	s.Contains(w.Body.String(), openapi.DocumentPath)
+	s.Equal(http.StatusOK, s.get(openapi.UIPath+"/swagger-ui-bundle.js").Code)
+	s.Equal(http.StatusNotFound, s.get(openapi.UIPath+"/missing.js").Code)
+}
+
+// TestContract calls the routes, their responses are checked against the document by the router
+func (s *openAPISuite) TestContract() {
+	w := s.request("POST", "/v1/tasks", `{"name": "contract", "status": 0, "due_at": "2030-01-02T03:04:05Z",
+		"recurrence": {"frequency": "daily", "interval": 1, "start_at": "2030-01-01T00:00:00Z"}}`)
+	s.Equal(http.StatusOK, w.Code, w.Body.String())
+	created := struct {
+		Data struct {
+			ID int `json:"id"`
+		} `json:"data"`
+	}{}
+	s.NoError(json.Unmarshal(w.Body.Bytes(), &created))
+	id := strconv.Itoa(created.Data.ID)
+
+	for _, call := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{"GET", "/v1/tasks?blocked=false", "", http.StatusOK},
+		{"GET", "/v1/tasks/" + id + "/occurrences?n=2", "", http.StatusOK},
+		{"PUT", "/v1/tasks/" + id, `{"name": "contract", "status": 1}`, http.StatusOK},
+		{"GET", "/v1/tasks/" + id + "/dependencies", "", http.StatusOK},
+		{"GET", "/v1/tasks/" + id + "/history?page=1&page_size=10", "", http.StatusOK},
+		{"GET", "/v1/tasks/" + id + "/versions", "", http.StatusOK},
+		{"GET", "/v1/audit", "", http.StatusOK},
+		{"DELETE", "/v1/tasks/" + id, "", http.StatusOK},
+		{"GET", "/v1/trash", "", http.StatusOK},
+		{"POST", "/v1/tasks/" + id + "/restore", "", http.StatusOK},
+		{"GET", "/v1/tasks/0/versions", "", http.StatusNotFound},
+		{"POST", "/graphql", `{"query": "query ($id: Int!) { task(id: $id) { id name recurrence { frequency } } }",
+			"variables": {"id": ` + id + `}, "operationName": null}`, http.StatusOK},
+		{"POST", "/graphql", `{"query": "{ task(id: 0) { id } }"}`, http.StatusOK},
+		{"POST", "/graphql", `{"query": "{ task { id } }"}`, http.StatusUnprocessableEntity},
+	} {
+		w := s.request(call.method, call.path, call.body)
+		s.Equal(call.status, w.Code, "%s %s: %s", call.method, call.path, w.Body.String())
+	}
+
+	req, err := http.NewRequest("GET", "/v1/tasks", nil)
+	s.NoError(err)
+	req.Header.Set("Accept", "application/x-ndjson")
+	w = httptest.NewRecorder()
+	s.Router.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+}
+
+func (s *openAPISuite) TestRequestValidation() {
+	resp := response.ErrorResp{}
+	w := s.request("POST", "/v1/tasks", `{"status": 3, "due_at": "tomorrow", "recurrence": {"frequency": "hourly"}}`)
+	s.Equal(http.StatusBadRequest, w.Code)
+	s.NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	s.Equal(code.ParamIncorrect, resp.Code)
+	s.Contains(resp.Details, &response.FieldError{In: "body", Field: "name", Message: "is required"})
+	s.Contains(resp.Details, &response.FieldError{In: "body", Field: "status", Message: "must be one of 0, 1, null"})
+	s.Contains(resp.Details, &response.FieldError{In: "body", Field: "due_at", Message: "must be a date-time like 2006-01-02T15:04:05Z"})
+	s.Contains(resp.Details, &response.FieldError{In: "body", Field: "recurrence.frequency", Message: "must be one of daily, weekly, monthly"})
+	s.Contains(resp.Message, "body.name: is required")
+
+	resp = response.ErrorResp{}
+	w = s.get("/v1/tasks/x/history?page=0&page_size=many")
+	s.Equal(http.StatusBadRequest, w.Code)
+	s.NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	s.Equal([]*response.FieldError{
+		{In: "path", Field: "id", Message: "must be an integer"},
+		{In: "query", Field: "page", Message: "must be at least 1"},
+		{In: "query", Field: "page_size", Message: "must be an integer"},
+	}, resp.Details)
+
+	s.Equal(http.StatusBadRequest, s.get("/v1/tasks?blocked=maybe").Code)
+	s.Equal(http.StatusBadRequest, s.request("POST", "/v1/tasks", `[]`).Code)
+	s.Equal(http.StatusBadRequest, s.request("POST", "/v1/tasks", ``).Code)
+	s.Equal(http.StatusBadRequest, s.request("POST", "/graphql", `{"variables": {}}`).Code)
+}
+
+func TestValidationAfterAuthentication(t *testing.T) {
+	verifier, err := auth.NewJWTVerifier(&auth.JWTConfig{HS256Secret: []byte("secret")})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	router, _ := newRouter(ctx, []middleware.AuthScheme{middleware.BearerScheme(verifier)}, nil)
+
+	// an anonymous caller learns nothing of the api from the violations of its body
+	w := testutil.Serve(router, httptest.NewRequest("POST", "/v1/tasks", strings.NewReader(`{"status": 3}`)),
+		"Content-Type", "application/json")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
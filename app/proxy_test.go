@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+)
+
+// failAuth sends a bad token to the router of a client behind httptest's remote address 192.0.2.1 which claims
+// to forward the request of forwardedFor
+func failAuth(t *testing.T, trustedProxies, forwardedFor string) int {
+	t.Setenv("TRUSTED_PROXIES", trustedProxies)
+	t.Setenv("AUTH_FAILURE_LIMIT", "2/1h")
+	verifier, err := auth.NewJWTVerifier(&auth.JWTConfig{HS256Secret: []byte("secret")})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	router, _ := newRouter(ctx, []middleware.AuthScheme{middleware.BearerScheme(verifier)}, nil)
+
+	code := 0
+	for i := 0; i < 3; i++ {
+		w := testutil.Serve(router, httptest.NewRequest("GET", "/v1/tasks", nil),
+			"Authorization", "Bearer guess", "X-Forwarded-For", forwardedFor+strconv.Itoa(i), "X-Real-IP", forwardedFor+strconv.Itoa(i))
+		code = w.Code
+	}
+	return code
+}
+
+func TestSpoofedClientIP(t *testing.T) {
+	// the forwarding headers of an untrusted client don't give it a new ip, so it is locked out
+	assert.Equal(t, http.StatusTooManyRequests, failAuth(t, "", "10.0.0."))
+	// the ones of a trusted proxy name the clients
+	assert.Equal(t, http.StatusUnauthorized, failAuth(t, "192.0.2.1", "10.0.0."))
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// APIKey is a credential for service-to-service access, only the salted hash of the secret is kept.
+// Prefix is the public part of the key used to look it up, a key is bound to the tenant it is issued in.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	OwnerID    string     `json:"owner_id"`
+	TenantID   string     `json:"tenant_id"`
+	Scopes     []string   `json:"scopes"`
+	Salt       []byte     `json:"-"`
+	Hash       []byte     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type APIKeyRepository interface {
+	// CreateAPIKey sets the id of the key
+	CreateAPIKey(ctx context.Context, key *APIKey) *code.CustomError
+	GetAPIKey(ctx context.Context, id int) (*APIKey, *code.CustomError)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*APIKey, *code.CustomError)
+	GetAPIKeysByOwner(ctx context.Context, ownerID string) ([]*APIKey, *code.CustomError)
+	RevokeAPIKey(ctx context.Context, id int, revokedAt time.Time) *code.CustomError
+	TouchAPIKey(ctx context.Context, id int, usedAt time.Time) *code.CustomError
+}
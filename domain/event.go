@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// TaskEvent is a change of a task published to the subscribers,
+// Task is the task after the change, or before it when the task is purged, Before is nil when the task is created
+type TaskEvent struct {
+	Action     TaskAction `json:"action"`
+	Task       *Task      `json:"task"`
+	Before     *Task      `json:"-"`
+	Actor      string     `json:"actor"`
+	OccurredAt time.Time  `json:"occurred_at"`
+	TenantID   string     `json:"-"`
+}
+
+// TaskEventPublisher publishes the changes of the tasks, it must not block
+type TaskEventPublisher func(event *TaskEvent)
@@ -0,0 +1,76 @@
+package domain
+
+import "time"
+
+// TaskAction is the kind of change recorded in the task history
+type TaskAction string
+
+// task actions
+const (
+	TaskActionCreate  TaskAction = "create"
+	TaskActionUpdate  TaskAction = "update"
+	TaskActionDelete  TaskAction = "delete"
+	TaskActionRestore TaskAction = "restore"
+	TaskActionPurge   TaskAction = "purge"
+	TaskActionRevert  TaskAction = "revert"
+)
+
+// TaskHistory is an immutable record of a change of a task
+type TaskHistory struct {
+	ID     int        `json:"id"`
+	TaskID int        `json:"task_id"`
+	Action TaskAction `json:"action"`
+	Before *Task      `json:"before,omitempty"`
+	After  *Task      `json:"after,omitempty"`
+	Actor  string     `json:"actor"`
+	// ActorVerified is false when the actor named itself without credentials
+	ActorVerified bool      `json:"actor_verified"`
+	RequestID     string    `json:"request_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TaskVersion is a snapshot of a task right after it was created or updated
+type TaskVersion struct {
+	Version int    `json:"version"`
+	Task    *Task  `json:"task"`
+	Actor   string `json:"actor"`
+	// ActorVerified is false when the actor named itself without credentials
+	ActorVerified bool      `json:"actor_verified"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TaskFieldDiff is the change of a field of a task
+type TaskFieldDiff struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// Pagination selects a page of a listing, Page starts from 1
+type Pagination struct {
+	Page     int
+	PageSize int
+}
+
+// Bounds returns the [start, end) indexes of the page in a listing of total items
+func (p Pagination) Bounds(total int) (int, int) {
+	start := (p.Page - 1) * p.PageSize
+	if start > total {
+		start = total
+	}
+	end := start + p.PageSize
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// GetAuditLogsParams filters the audit logs, nil fields are not filtered
+type GetAuditLogsParams struct {
+	Actor *string
+	// OwnerID keeps the history of the tasks owned by it
+	OwnerID    *string
+	From       *time.Time
+	To         *time.Time
+	Pagination Pagination
+}
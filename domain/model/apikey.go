@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// APIKey represents an api key entity for repository
+type APIKey struct {
+	Id         int
+	Name       string
+	Prefix     string
+	OwnerId    string
+	TenantId   string
+	Scopes     []string
+	Salt       []byte
+	Hash       []byte
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+)
+
+// RoleAssignment represents a role assignment entity for repository
+type RoleAssignment struct {
+	TenantId   string
+	Subject    string
+	Role       auth.Role
+	AssignedBy string
+	AssignedAt time.Time
+}
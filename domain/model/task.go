@@ -1,10 +1,41 @@
 package model
 
-import "github.com/Yu-Qi/restful_api/domain"
+import (
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+)
 
 // Task represents a task entity for repository
 type Task struct {
-	Id     int
-	Name   string
-	Status domain.TaskStatus
+	Id         int
+	Name       string
+	Status     domain.TaskStatus
+	DueAt      *time.Time
+	Recurrence *domain.Recurrence
+	DeletedAt  *time.Time
+	Version    int
+	OwnerId    string
+	ExternalId string
+}
+
+// TaskHistory represents a task history entity for repository
+type TaskHistory struct {
+	Id            int
+	TaskId        int
+	Action        domain.TaskAction
+	Before        *Task
+	After         *Task
+	Actor         string
+	ActorVerified bool
+	RequestId     string
+	CreatedAt     time.Time
+}
+
+// TaskVersion represents a task version entity for repository
+type TaskVersion struct {
+	Task          *Task
+	Actor         string
+	ActorVerified bool
+	CreatedAt     time.Time
 }
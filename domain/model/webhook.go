@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+)
+
+// Webhook represents a webhook entity for repository
+type Webhook struct {
+	Id        int
+	Url       string
+	Events    []domain.WebhookEvent
+	Active    bool
+	Secret    string
+	TenantId  string
+	CreatedBy string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WebhookDelivery represents a webhook delivery entity for repository
+type WebhookDelivery struct {
+	Id            int
+	WebhookId     int
+	TenantId      string
+	Event         domain.WebhookEvent
+	Payload       []byte
+	Status        domain.WebhookDeliveryStatus
+	Attempts      []WebhookAttempt
+	NextAttemptAt *time.Time
+	CreatedAt     time.Time
+}
+
+// WebhookAttempt represents a webhook delivery attempt for repository
+type WebhookAttempt struct {
+	AttemptedAt time.Time
+	StatusCode  int
+	Error       string
+	DurationMs  int64
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+// RecurrenceFrequency is how often a recurring task repeats
+type RecurrenceFrequency string
+
+// recurrence frequencies
+const (
+	RecurrenceFrequencyDaily   RecurrenceFrequency = "daily"
+	RecurrenceFrequencyWeekly  RecurrenceFrequency = "weekly"
+	RecurrenceFrequencyMonthly RecurrenceFrequency = "monthly"
+)
+
+var recurrenceFrequencies = map[RecurrenceFrequency]rrule.Frequency{
+	RecurrenceFrequencyDaily:   rrule.DAILY,
+	RecurrenceFrequencyWeekly:  rrule.WEEKLY,
+	RecurrenceFrequencyMonthly: rrule.MONTHLY,
+}
+
+var recurrenceWeekdays = map[string]rrule.Weekday{
+	"MO": rrule.MO,
+	"TU": rrule.TU,
+	"WE": rrule.WE,
+	"TH": rrule.TH,
+	"FR": rrule.FR,
+	"SA": rrule.SA,
+	"SU": rrule.SU,
+}
+
+// Recurrence is an RRULE-style schedule of a recurring task
+type Recurrence struct {
+	Frequency RecurrenceFrequency `json:"frequency" binding:"required,oneof=daily weekly monthly"`
+	// Interval repeats every Interval frequencies, default 1
+	Interval int `json:"interval,omitempty" binding:"omitempty,min=1"`
+	// ByWeekday limits occurrences to the given weekdays, e.g. MO, TU
+	ByWeekday []string  `json:"by_weekday,omitempty" binding:"omitempty,dive,oneof=MO TU WE TH FR SA SU"`
+	StartAt   time.Time `json:"start_at" binding:"required"`
+	// Until and Count bound the series, both are optional
+	Until *time.Time `json:"until,omitempty"`
+	Count int        `json:"count,omitempty" binding:"omitempty,min=1"`
+}
+
+// Validate checks the recurrence can build a schedule
+func (r *Recurrence) Validate() error {
+	_, err := r.rule()
+	return err
+}
+
+// First returns the first occurrence of the series
+func (r *Recurrence) First() (time.Time, bool) {
+	rule, err := r.rule()
+	if err != nil {
+		return time.Time{}, false
+	}
+	first := rule.After(r.StartAt, true)
+	return first, !first.IsZero()
+}
+
+// Next returns up to n occurrences strictly after the given time
+func (r *Recurrence) Next(after time.Time, n int) []time.Time {
+	rule, err := r.rule()
+	if err != nil {
+		return nil
+	}
+
+	occurrences := make([]time.Time, 0, n)
+	for len(occurrences) < n {
+		after = rule.After(after, false)
+		if after.IsZero() {
+			break
+		}
+		occurrences = append(occurrences, after)
+	}
+	return occurrences
+}
+
+func (r *Recurrence) rule() (*rrule.RRule, error) {
+	freq, ok := recurrenceFrequencies[r.Frequency]
+	if !ok {
+		return nil, fmt.Errorf("frequency %q is invalid", r.Frequency)
+	}
+
+	option := rrule.ROption{
+		Freq:     freq,
+		Dtstart:  r.StartAt,
+		Interval: r.Interval,
+		Count:    r.Count,
+	}
+	for _, day := range r.ByWeekday {
+		weekday, ok := recurrenceWeekdays[day]
+		if !ok {
+			return nil, fmt.Errorf("weekday %q is invalid", day)
+		}
+		option.Byweekday = append(option.Byweekday, weekday)
+	}
+	if r.Until != nil {
+		if r.Until.Before(r.StartAt) {
+			return nil, fmt.Errorf("until is before start_at")
+		}
+		option.Until = *r.Until
+	}
+	return rrule.NewRRule(option)
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// RoleAssignment grants a role to a subject within a tenant, it overrides the role claimed by the token of the subject
+type RoleAssignment struct {
+	TenantID   string    `json:"tenant_id"`
+	Subject    string    `json:"subject"`
+	Role       auth.Role `json:"role"`
+	AssignedBy string    `json:"assigned_by"`
+	AssignedAt time.Time `json:"assigned_at"`
+}
+
+type RoleRepository interface {
+	GetRoleAssignment(ctx context.Context, tenantID, subject string) (*RoleAssignment, *code.CustomError)
+	GetRoleAssignmentsByTenant(ctx context.Context, tenantID string) ([]*RoleAssignment, *code.CustomError)
+	// AssignRole replaces the previous assignment of the subject in the tenant
+	AssignRole(ctx context.Context, assignment *RoleAssignment) *code.CustomError
+}
@@ -3,6 +3,7 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"github.com/Yu-Qi/restful_api/pkg/code"
 )
@@ -11,21 +12,89 @@ import (
 // ENUM(incomplete,completed)
 type TaskStatus int
 
-// Task represents a task entity
+// Task represents a task entity, Version starts from 1 and increases on every update,
+// OwnerID is the subject of the principal who created the task, ExternalID identifies an imported task in the
+// system it comes from and is unique per tenant
 type Task struct {
-	ID     int        `json:"-"`
-	Name   string     `json:"name"`
-	Status TaskStatus `json:"status"`
+	ID         int         `json:"id"`
+	Name       string      `json:"name"`
+	Status     TaskStatus  `json:"status"`
+	DueAt      *time.Time  `json:"due_at,omitempty"`
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+	DeletedAt  *time.Time  `json:"deleted_at,omitempty"`
+	Version    int         `json:"version"`
+	OwnerID    string      `json:"owner_id,omitempty"`
+	ExternalID string      `json:"external_id,omitempty"`
 }
 
+// TaskRevision identifies the state of the tasks of a tenant, Revision increases on every change of the tasks or
+// their dependencies and ModifiedAt is the time of the last one. The revisions only compare within an Epoch,
+// a storage starting over gets a new one.
+type TaskRevision struct {
+	Epoch      int64
+	Revision   int64
+	ModifiedAt time.Time
+}
+
+// TaskRepository stores the tasks of every tenant apart, the methods work on the tenant carried by ctx,
+// see requestctx.GetTenantID
 type TaskRepository interface {
+	// GetTenantIDs returns the tenants having tasks, for the jobs working across tenants
+	GetTenantIDs(context.Context) ([]string, *code.CustomError)
+
 	GetTasks(context.Context) ([]*Task, *code.CustomError)
+	// RangeTasks calls fn for each task out of the trash in id order until fn returns false, without collecting them
+	RangeTasks(ctx context.Context, fn func(task *Task) bool) *code.CustomError
+	// GetTaskRevision returns the revision of the tasks, read it before the tasks so that they are at least as new
+	GetTaskRevision(ctx context.Context) (*TaskRevision, *code.CustomError)
+	GetTask(ctx context.Context, id int) (*Task, *code.CustomError)
+	// GetTaskByExternalID gets a task by its external id, an external id held by a task in the trash is a conflict
+	// since CreateTask rejects it too
+	GetTaskByExternalID(ctx context.Context, externalID string) (*Task, *code.CustomError)
+	// CreateTask rejects an external id used by another task, even in the trash
 	CreateTask(ctx context.Context, task *Task) *code.CustomError
 	UpdateTask(ctx context.Context, params *UpdateTaskParams) *code.CustomError
+	// PatchTask replaces the name, status, due date and recurrence of a task by the ones of the task returned by patch,
+	// patch gets a copy of the current task and runs under the row lock, so the read-modify-write is atomic
+	PatchTask(ctx context.Context, id int, patch TaskPatchFunc) (*Task, *code.CustomError)
+	// DeleteTask moves a task to the trash, trashed tasks are hidden from the other methods
 	DeleteTask(ctx context.Context, id int) *code.CustomError
+
+	// GetDeletedTasks returns the tasks in the trash in id order
+	GetDeletedTasks(ctx context.Context) ([]*Task, *code.CustomError)
+	GetDeletedTask(ctx context.Context, id int) (*Task, *code.CustomError)
+	RestoreTask(ctx context.Context, id int) *code.CustomError
+	// PurgeTask permanently removes a task from the trash
+	PurgeTask(ctx context.Context, id int) *code.CustomError
+	// PurgeDeletedTasks permanently removes the tasks trashed before the given time
+	PurgeDeletedTasks(ctx context.Context, before time.Time) (int, *code.CustomError)
+
+	// GetTaskVersions returns every version of a task, oldest first
+	GetTaskVersions(ctx context.Context, id int) ([]*TaskVersion, *code.CustomError)
+	// RevertTask restores the fields of a task to the given version as a new version and returns the applied diff
+	RevertTask(ctx context.Context, id, version int) ([]*TaskFieldDiff, *code.CustomError)
+
+	// GetTaskHistory returns a page of the history of a task, newest first, and the total count
+	GetTaskHistory(ctx context.Context, taskID int, pagination Pagination) ([]*TaskHistory, int, *code.CustomError)
+	// GetAuditLogs returns a page of the history of all tasks, newest first, and the total count
+	GetAuditLogs(ctx context.Context, params *GetAuditLogsParams) ([]*TaskHistory, int, *code.CustomError)
+
+	// AddDependency records that taskID is blocked by blockerID
+	AddDependency(ctx context.Context, taskID, blockerID int) *code.CustomError
+	RemoveDependency(ctx context.Context, taskID, blockerID int) *code.CustomError
+	// GetBlockers returns the ids of the tasks blocking taskID
+	GetBlockers(ctx context.Context, taskID int) ([]int, *code.CustomError)
+	// GetDependencies returns the whole blocked-by graph, key: task id, value: blocker ids
+	GetDependencies(ctx context.Context) (map[int][]int, *code.CustomError)
 }
+
+// TaskPatchFunc computes the new state of a task from the current one
+type TaskPatchFunc func(current *Task) (*Task, *code.CustomError)
+
 type UpdateTaskParams struct {
-	ID     int
-	Name   *string
-	Status *TaskStatus
+	ID         int
+	Name       *string
+	Status     *TaskStatus
+	DueAt      *time.Time
+	Recurrence *Recurrence
 }
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// WebhookEvent is a kind of task change a webhook is notified of
+type WebhookEvent string
+
+// webhook events
+const (
+	WebhookEventTaskCreated = WebhookEvent("task.created")
+	WebhookEventTaskUpdated = WebhookEvent("task.updated")
+	WebhookEventTaskDeleted = WebhookEvent("task.deleted")
+	// WebhookEventTaskCompleted is sent besides task.updated when the status of a task becomes completed
+	WebhookEventTaskCompleted = WebhookEvent("task.completed")
+)
+
+// Webhook is a subscription of a tenant to the task changes, the payloads are signed by Secret
+type Webhook struct {
+	ID        int            `json:"id"`
+	URL       string         `json:"url"`
+	Events    []WebhookEvent `json:"events"`
+	Active    bool           `json:"active"`
+	Secret    string         `json:"-"`
+	TenantID  string         `json:"tenant_id"`
+	CreatedBy string         `json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// WebhookDeliveryStatus is the state of a delivery, a dead delivery gave up retrying
+type WebhookDeliveryStatus string
+
+// webhook delivery statuses
+const (
+	WebhookDeliveryPending   = WebhookDeliveryStatus("pending")
+	WebhookDeliveryDelivered = WebhookDeliveryStatus("delivered")
+	WebhookDeliveryDead      = WebhookDeliveryStatus("dead")
+)
+
+// WebhookDelivery is a payload sent to a webhook and the attempts made so far
+type WebhookDelivery struct {
+	ID            int                   `json:"id"`
+	WebhookID     int                   `json:"webhook_id"`
+	TenantID      string                `json:"tenant_id"`
+	Event         WebhookEvent          `json:"event"`
+	Payload       json.RawMessage       `json:"payload"`
+	Status        WebhookDeliveryStatus `json:"status"`
+	Attempts      []*WebhookAttempt     `json:"attempts"`
+	NextAttemptAt *time.Time            `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+}
+
+// WebhookAttempt is a request made for a delivery, StatusCode is 0 when no response is received
+type WebhookAttempt struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+}
+
+type WebhookRepository interface {
+	// CreateWebhook sets the id of the webhook
+	CreateWebhook(ctx context.Context, webhook *Webhook) *code.CustomError
+	GetWebhook(ctx context.Context, id int) (*Webhook, *code.CustomError)
+	// GetWebhooksByTenant returns the webhooks of a tenant, ordered by id
+	GetWebhooksByTenant(ctx context.Context, tenantID string) ([]*Webhook, *code.CustomError)
+	UpdateWebhook(ctx context.Context, webhook *Webhook) *code.CustomError
+	// DeleteWebhook deletes a webhook and its deliveries
+	DeleteWebhook(ctx context.Context, id int) *code.CustomError
+
+	// CreateDelivery sets the id of the delivery
+	CreateDelivery(ctx context.Context, delivery *WebhookDelivery) *code.CustomError
+	GetDelivery(ctx context.Context, id int) (*WebhookDelivery, *code.CustomError)
+	UpdateDelivery(ctx context.Context, delivery *WebhookDelivery) *code.CustomError
+	// GetDeliveries returns the deliveries of a webhook, newest first
+	GetDeliveries(ctx context.Context, webhookID int) ([]*WebhookDelivery, *code.CustomError)
+	// GetDeliveriesByStatus returns the deliveries of a tenant in a status, newest first
+	GetDeliveriesByStatus(ctx context.Context, tenantID string, status WebhookDeliveryStatus) ([]*WebhookDelivery, *code.CustomError)
+	// DeleteDeliveries deletes the delivered and dead deliveries created before the given time,
+	// the pending ones are kept
+	DeleteDeliveries(ctx context.Context, before time.Time) (int, *code.CustomError)
+}
@@ -0,0 +1,63 @@
+// Package graphqlapi holds what the GraphQL endpoints share: the errors carrying the codes, the limits of the
+// operations and the check of the scopes.
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	customlog "github.com/Yu-Qi/restful_api/pkg/custom_log"
+)
+
+// extension keys of the errors
+const (
+	ExtensionCode   = "code"
+	ExtensionReason = "reason"
+)
+
+// Error converts an error of the usecases to a GraphQL error whose extensions hold the code and its name as reason
+func Error(customErr *code.CustomError) *gqlerror.Error {
+	message := ""
+	if customErr.Error != nil {
+		message = customErr.Error.Error()
+	}
+	return &gqlerror.Error{Message: message, Extensions: extensionsOf(customErr.Code)}
+}
+
+func extensionsOf(c int) map[string]interface{} {
+	return map[string]interface{}{ExtensionCode: c, ExtensionReason: code.Name(c)}
+}
+
+// ErrorPresenter gives a code to the errors without one, ParamIncorrect to the operations rejected by gqlgen,
+// which names the failure by a string code, and InternalUnknownError to the others.
+// The error is copied since the transports read the string code of the original to pick the response.
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	if gqlErr.Path == nil {
+		gqlErr.Path = graphql.GetPath(ctx)
+	}
+	if _, ok := gqlErr.Extensions[ExtensionCode].(int); ok {
+		return gqlErr
+	}
+
+	presented := *gqlErr
+	if _, ok := gqlErr.Extensions[ExtensionCode].(string); ok {
+		presented.Extensions = extensionsOf(code.ParamIncorrect)
+	} else {
+		presented.Extensions = extensionsOf(code.InternalUnknownError)
+	}
+	return &presented
+}
+
+// Recover logs the panics of the resolvers, the clients get an internal error
+func Recover(_ context.Context, r interface{}) error {
+	customlog.Errorf("graphql panic: %v", r)
+	customlog.ErrorWithData("stack trace", string(debug.Stack()))
+	return Error(code.NewCustomError(code.InternalUnknownError, http.StatusInternalServerError, errors.New("internal error")))
+}
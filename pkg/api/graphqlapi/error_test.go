@@ -0,0 +1,40 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/stretchr/testify/suite"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+func TestGraphQLAPISuite(t *testing.T) {
+	suite.Run(t, new(graphqlAPISuite))
+}
+
+type graphqlAPISuite struct {
+	suite.Suite
+}
+
+func (s *graphqlAPISuite) TestErrorPresenter() {
+	ctx := context.Background()
+	presented := ErrorPresenter(ctx, Error(code.NewCustomError(code.NotFound, http.StatusNotFound, errors.New("task not found"))))
+	s.Equal("task not found", presented.Message)
+	s.Equal(map[string]interface{}{ExtensionCode: code.NotFound, ExtensionReason: "NotFound"}, presented.Extensions)
+
+	// the rejected operations keep their string code for the transports
+	rejected := gqlerror.Errorf("cannot query field")
+	errcode.Set(rejected, errcode.ValidationFailed)
+	presented = ErrorPresenter(ctx, rejected)
+	s.Equal(code.ParamIncorrect, presented.Extensions[ExtensionCode])
+	s.Equal(errcode.KindProtocol, errcode.GetErrorKind(gqlerror.List{rejected}))
+
+	presented = ErrorPresenter(ctx, errors.New("failed"))
+	s.Equal("failed", presented.Message)
+	s.Equal(code.InternalUnknownError, presented.Extensions[ExtensionCode])
+}
@@ -0,0 +1,84 @@
+package graphqlapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// the codes of the operations over the limits, errComplexityLimit is the one of extension.ComplexityLimit
+const (
+	errDepthLimit      = "DEPTH_LIMIT_EXCEEDED"
+	errComplexityLimit = "COMPLEXITY_LIMIT_EXCEEDED"
+)
+
+// the operations over the limits are rejected before they run like the invalid ones
+func init() {
+	errcode.RegisterErrorType(errDepthLimit, errcode.KindProtocol)
+	errcode.RegisterErrorType(errComplexityLimit, errcode.KindProtocol)
+}
+
+// DepthLimit rejects the operations nesting their fields deeper than MaxDepth, the introspection fields are not
+// counted so that the clients can read the schema
+type DepthLimit struct {
+	MaxDepth int
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = DepthLimit{}
+
+// ExtensionName .
+func (d DepthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+// Validate .
+func (d DepthLimit) Validate(graphql.ExecutableSchema) error {
+	if d.MaxDepth < 1 {
+		return fmt.Errorf("max depth must be positive, got %d", d.MaxDepth)
+	}
+	return nil
+}
+
+// MutateOperationContext rejects the operation before it is executed
+func (d DepthLimit) MutateOperationContext(_ context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if depth := depthOf(rc.Operation.SelectionSet); depth > d.MaxDepth {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.MaxDepth)
+		errcode.Set(err, errDepthLimit)
+		return err
+	}
+	return nil
+}
+
+// depthOf returns how deep the fields of a selection set nest, the fragments don't add a level
+func depthOf(selections ast.SelectionSet) int {
+	depth := 0
+	for _, selection := range selections {
+		d := 0
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			d = 1 + depthOf(s.SelectionSet)
+		case *ast.InlineFragment:
+			d = depthOf(s.SelectionSet)
+		case *ast.FragmentSpread:
+			// the validation has rejected the cycles and the unknown fragments
+			if s.Definition != nil {
+				d = depthOf(s.Definition.SelectionSet)
+			}
+		}
+		if d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
@@ -0,0 +1,19 @@
+package graphqlapi
+
+import (
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+func (s *graphqlAPISuite) TestDepth() {
+	for query, expected := range map[string]int{
+		`{ tasks { total } }`: 2,
+		`{ tasks { items { recurrence { frequency } } } total: __typename }`: 4,
+		`{ tasks { ... on TaskPage { items { id } } } }`:                     3,
+		`{ __schema { types { fields { type { ofType { name } } } } } }`:     0,
+	} {
+		doc, err := parser.ParseQuery(&ast.Source{Input: query})
+		s.Require().Nil(err)
+		s.Equal(expected, depthOf(doc.Operations[0].SelectionSet), query)
+	}
+}
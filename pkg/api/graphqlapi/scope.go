@@ -0,0 +1,53 @@
+package graphqlapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// RequireScope rejects the principals not granted ReadScope for the queries and the subscriptions or WriteScope
+// for the mutations, all of them are sent by POST so the scope can't follow the http method
+type RequireScope struct {
+	ReadScope  string
+	WriteScope string
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = RequireScope{}
+
+// ExtensionName .
+func (s RequireScope) ExtensionName() string {
+	return "RequireScope"
+}
+
+// Validate .
+func (s RequireScope) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationContext rejects the operation before it is executed
+func (s RequireScope) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	principal := auth.GetPrincipal(ctx)
+	if principal == nil {
+		return nil
+	}
+
+	scope := s.ReadScope
+	if rc.Operation.Operation == ast.Mutation {
+		scope = s.WriteScope
+	}
+	if !principal.HasScope(scope) {
+		return Error(code.NewCustomError(code.Forbidden, http.StatusForbidden, fmt.Errorf("scope %s is required", scope)))
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package graphqlapi
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+// keepAliveInterval is how often an idle subscription is pinged
+const keepAliveInterval = 15 * time.Second
+
+// NewServer creates the handler of a schema taking the operations as JSON by POST and the subscriptions over
+// WebSocket. The operations deeper than maxDepth or more complex than maxComplexity are rejected.
+func NewServer(schema graphql.ExecutableSchema, maxDepth, maxComplexity int) *handler.Server {
+	srv := handler.New(schema)
+	srv.AddTransport(transport.Websocket{KeepAlivePingInterval: keepAliveInterval})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(DepthLimit{MaxDepth: maxDepth})
+	srv.Use(extension.FixedComplexityLimit(maxComplexity))
+	srv.SetErrorPresenter(ErrorPresenter)
+	srv.SetRecoverFunc(Recover)
+	return srv
+}
@@ -0,0 +1,218 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	customlog "github.com/Yu-Qi/restful_api/pkg/custom_log"
+	"github.com/Yu-Qi/restful_api/pkg/ratelimit"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+)
+
+// MetadataRequestID is the metadata naming the request id of a call, one is generated when it is absent
+const MetadataRequestID = "x-request-id"
+
+// Stage prepares the context of a call like an http middleware, an error rejects the call
+type Stage func(ctx context.Context, fullMethod string) (context.Context, *code.CustomError)
+
+// UnaryInterceptor runs the stages in order before the unary calls and recovers from their panics
+func UnaryInterceptor(stages ...Stage) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer recoverPanic(&err)
+		ctx, err = runStages(ctx, info.FullMethod, stages)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor runs the stages in order before the streaming calls and recovers from their panics
+func StreamInterceptor(stages ...Stage) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recoverPanic(&err)
+		ctx, err := runStages(ss.Context(), info.FullMethod, stages)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverStream replaces the context of a stream by the one prepared by the stages
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func runStages(ctx context.Context, fullMethod string, stages []Stage) (context.Context, error) {
+	for _, stage := range stages {
+		var customErr *code.CustomError
+		ctx, customErr = stage(ctx, fullMethod)
+		if customErr != nil {
+			return nil, Error(ctx, customErr)
+		}
+	}
+	return ctx, nil
+}
+
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		customlog.Errorf("grpc panic: %v", r)
+		customlog.ErrorWithData("stack trace", string(debug.Stack()))
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
+
+// metadataValue returns the first value of a metadata key of the call
+func metadataValue(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// RequestContext copies the request id, the actor and the tenant of the metadata and the address of the peer
+// into the context of a call, the metadata keys are the lower case http headers
+func RequestContext(ctx context.Context, _ string) (context.Context, *code.CustomError) {
+	requestID := metadataValue(ctx, MetadataRequestID)
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	ctx = requestctx.WithRequestID(ctx, requestID)
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			ctx = requestctx.WithClientIP(ctx, host)
+		}
+	}
+	if actor := metadataValue(ctx, strings.ToLower(middleware.HeaderActor)); actor != "" {
+		ctx = requestctx.WithUnverifiedActor(ctx, actor)
+	}
+	if tenantID := metadataValue(ctx, strings.ToLower(middleware.HeaderTenantID)); tenantID != "" {
+		if !middleware.ValidTenantID(tenantID) {
+			return ctx, code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest,
+				fmt.Errorf("invalid %s metadata", strings.ToLower(middleware.HeaderTenantID)))
+		}
+		ctx = requestctx.WithTenantID(ctx, tenantID)
+	}
+	return ctx, nil
+}
+
+// Authenticate rejects the calls without valid credentials of one of the schemes in the authorization metadata,
+// and puts the principal of the credentials into the context
+func Authenticate(schemes ...middleware.AuthScheme) Stage {
+	names := make([]string, 0, len(schemes))
+	for _, scheme := range schemes {
+		names = append(names, scheme.Name)
+	}
+
+	return func(ctx context.Context, _ string) (context.Context, *code.CustomError) {
+		name, credentials, _ := strings.Cut(metadataValue(ctx, "authorization"), " ")
+		for _, scheme := range schemes {
+			if !strings.EqualFold(scheme.Name, name) || credentials == "" {
+				continue
+			}
+
+			principal, customErr := scheme.Verify(ctx, credentials)
+			if customErr != nil {
+				return ctx, customErr
+			}
+			if tenantID := metadataValue(ctx, strings.ToLower(middleware.HeaderTenantID)); tenantID != "" && tenantID != principal.BoundTenantID() {
+				return ctx, code.NewCustomError(code.Forbidden, http.StatusForbidden,
+					fmt.Errorf("credentials are not valid for tenant %s", tenantID))
+			}
+			ctx = requestctx.WithTenantID(ctx, principal.BoundTenantID())
+			ctx = auth.WithPrincipal(ctx, principal)
+			return requestctx.WithActor(ctx, principal.Subject), nil
+		}
+
+		return ctx, code.NewCustomError(code.Unauthorized, http.StatusUnauthorized,
+			fmt.Errorf("missing credentials, supported schemes: %s", strings.Join(names, ", ")))
+	}
+}
+
+// LimitFailedAuth rejects the clients whose credentials authenticate refused more than the limit, counted by ip
+// like middleware.LimitFailedAuth does for the http api. It wraps authenticate, the stages don't see the outcome
+// of the next ones.
+func LimitFailedAuth(store ratelimit.Store, limit ratelimit.Limit, authenticate Stage) Stage {
+	return func(ctx context.Context, fullMethod string) (context.Context, *code.CustomError) {
+		key := "auth failures ip:" + requestctx.GetClientIP(ctx)
+		result, err := store.Peek(ctx, key, limit, time.Now())
+		if err != nil {
+			customlog.ErrorfCtx(ctx, "rate limit store: %v", err)
+		} else if !result.Allowed {
+			return ctx, code.NewCustomError(code.TooManyRequests, http.StatusTooManyRequests,
+				fmt.Errorf("more than %d failed authentications per %s", limit.Requests, limit.Period))
+		}
+
+		ctx, customErr := authenticate(ctx, fullMethod)
+		if customErr != nil && customErr.HttpStatus == http.StatusUnauthorized {
+			if _, err := store.Take(ctx, key, limit, time.Now()); err != nil {
+				customlog.ErrorfCtx(ctx, "rate limit store: %v", err)
+			}
+		}
+		return ctx, customErr
+	}
+}
+
+// ResolveRole sets the role of the authenticated principal, it must come after Authenticate
+func ResolveRole(resolve func(ctx context.Context, principal *auth.Principal) (auth.Role, *code.CustomError)) Stage {
+	return func(ctx context.Context, _ string) (context.Context, *code.CustomError) {
+		principal := auth.GetPrincipal(ctx)
+		if principal == nil {
+			return ctx, nil
+		}
+
+		role, customErr := resolve(ctx, principal)
+		if customErr != nil {
+			return ctx, customErr
+		}
+		resolved := *principal
+		resolved.Role = role
+		return auth.WithPrincipal(ctx, &resolved), nil
+	}
+}
+
+// RequireScope rejects the principals not granted readScope for readMethods or writeScope for the other methods
+func RequireScope(readScope, writeScope string, readMethods ...string) Stage {
+	reads := map[string]bool{}
+	for _, method := range readMethods {
+		reads[method] = true
+	}
+
+	return func(ctx context.Context, fullMethod string) (context.Context, *code.CustomError) {
+		principal := auth.GetPrincipal(ctx)
+		if principal == nil {
+			return ctx, nil
+		}
+
+		scope := writeScope
+		if reads[fullMethod] {
+			scope = readScope
+		}
+		if !principal.HasScope(scope) {
+			return ctx, code.NewCustomError(code.Forbidden, http.StatusForbidden, fmt.Errorf("scope %s is required", scope))
+		}
+		return ctx, nil
+	}
+}
@@ -0,0 +1,54 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/ratelimit"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+)
+
+func TestInterceptorSuite(t *testing.T) {
+	suite.Run(t, new(interceptorSuite))
+}
+
+type interceptorSuite struct {
+	suite.Suite
+}
+
+func (s *interceptorSuite) TestLimitFailedAuth() {
+	// the calls with the "good" method are authenticated, the others are refused
+	authenticate := func(ctx context.Context, fullMethod string) (context.Context, *code.CustomError) {
+		if fullMethod != "good" {
+			return ctx, code.NewCustomError(code.Unauthorized, http.StatusUnauthorized, errors.New("invalid token"))
+		}
+		return ctx, nil
+	}
+	stage := LimitFailedAuth(ratelimit.NewInMemoryStore(), ratelimit.Limit{Requests: 2, Period: time.Hour}, authenticate)
+	call := func(fullMethod, clientIP string) int {
+		_, customErr := stage(requestctx.WithClientIP(context.Background(), clientIP), fullMethod)
+		if customErr == nil {
+			return 0
+		}
+		return customErr.Code
+	}
+
+	s.Equal(0, call("good", "10.0.0.1"))
+	s.Equal(code.Unauthorized, call("guess-1", "10.0.0.1"))
+	s.Equal(code.Unauthorized, call("guess-2", "10.0.0.1"))
+
+	// the ip is locked out, even with valid credentials
+	s.Equal(code.TooManyRequests, call("guess-3", "10.0.0.1"))
+	s.Equal(code.TooManyRequests, call("good", "10.0.0.1"))
+
+	// the other ips and the successful authentications are not counted
+	for i := 0; i < 3; i++ {
+		s.Equal(0, call("good", "10.0.0.2"))
+	}
+}
@@ -0,0 +1,102 @@
+// Package grpcapi holds what the gRPC services share: the mapping of the errors to statuses and the interceptors
+// setting the request context and the principal like the http middlewares.
+package grpcapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details
+const ErrorDomain = "restful_api"
+
+// metadata keys of the ErrorInfo details
+const (
+	MetadataCode       = "code"
+	MetadataHTTPStatus = "http_status"
+)
+
+// statusCodes maps the codes to the gRPC status codes, the other codes are mapped by their http status
+var statusCodes = map[int]codes.Code{
+	code.ParamIncorrect:       codes.InvalidArgument,
+	code.NotFound:             codes.NotFound,
+	code.Timeout:              codes.DeadlineExceeded,
+	code.BlockedByIncomplete:  codes.FailedPrecondition,
+	code.DependencyCycle:      codes.FailedPrecondition,
+	code.Unauthorized:         codes.Unauthenticated,
+	code.Forbidden:            codes.PermissionDenied,
+	code.TooManyRequests:      codes.ResourceExhausted,
+	code.IdempotencyKeyReused: codes.AlreadyExists,
+	code.UnsupportedMediaType: codes.InvalidArgument,
+	code.PatchFailed:          codes.FailedPrecondition,
+	code.NotAcceptable:        codes.InvalidArgument,
+	code.RequestTooLarge:      codes.ResourceExhausted,
+}
+
+var httpStatusCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.Aborted,
+	http.StatusPreconditionFailed:  codes.FailedPrecondition,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusNotImplemented:      codes.Unimplemented,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusGatewayTimeout:      codes.DeadlineExceeded,
+	http.StatusRequestTimeout:      codes.DeadlineExceeded,
+	http.StatusInternalServerError: codes.Internal,
+}
+
+// Error converts an error of the usecases to a status carrying an ErrorInfo, whose reason is the name of the code
+// and whose metadata holds the code and the http status, and a RequestInfo with the request id
+func Error(ctx context.Context, customErr *code.CustomError) error {
+	statusCode, ok := statusCodes[customErr.Code]
+	if !ok {
+		if statusCode, ok = httpStatusCodes[customErr.HttpStatus]; !ok {
+			statusCode = codes.Unknown
+		}
+	}
+	message := ""
+	if customErr.Error != nil {
+		message = customErr.Error.Error()
+	}
+
+	st := status.New(statusCode, message)
+	detailed, err := st.WithDetails(
+		&errdetails.ErrorInfo{
+			Reason: code.Name(customErr.Code),
+			Domain: ErrorDomain,
+			Metadata: map[string]string{
+				MetadataCode:       strconv.Itoa(customErr.Code),
+				MetadataHTTPStatus: strconv.Itoa(customErr.HttpStatus),
+			},
+		},
+		&errdetails.RequestInfo{RequestId: requestctx.GetRequestID(ctx)},
+	)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// CodeOf reads the code from the ErrorInfo of a status error, false when there is none
+func CodeOf(err error) (int, bool) {
+	for _, detail := range status.Convert(err).Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.GetDomain() != ErrorDomain {
+			continue
+		}
+		c, convErr := strconv.Atoi(info.GetMetadata()[MetadataCode])
+		return c, convErr == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,47 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+func TestStatusSuite(t *testing.T) {
+	suite.Run(t, new(statusSuite))
+}
+
+type statusSuite struct {
+	suite.Suite
+}
+
+func (s *statusSuite) TestError() {
+	for _, tc := range []struct {
+		customErr *code.CustomError
+		expected  codes.Code
+	}{
+		{code.NewCustomError(code.NotFound, http.StatusNotFound, errors.New("task not found")), codes.NotFound},
+		{code.NewCustomError(code.BlockedByIncomplete, http.StatusConflict, errors.New("blocked")), codes.FailedPrecondition},
+		// the codes without a status code are mapped by their http status
+		{code.NewCustomError(code.InternalUnknownError, http.StatusServiceUnavailable, errors.New("disabled")), codes.Unavailable},
+		{code.NewCustomError(code.InternalUnknownError, http.StatusInternalServerError, errors.New("failed")), codes.Internal},
+		{code.NewCustomError(code.InternalUnknownError, http.StatusTeapot, nil), codes.Unknown},
+	} {
+		err := Error(context.Background(), tc.customErr)
+		s.Equal(tc.expected, status.Code(err), tc.customErr.Code)
+		c, ok := CodeOf(err)
+		s.True(ok)
+		s.Equal(tc.customErr.Code, c)
+	}
+
+	_, ok := CodeOf(status.Error(codes.Internal, "no details"))
+	s.False(ok)
+	_, ok = CodeOf(errors.New("not a status"))
+	s.False(ok)
+}
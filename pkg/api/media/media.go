@@ -0,0 +1,181 @@
+// Package media negotiates the media types of the request and response bodies and converts between them.
+// The YAML and MessagePack documents mirror the JSON ones, they carry the same fields by the json tags.
+package media
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+	"gopkg.in/yaml.v3"
+)
+
+// media types of the bodies
+const (
+	MIMEJSON     = "application/json"
+	MIMEYAML     = "application/yaml"
+	MIMEMsgPack  = "application/msgpack"
+	MIMEProtobuf = "application/x-protobuf"
+)
+
+// aliases maps the other names of the media types in use to the ones above
+var aliases = map[string]string{
+	"application/x-yaml":    MIMEYAML,
+	"text/yaml":             MIMEYAML,
+	"application/x-msgpack": MIMEMsgPack,
+	"application/protobuf":  MIMEProtobuf,
+}
+
+var msgpackHandle = func() *codec.MsgpackHandle {
+	handle := &codec.MsgpackHandle{}
+	handle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	handle.RawToString = true
+	handle.WriteExt = true
+	return handle
+}()
+
+// Canonical returns the media type of a Content-Type header without the parameters, "" when it is malformed
+func Canonical(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if alias, ok := aliases[mediaType]; ok {
+		return alias
+	}
+	return mediaType
+}
+
+// Negotiate picks the offered media type preferred by an Accept header by the q values, an exact range wins over
+// a wildcard one. The first offered type is picked when accept is empty and "" when none is acceptable.
+func Negotiate(accept string, offered ...string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offered[0]
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if alias, ok := aliases[mediaType]; ok {
+			mediaType = alias
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+
+	best, bestQ := "", 0.0
+	for _, candidate := range offered {
+		// specificity: 3 for an exact range, 2 for type/*, 1 for */*
+		q, specificity := 0.0, 0
+		for _, r := range ranges {
+			s := 0
+			switch {
+			case r.mediaType == candidate:
+				s = 3
+			case strings.HasSuffix(r.mediaType, "/*") && strings.HasPrefix(candidate, strings.TrimSuffix(r.mediaType, "*")):
+				s = 2
+			case r.mediaType == "*/*":
+				s = 1
+			}
+			if s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = candidate, q
+		}
+	}
+	return best
+}
+
+// Marshal encodes v as JSON, YAML or MessagePack
+func Marshal(mediaType string, v interface{}) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil || mediaType == MIMEJSON {
+		return body, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc interface{}
+	if err = decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	doc = fromJSONNumbers(doc)
+
+	switch mediaType {
+	case MIMEYAML:
+		return yaml.Marshal(doc)
+	case MIMEMsgPack:
+		var out []byte
+		err = codec.NewEncoderBytes(&out, msgpackHandle).Encode(doc)
+		return out, err
+	}
+	return nil, fmt.Errorf("media type %s is not supported", mediaType)
+}
+
+// Unmarshal decodes a JSON, YAML or MessagePack body into v by the json tags
+func Unmarshal(mediaType string, body []byte, v interface{}) error {
+	if mediaType == MIMEJSON {
+		return json.Unmarshal(body, v)
+	}
+
+	var doc interface{}
+	var err error
+	switch mediaType {
+	case MIMEYAML:
+		err = yaml.Unmarshal(body, &doc)
+	case MIMEMsgPack:
+		err = codec.NewDecoderBytes(body, msgpackHandle).Decode(&doc)
+	default:
+		err = fmt.Errorf("media type %s is not supported", mediaType)
+	}
+	if err != nil {
+		return err
+	}
+
+	// mirror the document as JSON
+	body, err = json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// fromJSONNumbers replaces the json.Number values by int64 or float64, the other encoders take them for strings
+func fromJSONNumbers(doc interface{}) interface{} {
+	switch value := doc.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	case map[string]interface{}:
+		for k, v := range value {
+			value[k] = fromJSONNumbers(v)
+		}
+	case []interface{}:
+		for i, v := range value {
+			value[i] = fromJSONNumbers(v)
+		}
+	}
+	return doc
+}
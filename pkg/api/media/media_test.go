@@ -0,0 +1,75 @@
+package media
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMediaSuite(t *testing.T) {
+	suite.Run(t, new(mediaSuite))
+}
+
+type mediaSuite struct {
+	suite.Suite
+}
+
+func (s *mediaSuite) TestNegotiate() {
+	offered := []string{MIMEJSON, MIMEYAML, MIMEMsgPack}
+	for accept, expected := range map[string]string{
+		"":                                  MIMEJSON,
+		"*/*":                               MIMEJSON,
+		"application/yaml":                  MIMEYAML,
+		"application/x-yaml":                MIMEYAML,
+		"text/html, application/msgpack":    MIMEMsgPack,
+		"application/json;q=0.5, text/yaml": MIMEYAML,
+		"application/*;q=0.2, application/msgpack;q=0.9, application/json;q=0.1": MIMEMsgPack,
+		"*/*;q=0.1, application/json;q=0":                                        MIMEYAML,
+		"text/html":                                                              "",
+		"application/yaml;q=0":                                                   "",
+		"application/yaml;q=x":                                                   "",
+	} {
+		s.Equal(expected, Negotiate(accept, offered...), accept)
+	}
+}
+
+func (s *mediaSuite) TestCanonical() {
+	s.Equal(MIMEJSON, Canonical("application/json; charset=utf-8"))
+	s.Equal(MIMEMsgPack, Canonical("application/x-msgpack"))
+	s.Equal(MIMEProtobuf, Canonical("application/protobuf"))
+	s.Equal("", Canonical(""))
+}
+
+type document struct {
+	Name   string     `json:"name"`
+	Count  int        `json:"count"`
+	Ratio  float64    `json:"ratio"`
+	DueAt  *time.Time `json:"due_at,omitempty"`
+	Labels []string   `json:"labels"`
+}
+
+func (s *mediaSuite) TestRoundTrip() {
+	dueAt := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	expected := &document{Name: "task", Count: 3, Ratio: 0.5, DueAt: &dueAt, Labels: []string{"a", "b"}}
+
+	for _, mediaType := range []string{MIMEJSON, MIMEYAML, MIMEMsgPack} {
+		body, err := Marshal(mediaType, expected)
+		s.NoError(err, mediaType)
+		actual := &document{}
+		s.NoError(Unmarshal(mediaType, body, actual), mediaType)
+		s.Equal(expected, actual, mediaType)
+	}
+
+	body, err := Marshal(MIMEYAML, expected)
+	s.NoError(err)
+	s.Equal("count: 3\ndue_at: \"2030-01-01T09:00:00Z\"\nlabels:\n    - a\n    - b\nname: task\nratio: 0.5\n", string(body))
+
+	// an unquoted timestamp is decoded too
+	actual := &document{}
+	s.NoError(Unmarshal(MIMEYAML, []byte("name: task\ndue_at: 2030-01-01T09:00:00Z\n"), actual))
+	s.Equal(dueAt, actual.DueAt.UTC())
+
+	s.Error(Unmarshal(MIMEYAML, []byte("name: [\n"), actual))
+	s.Error(Unmarshal("text/plain", []byte("task"), actual))
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// APIKeyScheme accepts `Authorization: ApiKey <key>`, verify resolves the principal owning the key
+func APIKeyScheme(verify func(ctx context.Context, key string) (*auth.Principal, *code.CustomError)) AuthScheme {
+	return AuthScheme{
+		Name:   "ApiKey",
+		Verify: verify,
+	}
+}
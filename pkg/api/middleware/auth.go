@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthScheme verifies the credentials following a scheme name in the Authorization header
+type AuthScheme struct {
+	Name   string
+	Verify func(ctx context.Context, credentials string) (*auth.Principal, *code.CustomError)
+}
+
+// BearerScheme accepts `Authorization: Bearer <jwt>`
+func BearerScheme(verifier *auth.JWTVerifier) AuthScheme {
+	return AuthScheme{
+		Name: "Bearer",
+		Verify: func(ctx context.Context, token string) (*auth.Principal, *code.CustomError) {
+			principal, err := verifier.Verify(token)
+			if err != nil {
+				return nil, code.NewCustomError(code.Unauthorized, http.StatusUnauthorized, err)
+			}
+			return principal, nil
+		},
+	}
+}
+
+// Authenticate rejects the requests without valid credentials of one of the schemes,
+// and puts the principal of the credentials into the request context.
+func Authenticate(schemes ...AuthScheme) gin.HandlerFunc {
+	names := make([]string, 0, len(schemes))
+	for _, scheme := range schemes {
+		names = append(names, scheme.Name)
+	}
+
+	return func(c *gin.Context) {
+		name, credentials, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		for _, scheme := range schemes {
+			if !strings.EqualFold(scheme.Name, name) || credentials == "" {
+				continue
+			}
+
+			principal, customErr := scheme.Verify(c, credentials)
+			if customErr != nil {
+				response.CustomError(c, customErr)
+				return
+			}
+			if tenantID := c.GetHeader(HeaderTenantID); tenantID != "" && tenantID != principal.BoundTenantID() {
+				response.ErrorWithMsg(c, http.StatusForbidden, code.Forbidden, fmt.Sprintf("credentials are not valid for tenant %s", tenantID))
+				return
+			}
+			setPrincipal(c, principal)
+			c.Next()
+			return
+		}
+
+		response.ErrorWithMsg(c, http.StatusUnauthorized, code.Unauthorized,
+			fmt.Sprintf("missing credentials, supported schemes: %s", strings.Join(names, ", ")))
+	}
+}
+
+// RequireScope rejects the principals not granted readScope for safe methods or writeScope for the others
+func RequireScope(readScope, writeScope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := auth.GetPrincipal(c)
+		if principal == nil {
+			c.Next()
+			return
+		}
+
+		scope := writeScope
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			scope = readScope
+		}
+		if !principal.HasScope(scope) {
+			response.ErrorWithMsg(c, http.StatusForbidden, code.Forbidden, fmt.Sprintf("scope %s is required", scope))
+			return
+		}
+		c.Next()
+	}
+}
+
+// setPrincipal puts the principal into the request context, it is the actor of the request as well,
+// and the request works on the tenant the principal is bound to
+func setPrincipal(c *gin.Context, principal *auth.Principal) {
+	ctx := auth.WithPrincipal(c.Request.Context(), principal)
+	ctx = requestctx.WithActor(ctx, principal.Subject)
+	ctx = requestctx.WithTenantID(ctx, principal.BoundTenantID())
+	c.Request = c.Request.WithContext(ctx)
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// DefaultMaxBodySize is the size in bytes of the longest request body
+const DefaultMaxBodySize int64 = 10 << 20
+
+// maxBodySizeKey keeps the limit of LimitBody on the gin context, for the middlewares replacing the body
+const maxBodySizeKey = "max_body_size"
+
+// LimitBody rejects the request bodies longer than maxBytes with a 413. A body with a Content-Length over the limit
+// is rejected at once, the others fail to read past maxBytes and util.BodyError turns the error into the 413.
+func LimitBody(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			response.ErrorWithMsg(c, http.StatusRequestEntityTooLarge, code.RequestTooLarge,
+				fmt.Sprintf("the request body is larger than %d bytes", maxBytes))
+			return
+		}
+		c.Set(maxBodySizeKey, maxBytes)
+		c.Request.Body = limitBody(c, c.Request.Body)
+		c.Next()
+	}
+}
+
+// limitBody caps body to the limit of LimitBody, body is returned as is when there is no limit
+func limitBody(c *gin.Context, body io.ReadCloser) io.ReadCloser {
+	maxBytes, ok := c.Get(maxBodySizeKey)
+	if !ok || body == nil || body == http.NoBody {
+		return body
+	}
+	return http.MaxBytesReader(c.Writer, body, maxBytes.(int64))
+}
@@ -0,0 +1,250 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// content codings of the bodies
+const (
+	EncodingGzip   = "gzip"
+	EncodingBrotli = "br"
+)
+
+// DefaultCompressMinSize is the size under which a response is sent uncompressed, the coding would not pay off
+const DefaultCompressMinSize = 1024
+
+// encoders keeps the writers for reuse, they allocate large windows
+var encoders = map[string]*sync.Pool{
+	EncodingBrotli: {New: func() interface{} { return brotli.NewWriter(nil) }},
+	EncodingGzip: {New: func() interface{} {
+		writer, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return writer
+	}},
+}
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressWriter holds the response until minSize bytes are written, then it sends them compressed.
+// A response ending shorter, or which turns out not to be compressible, is sent as is.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+	buffer   []byte
+	started  bool
+	encoder  encoder
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.started {
+		w.buffer = append(w.buffer, b...)
+		if len(w.buffer) >= w.minSize {
+			if err := w.start(true); err != nil {
+				return 0, err
+			}
+		}
+		return len(b), nil
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush starts compressing a streamed response even under minSize, its chunks add up
+func (w *compressWriter) Flush() {
+	if !w.started {
+		if err := w.start(true); err != nil {
+			return
+		}
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.started = true
+	return w.ResponseWriter.Hijack()
+}
+
+// compressible tells whether the headers set by the handlers allow coding the body
+func (w *compressWriter) compressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if strings.HasPrefix(header.Get("Content-Type"), "text/event-stream") {
+		return false
+	}
+	status := w.Status()
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// start sends the headers and the buffered bytes, compressed when compress is set and the response allows it
+func (w *compressWriter) start(compress bool) error {
+	w.started = true
+	if compress && w.compressible() {
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		// the representation changes, a strong validator of the identity body does not match it any more
+		if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			header.Set("ETag", "W/"+etag)
+		}
+		w.encoder = encoders[w.encoding].Get().(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+
+	buffer := w.buffer
+	w.buffer = nil
+	if len(buffer) == 0 {
+		return nil
+	}
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(buffer)
+	} else {
+		_, err = w.ResponseWriter.Write(buffer)
+	}
+	return err
+}
+
+// finish sends what is still held and ends the coded stream
+func (w *compressWriter) finish() {
+	if !w.started {
+		_ = w.start(false)
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+		encoders[w.encoding].Put(w.encoder)
+		w.encoder = nil
+	}
+}
+
+// Compress codes the responses of at least minSize bytes with brotli or gzip, picked by the Accept-Encoding header,
+// and decodes the gzip request bodies so that the handlers bind plain ones.
+// The server-sent event streams and the WebSocket upgrades are left as is.
+func Compress(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !decodeRequestBody(c) {
+			return
+		}
+
+		if c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, minSize: minSize}
+		c.Writer = writer
+		defer func() {
+			writer.finish()
+			c.Writer = writer.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// decodeRequestBody replaces a gzip request body by its content, it answers a 415 to the other codings.
+// The content longer than the limit of LimitBody fails to read like a longer body.
+func decodeRequestBody(c *gin.Context) bool {
+	contentEncoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+	switch contentEncoding {
+	case "", "identity":
+		return true
+	case EncodingGzip, "x-gzip":
+	default:
+		c.Header("Accept-Encoding", EncodingGzip)
+		response.ErrorWithMsg(c, http.StatusUnsupportedMediaType, code.UnsupportedMediaType,
+			fmt.Sprintf("content encoding %s is not supported", contentEncoding))
+		return false
+	}
+
+	reader, err := gzip.NewReader(c.Request.Body)
+	if err != nil {
+		response.ErrorWithMsg(c, http.StatusBadRequest, code.ParamIncorrect, "invalid gzip body: "+err.Error())
+		return false
+	}
+	// the content is held to the body limit too, a small body can decode to gigabytes
+	c.Request.Body = limitBody(c, &gzipBody{Reader: reader, body: c.Request.Body})
+	c.Request.Header.Del("Content-Encoding")
+	c.Request.Header.Del("Content-Length")
+	c.Request.ContentLength = -1
+	return true
+}
+
+// gzipBody closes the gzip reader and the request body under it
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	_ = b.Reader.Close()
+	return b.body.Close()
+}
+
+// negotiateEncoding picks the coding preferred by an Accept-Encoding header, brotli on a tie, "" for none
+func negotiateEncoding(acceptEncoding string) string {
+	qValues := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			var err error
+			if q, err = strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+				continue
+			}
+		}
+		if coding == "x-gzip" {
+			coding = EncodingGzip
+		}
+		qValues[coding] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, coding := range []string{EncodingBrotli, EncodingGzip} {
+		q, ok := qValues[coding]
+		if !ok {
+			q, ok = qValues["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
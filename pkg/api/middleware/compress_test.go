@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+)
+
+func TestCompressSuite(t *testing.T) {
+	suite.Run(t, new(compressSuite))
+}
+
+type compressSuite struct {
+	suite.Suite
+	Router *gin.Engine
+}
+
+type echoParams struct {
+	Name string `json:"name" binding:"required"`
+}
+
+var largeBody = strings.Repeat("task ", 1000)
+
+// maxTestBodySize is the body limit of the suite
+const maxTestBodySize = 1 << 10
+
+func (s *compressSuite) SetupSuite() {
+	s.Router = gin.New()
+	s.Router.Use(LimitBody(maxTestBodySize), Compress(DefaultCompressMinSize))
+	s.Router.GET("/large", func(c *gin.Context) {
+		response.OK(c, largeBody)
+	})
+	s.Router.GET("/small", func(c *gin.Context) {
+		response.OK(c, "task")
+	})
+	s.Router.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "application/x-ndjson")
+		_, _ = c.Writer.WriteString("{}\n")
+		c.Writer.Flush()
+		_, _ = c.Writer.WriteString("{}\n")
+	})
+	s.Router.GET("/events", func(c *gin.Context) {
+		c.Header("Content-Type", sse.ContentType)
+		_, _ = c.Writer.WriteString(largeBody)
+		c.Writer.Flush()
+	})
+	s.Router.POST("/echo", func(c *gin.Context) {
+		params := echoParams{}
+		if customErr := util.ToGinContextExt(c).BindJson(&params); customErr != nil {
+			response.CustomError(c, customErr)
+			return
+		}
+		response.OK(c, params.Name)
+	})
+}
+
+func (s *compressSuite) get(path, acceptEncoding string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", path, nil)
+	s.NoError(err)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	s.Router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *compressSuite) TestResponse() {
+	w := s.get("/large", "gzip, deflate")
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal(EncodingGzip, w.Header().Get("Content-Encoding"))
+	s.Equal([]string{"Accept-Encoding", "Accept"}, w.Header().Values("Vary"))
+	s.Less(w.Body.Len(), len(largeBody))
+	reader, err := gzip.NewReader(w.Body)
+	s.NoError(err)
+	body, err := io.ReadAll(reader)
+	s.NoError(err)
+	s.Contains(string(body), largeBody)
+
+	w = s.get("/large", "gzip;q=0.5, br")
+	s.Equal(EncodingBrotli, w.Header().Get("Content-Encoding"))
+	body, err = io.ReadAll(brotli.NewReader(w.Body))
+	s.NoError(err)
+	s.Contains(string(body), largeBody)
+
+	// identity
+	for _, acceptEncoding := range []string{"", "identity", "gzip;q=0, br;q=0"} {
+		w = s.get("/large", acceptEncoding)
+		s.Empty(w.Header().Get("Content-Encoding"), acceptEncoding)
+		s.Contains(w.Body.String(), largeBody, acceptEncoding)
+		s.Equal("Accept-Encoding", w.Header().Get("Vary"), acceptEncoding)
+	}
+
+	// under the threshold
+	w = s.get("/small", "gzip")
+	s.Empty(w.Header().Get("Content-Encoding"))
+	s.Equal(`{"code":0,"data":"task"}`, w.Body.String())
+	s.Equal("Accept-Encoding", w.Header().Get("Vary"))
+}
+
+func (s *compressSuite) TestStream() {
+	w := s.get("/stream", "gzip")
+	s.Equal(EncodingGzip, w.Header().Get("Content-Encoding"))
+	reader, err := gzip.NewReader(w.Body)
+	s.NoError(err)
+	body, err := io.ReadAll(reader)
+	s.NoError(err)
+	s.Equal("{}\n{}\n", string(body))
+
+	// server-sent events are never compressed
+	w = s.get("/events", "gzip")
+	s.Empty(w.Header().Get("Content-Encoding"))
+	s.Equal(largeBody, w.Body.String())
+}
+
+func (s *compressSuite) TestRequest() {
+	post := func(body io.Reader, contentEncoding string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/echo", body)
+		s.NoError(err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", contentEncoding)
+		s.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	compressed := &bytes.Buffer{}
+	writer := gzip.NewWriter(compressed)
+	_, err := writer.Write([]byte(`{"name": "task"}`))
+	s.NoError(err)
+	s.NoError(writer.Close())
+	w := post(compressed, "gzip")
+	s.Equal(http.StatusOK, w.Code, w.Body.String())
+	s.Equal(`{"code":0,"data":"task"}`, w.Body.String())
+
+	s.Equal(http.StatusBadRequest, post(strings.NewReader(`{"name": "task"}`), "gzip").Code)
+	w = post(strings.NewReader(`{"name": "task"}`), "deflate")
+	s.Equal(http.StatusUnsupportedMediaType, w.Code)
+	s.Equal(EncodingGzip, w.Header().Get("Accept-Encoding"))
+	s.Equal(http.StatusOK, post(strings.NewReader(`{"name": "task"}`), "identity").Code)
+}
+
+func (s *compressSuite) TestRequestTooLarge() {
+	compressed := &bytes.Buffer{}
+	writer := gzip.NewWriter(compressed)
+	_, err := writer.Write([]byte(`{"name": "` + strings.Repeat("a", 64*maxTestBodySize) + `"}`))
+	s.NoError(err)
+	s.NoError(writer.Close())
+	s.Less(compressed.Len(), maxTestBodySize)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", "/echo", compressed)
+	s.NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", EncodingGzip)
+	s.Router.ServeHTTP(w, req)
+	s.Equal(http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+	s.Contains(w.Body.String(), fmt.Sprintf(`"code":%d`, code.RequestTooLarge))
+}
+
+func (s *compressSuite) TestNegotiateEncoding() {
+	for acceptEncoding, expected := range map[string]string{
+		"":                      "",
+		"identity":              "",
+		"gzip":                  EncodingGzip,
+		"x-gzip":                EncodingGzip,
+		"GZIP, br":              EncodingBrotli,
+		"br;q=0.5, gzip":        EncodingGzip,
+		"*":                     EncodingBrotli,
+		"*;q=0.5, gzip;q=0.8":   EncodingGzip,
+		"br;q=0, *":             EncodingGzip,
+		"br;q=x, deflate":       "",
+		"gzip ; q=0.1, br;q=0 ": EncodingGzip,
+	} {
+		s.Equal(expected, negotiateEncoding(acceptEncoding), acceptEncoding)
+	}
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	customlog "github.com/Yu-Qi/restful_api/pkg/custom_log"
+	"github.com/Yu-Qi/restful_api/pkg/idempotency"
+	"github.com/Yu-Qi/restful_api/pkg/lock"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderIdempotencyKey is the header a client sets to make the retries of a POST replay the first response
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// HeaderIdempotentReplayed marks a replayed response
+const HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+const (
+	idempotencyLockWaitSecond = 5
+	// idempotencyLockStripes bounds the locks kept by the LockMap, the keys share the locks by hash
+	idempotencyLockStripes  = 1024
+	maxIdempotencyKeyLength = 255
+)
+
+// responseRecorder keeps a copy of the response written by the handlers
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency replays the first response of a POST to the retries with the same Idempotency-Key for ttl.
+// The keys are scoped to the tenant and the client, so it must come after Authenticate,
+// reusing a key for another request is rejected and the concurrent retries wait for the first one.
+func Idempotency(store idempotency.Store, ttl time.Duration) gin.HandlerFunc {
+	locks := lock.NewLockMap(idempotencyLockWaitSecond)
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderIdempotencyKey)
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			response.ErrorWithMsg(c, http.StatusBadRequest, code.ParamIncorrect,
+				fmt.Sprintf("%s is longer than %d characters", HeaderIdempotencyKey, maxIdempotencyKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			response.CustomError(c, util.BodyError(err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := sha256.Sum256(append([]byte(c.Request.Method+" "+c.Request.URL.RequestURI()+"\n"), body...))
+
+		scopedKey := requestctx.GetTenantID(c) + " " + clientKey(c) + " " + key
+		stripe := lockStripe(scopedKey)
+		if err = locks.Lock(stripe); err != nil {
+			response.ErrorWithMsg(c, http.StatusConflict, code.Timeout,
+				fmt.Sprintf("a request with the same %s is in progress", HeaderIdempotencyKey))
+			return
+		}
+		defer locks.Unlock(stripe)
+
+		record, err := store.Get(c, scopedKey, time.Now())
+		if err != nil {
+			customlog.ErrorfCtx(c, "idempotency store: %v", err)
+			response.ErrorWithMsg(c, http.StatusInternalServerError, code.InternalUnknownError, "idempotency store is unavailable")
+			return
+		}
+		if record != nil {
+			if record.Fingerprint != hex.EncodeToString(fingerprint[:]) {
+				response.ErrorWithMsg(c, http.StatusUnprocessableEntity, code.IdempotencyKeyReused,
+					fmt.Sprintf("%s was used by another request", HeaderIdempotencyKey))
+				return
+			}
+			replay(c, record.Response)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// the server errors may be gone on a retry
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+		err = store.Put(c, scopedKey, &idempotency.Record{
+			Fingerprint: hex.EncodeToString(fingerprint[:]),
+			Response: &idempotency.Response{
+				Status: recorder.Status(),
+				Header: http.Header{"Content-Type": recorder.Header().Values("Content-Type")},
+				Body:   recorder.body.Bytes(),
+			},
+			ExpiresAt: time.Now().Add(ttl),
+		})
+		if err != nil {
+			customlog.ErrorfCtx(c, "idempotency store: %v", err)
+		}
+	}
+}
+
+func replay(c *gin.Context, stored *idempotency.Response) {
+	for name, values := range stored.Header {
+		c.Writer.Header()[name] = values
+	}
+	c.Header(HeaderIdempotentReplayed, "true")
+	c.Status(stored.Status)
+	_, _ = c.Writer.Write(stored.Body)
+	c.Abort()
+}
+
+func lockStripe(key string) uint32 {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return hash.Sum32() % idempotencyLockStripes
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/media"
+	"github.com/Yu-Qi/restful_api/pkg/api/openapi"
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+)
+
+// ValidateOpenAPIRequest rejects the requests which don't match the OpenAPI document of their route with
+// the violations in the details of the error, and a body longer than the limit of LimitBody with a 413.
+// It should be registered after Authenticate, so that the anonymous callers can't make the server read their bodies,
+// and after Compress, so the bodies are checked uncompressed.
+func ValidateOpenAPIRequest(validator *openapi.Validator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		violations, err := validator.ValidateRequest(c)
+		if err != nil {
+			response.CustomError(c, util.BodyError(err))
+			return
+		}
+		if len(violations) > 0 {
+			response.ErrorWithDetails(c, http.StatusBadRequest, code.ParamIncorrect, violationsMessage(violations), violations)
+			return
+		}
+		c.Next()
+	}
+}
+
+// ValidateOpenAPIResponse passes the violations of the responses which don't match the OpenAPI document of their
+// route to onInvalidResponse, which is meant for the tests as every response is kept in memory
+func ValidateOpenAPIResponse(validator *openapi.Validator, onInvalidResponse func(c *gin.Context, violations []*response.FieldError)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		contentType := recorder.Header().Get("Content-Type")
+		// the streams and the upgraded connections have no body to check as a whole
+		if recorder.Status() == http.StatusSwitchingProtocols || media.Canonical(contentType) == sse.ContentType {
+			return
+		}
+		if violations := validator.ValidateResponse(c, recorder.Status(), contentType, recorder.body.Bytes()); len(violations) > 0 {
+			onInvalidResponse(c, violations)
+		}
+	}
+}
+
+// violationsMessage lists the violations like "query.page: must be at least 1; body.name: is required"
+func violationsMessage(violations []*response.FieldError) string {
+	messages := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		location := violation.In
+		if violation.Field != "" {
+			location += "." + violation.Field
+		}
+		messages = append(messages, location+": "+violation.Message)
+	}
+	return "request does not match the api, " + strings.Join(messages, "; ")
+}
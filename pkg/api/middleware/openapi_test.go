@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/openapi"
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+func TestValidateOpenAPISuite(t *testing.T) {
+	suite.Run(t, new(validateOpenAPISuite))
+}
+
+type validateOpenAPISuite struct {
+	suite.Suite
+	Router     *gin.Engine
+	violations []*response.FieldError
+}
+
+func (s *validateOpenAPISuite) SetupTest() {
+	spec := openapi.NewSpec(openapi.Info{Title: "test", Version: "1"})
+	spec.Add("echo",
+		&openapi.Route{Method: http.MethodPost, Path: "/echo", Body: echoParams{}, Response: ""},
+		&openapi.Route{Method: http.MethodGet, Path: "/count", Response: 0},
+	)
+
+	s.violations = nil
+	s.Router = gin.New()
+	validator := spec.Validator(s.Router)
+	s.Router.Use(LimitBody(maxTestBodySize), ValidateOpenAPIResponse(validator, func(c *gin.Context, violations []*response.FieldError) {
+		s.violations = append(s.violations, violations...)
+	}), ValidateOpenAPIRequest(validator))
+	s.Router.POST("/echo", func(c *gin.Context) {
+		params := map[string]interface{}{}
+		s.NoError(c.ShouldBindJSON(&params))
+		response.OK(c, params["name"])
+	})
+	s.Router.GET("/count", func(c *gin.Context) {
+		response.OK(c, "many")
+	})
+}
+
+func (s *validateOpenAPISuite) post(body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", "/echo", strings.NewReader(body))
+	s.NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	s.Router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *validateOpenAPISuite) TestRequest() {
+	w := s.post(`{"name": "task"}`)
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal(`{"code":0,"data":"task"}`, w.Body.String())
+
+	w = s.post(`{}`)
+	s.Equal(http.StatusBadRequest, w.Code)
+	resp := response.ErrorResp{}
+	s.NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	s.Equal(code.ParamIncorrect, resp.Code)
+	s.Equal("request does not match the api, body.name: is required", resp.Message)
+	s.Equal([]*response.FieldError{{In: "body", Field: "name", Message: "is required"}}, resp.Details)
+	s.Empty(s.violations)
+}
+
+func (s *validateOpenAPISuite) TestRequestTooLarge() {
+	req := httptest.NewRequest("POST", "/echo", strings.NewReader(`{"name": "`+strings.Repeat("a", maxTestBodySize)+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+	s.Router.ServeHTTP(w, req)
+	s.Equal(http.StatusRequestEntityTooLarge, w.Code)
+	resp := response.ErrorResp{}
+	s.NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	s.Equal(code.RequestTooLarge, resp.Code)
+}
+
+func (s *validateOpenAPISuite) TestResponse() {
+	s.Equal(http.StatusOK, s.post(`{"name": "task", "extra": 1}`).Code)
+	s.Empty(s.violations)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/count", nil)
+	s.NoError(err)
+	s.Router.ServeHTTP(w, req)
+	// the response is sent as it is
+	s.Equal(`{"code":0,"data":"many"}`, w.Body.String())
+	s.Equal([]*response.FieldError{{In: "body", Field: "data", Message: "must be integer"}}, s.violations)
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	customlog "github.com/Yu-Qi/restful_api/pkg/custom_log"
+	"github.com/Yu-Qi/restful_api/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit limits the requests of every client to each route by the rules, the routes without a rule are not limited.
+// A client is identified by its api key, its user or its ip, so it must come after Authenticate,
+// see LimitFailedAuth for the requests refused by Authenticate.
+func RateLimit(store ratelimit.Store, rules ratelimit.Rules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, ok := rules.Match(c.Request.Method, c.FullPath())
+		if !ok {
+			c.Next()
+			return
+		}
+
+		key := c.Request.Method + " " + c.FullPath() + " " + clientKey(c)
+		result, err := store.Take(c, key, limit, time.Now())
+		if err != nil {
+			// the api stays available when the store is down
+			customlog.ErrorfCtx(c, "rate limit store: %v", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", ceilSeconds(result.Reset))
+		if !result.Allowed {
+			c.Header("Retry-After", ceilSeconds(result.RetryAfter))
+			response.ErrorWithMsg(c, http.StatusTooManyRequests, code.TooManyRequests,
+				fmt.Sprintf("rate limit of %d requests per %s exceeded", limit.Requests, limit.Period))
+			return
+		}
+		c.Next()
+	}
+}
+
+// LimitFailedAuth rejects the clients whose credentials were refused more than the limit, counted by ip,
+// so that credentials cannot be guessed. It must come before Authenticate, which does not see the client otherwise.
+func LimitFailedAuth(store ratelimit.Store, limit ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := "auth failures ip:" + c.ClientIP()
+		result, err := store.Peek(c, key, limit, time.Now())
+		if err != nil {
+			customlog.ErrorfCtx(c, "rate limit store: %v", err)
+		} else if !result.Allowed {
+			c.Header("Retry-After", ceilSeconds(result.RetryAfter))
+			response.ErrorWithMsg(c, http.StatusTooManyRequests, code.TooManyRequests,
+				fmt.Sprintf("more than %d failed authentications per %s", limit.Requests, limit.Period))
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() == http.StatusUnauthorized {
+			if _, err := store.Take(c, key, limit, time.Now()); err != nil {
+				customlog.ErrorfCtx(c, "rate limit store: %v", err)
+			}
+		}
+	}
+}
+
+// clientKey identifies the client of a request
+func clientKey(c *gin.Context) string {
+	return ratelimit.ClientKey(auth.GetPrincipal(c), c.ClientIP())
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
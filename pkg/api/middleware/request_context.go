@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+	"github.com/gin-contrib/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderActor is the header naming the actor of an anonymous request, it is recorded as unverified
+const HeaderActor = "X-Actor"
+
+// HeaderTenantID is the header naming the tenant of a request, a tenant claimed by the token must match it
+const HeaderTenantID = "X-Tenant-ID"
+
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidTenantID reports whether a tenant id is 1 to 64 letters, digits, underscores or dashes
+func ValidTenantID(tenantID string) bool {
+	return tenantIDPattern.MatchString(tenantID)
+}
+
+// RequestContext copies the request id, the client ip, the actor and the tenant into the request context so that
+// the usecase and repository layers can read them through requestctx.
+// It should be registered after requestid.New() and the engine needs ContextWithFallback enabled.
+func RequestContext(c *gin.Context) {
+	ctx := requestctx.WithRequestID(c.Request.Context(), requestid.Get(c))
+	ctx = requestctx.WithClientIP(ctx, c.ClientIP())
+	if actor := c.GetHeader(HeaderActor); actor != "" {
+		ctx = requestctx.WithUnverifiedActor(ctx, actor)
+	}
+	if tenantID := c.GetHeader(HeaderTenantID); tenantID != "" {
+		if !ValidTenantID(tenantID) {
+			response.ErrorWithMsg(c, http.StatusBadRequest, code.ParamIncorrect, fmt.Sprintf("invalid %s header", HeaderTenantID))
+			return
+		}
+		ctx = requestctx.WithTenantID(ctx, tenantID)
+	}
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/gin-gonic/gin"
+)
+
+// ResolveRole sets the role of the authenticated principal, it must come after Authenticate
+func ResolveRole(resolve func(ctx context.Context, principal *auth.Principal) (auth.Role, *code.CustomError)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := auth.GetPrincipal(c)
+		if principal == nil {
+			c.Next()
+			return
+		}
+
+		role, customErr := resolve(c, principal)
+		if customErr != nil {
+			response.CustomError(c, customErr)
+			return
+		}
+		resolved := *principal
+		resolved.Role = role
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), &resolved))
+		c.Next()
+	}
+}
@@ -0,0 +1,386 @@
+// Package openapi generates the OpenAPI 3.1 document of the api from the routes of a gin engine and the go types
+// of their parameters and bodies, and serves it with a Swagger UI.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/media"
+	"github.com/Yu-Qi/restful_api/pkg/api/pb"
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+)
+
+// Version is the OpenAPI version of the documents
+const Version = "3.1.0"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+}
+
+// Info describes the api
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path, key: lower case method
+type PathItem map[string]*Operation
+
+// Operation documents a route
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a path, query or header parameter
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody is the body of a request, key of Content: media type
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// MediaType describes a body in a media type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Response is a response of an operation, key of Content: media type
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Components holds the schemas referred by the operations
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is a way to authenticate
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Route documents a route of the engine, it is matched by Method and Path in the gin syntax like /v1/tasks/:id
+type Route struct {
+	Method  string
+	Path    string
+	Summary string
+	// Params holds the parameters by the uri, form and header tags of its fields, the path parameters it misses
+	// are strings
+	Params interface{}
+	// Body is bound like BindJson, so it is taken as JSON, YAML and MessagePack, and Protobuf when it implements
+	// util.ProtoBinding
+	Body interface{}
+	// BodyTypes replaces the media types of Body
+	BodyTypes []string
+	// Response is the data of the OK envelope, it is left out when Raw is set and Response is nil
+	Response interface{}
+	// Raw describes the responses out of the envelope by media type, a nil value is a string
+	Raw map[string]interface{}
+	// Status is the status of the success, default 200
+	Status int
+	// Statuses are the other statuses without a body, e.g. 304
+	Statuses []int
+	// Failures describes the JSON bodies of the failures out of the error envelope by status
+	Failures map[int]interface{}
+
+	tag string
+}
+
+func (r *Route) key() string {
+	return r.Method + " " + r.Path
+}
+
+// bodyTypes are the media types bound by BindJson, and responded by response.OK
+var bodyTypes = []string{media.MIMEJSON, media.MIMEYAML, media.MIMEMsgPack}
+
+// Spec collects the routes and the enums documenting an engine
+type Spec struct {
+	info   Info
+	routes map[string]*Route
+	enums  map[reflect.Type][]interface{}
+
+	once     sync.Once
+	document *Document
+}
+
+// NewSpec creates an empty spec
+func NewSpec(info Info) *Spec {
+	return &Spec{info: info, routes: map[string]*Route{}, enums: map[reflect.Type][]interface{}{}}
+}
+
+// Add documents routes under a tag
+func (s *Spec) Add(tag string, routes ...*Route) {
+	for _, route := range routes {
+		route.tag = tag
+		s.routes[route.key()] = route
+	}
+}
+
+// Enum lists the values of the type of v, a binding oneof tag wins over them
+func (s *Spec) Enum(v interface{}, values ...interface{}) {
+	s.enums[reflect.TypeOf(v)] = values
+}
+
+// Drift compares the routes of an engine with the documented ones, it returns the routes without a Route and
+// the Routes without a route
+func (s *Spec) Drift(routes gin.RoutesInfo) (undocumented, unregistered []string) {
+	registered := map[string]bool{}
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if _, ok := s.routes[key]; !ok {
+			undocumented = append(undocumented, key)
+		}
+	}
+	for key := range s.routes {
+		if !registered[key] {
+			unregistered = append(unregistered, key)
+		}
+	}
+	sort.Strings(undocumented)
+	sort.Strings(unregistered)
+	return undocumented, unregistered
+}
+
+// Document builds the document of the routes of r once, it must be called when every route is registered
+func (s *Spec) Document(r *gin.Engine) *Document {
+	s.once.Do(func() {
+		s.document = s.Build(r.Routes())
+	})
+	return s.document
+}
+
+// Build generates the document of the routes of an engine, a route without a Route only gets the error response
+func (s *Spec) Build(routes gin.RoutesInfo) *Document {
+	builder := newSchemaBuilder()
+	for t, values := range s.enums {
+		builder.enums[t] = values
+	}
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    s.info,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas: builder.schemas,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearer": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"apiKey": {Type: "apiKey", In: "header", Name: "Authorization", Description: "ApiKey <key>"},
+			},
+		},
+		Security: []map[string][]string{{"bearer": {}}, {"apiKey": {}}},
+	}
+	errorSchema := builder.schemaOf(reflect.TypeOf(response.ErrorResp{}))
+	describeCodes(builder.schemas["ErrorResp"])
+
+	for _, info := range routes {
+		path := PathOf(info.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		operation := &Operation{
+			OperationID: operationID(info.Handler),
+			Responses: map[string]*Response{
+				"default": {Description: "Error", Content: contentOf(bodyTypes, errorSchema)},
+			},
+		}
+		if route, ok := s.routes[info.Method+" "+info.Path]; ok {
+			s.describe(builder, operation, route)
+		}
+		doc.Paths[path][strings.ToLower(info.Method)] = operation
+	}
+	return doc
+}
+
+// describe fills an operation from its Route
+func (s *Spec) describe(builder *schemaBuilder, operation *Operation, route *Route) {
+	operation.Summary = route.Summary
+	operation.Tags = []string{route.tag}
+	operation.Parameters = parametersOf(builder, route)
+
+	if route.Body != nil || route.BodyTypes != nil {
+		types := route.BodyTypes
+		if types == nil {
+			types = bodyTypes
+		}
+		body := &RequestBody{Required: true, Content: contentOf(types, builder.schemaOf(reflect.TypeOf(route.Body)))}
+		if _, ok := route.Body.(util.ProtoBinding); ok && route.BodyTypes == nil {
+			body.Content[media.MIMEProtobuf] = &MediaType{Schema: &Schema{Type: SchemaType{"string"}, Format: "binary"}}
+		}
+		operation.RequestBody = body
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	if route.Response != nil || route.Raw == nil {
+		success.Content = contentOf(bodyTypes, envelopeOf(builder, route.Response))
+		if _, ok := pb.NewOKResponse(route.Response); ok {
+			success.Content[media.MIMEProtobuf] = &MediaType{Schema: &Schema{Type: SchemaType{"string"}, Format: "binary"}}
+		}
+	}
+	if status == http.StatusSwitchingProtocols {
+		success.Content = nil
+	}
+	for mediaType, raw := range route.Raw {
+		if success.Content == nil {
+			success.Content = map[string]*MediaType{}
+		}
+		schema := &Schema{Type: SchemaType{"string"}}
+		if raw != nil {
+			schema = builder.schemaOf(reflect.TypeOf(raw))
+		}
+		success.Content[mediaType] = &MediaType{Schema: schema}
+	}
+	operation.Responses[strconv.Itoa(status)] = success
+	for _, status := range route.Statuses {
+		operation.Responses[strconv.Itoa(status)] = &Response{Description: http.StatusText(status)}
+	}
+	for status, failure := range route.Failures {
+		operation.Responses[strconv.Itoa(status)] = &Response{
+			Description: http.StatusText(status),
+			Content:     contentOf([]string{media.MIMEJSON}, builder.schemaOf(reflect.TypeOf(failure))),
+		}
+	}
+}
+
+// envelopeOf describes response.OKResp carrying data
+func envelopeOf(builder *schemaBuilder, data interface{}) *Schema {
+	ok := 0.0
+	envelope := &Schema{
+		Type: SchemaType{"object"},
+		Properties: map[string]*Schema{
+			"code": {Type: SchemaType{"integer"}, Minimum: &ok, Maximum: &ok},
+		},
+		Required: []string{"code"},
+	}
+	if data != nil {
+		envelope.Properties["data"] = builder.schemaOf(reflect.TypeOf(data))
+		envelope.Required = append(envelope.Required, "data")
+	}
+	return envelope
+}
+
+// describeCodes lists the codes in the schema of ErrorResp
+func describeCodes(schema *Schema) {
+	codeSchema := schema.Properties["code"]
+	var names []string
+	for _, c := range code.Codes() {
+		if c == code.OK {
+			continue
+		}
+		codeSchema.Enum = append(codeSchema.Enum, c)
+		names = append(names, fmt.Sprintf("%d: %s", c, code.Name(c)))
+	}
+	codeSchema.Description = strings.Join(names, ", ")
+}
+
+func contentOf(types []string, schema *Schema) map[string]*MediaType {
+	content := map[string]*MediaType{}
+	for _, mediaType := range types {
+		content[mediaType] = &MediaType{Schema: schema}
+	}
+	return content
+}
+
+// parametersOf describes the path parameters of a route and the fields of its Params
+func parametersOf(builder *schemaBuilder, route *Route) []*Parameter {
+	var parameters []*Parameter
+	byName := map[string]*Parameter{}
+	for _, name := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+		parameter := &Parameter{Name: name[1], In: "path", Required: true, Schema: &Schema{Type: SchemaType{"string"}}}
+		parameters = append(parameters, parameter)
+		byName[parameter.Name] = parameter
+	}
+	if route.Params == nil {
+		return parameters
+	}
+
+	forEachParam(reflect.TypeOf(route.Params), func(field reflect.StructField) {
+		schema := builder.schemaOf(field.Type)
+		required := applyBinding(schema, field.Tag.Get("binding"))
+		for _, in := range []string{"uri", "form", "header"} {
+			name := field.Tag.Get(in)
+			if name == "" || name == "-" {
+				continue
+			}
+			if parameter, ok := byName[name]; ok && in == "uri" {
+				parameter.Schema = schema
+				continue
+			}
+			location := map[string]string{"uri": "path", "form": "query", "header": "header"}[in]
+			parameters = append(parameters, &Parameter{Name: name, In: location, Required: required, Schema: schema})
+		}
+	})
+	return parameters
+}
+
+// forEachParam calls fn for every field of a params struct, the embedded structs are flattened
+func forEachParam(t reflect.Type, fn func(field reflect.StructField)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			forEachParam(field.Type, fn)
+			continue
+		}
+		if field.IsExported() {
+			fn(field)
+		}
+	}
+}
+
+var pathParamPattern = regexp.MustCompile(`[:*]([^/]+)`)
+
+// PathOf converts a gin path like /v1/tasks/:id to the OpenAPI syntax /v1/tasks/{id}
+func PathOf(ginPath string) string {
+	return pathParamPattern.ReplaceAllString(ginPath, "{$1}")
+}
+
+// operationID takes the method name from a gin handler name like pkg.(*TaskHandler).GetTasks-fm
+func operationID(handler string) string {
+	name := strings.TrimSuffix(handler, "-fm")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
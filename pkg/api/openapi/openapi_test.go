@@ -0,0 +1,117 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestOpenAPISuite(t *testing.T) {
+	suite.Run(t, new(openAPISuite))
+}
+
+type openAPISuite struct {
+	suite.Suite
+}
+
+type page struct {
+	Items interface{} `json:"items"`
+	Total int         `json:"total"`
+}
+
+type item struct {
+	Name   string     `json:"name" binding:"required,min=1,max=10"`
+	Kind   string     `json:"kind" binding:"omitempty,oneof=a b"`
+	Tags   []string   `json:"tags" binding:"required,min=1,dive,oneof=x y"`
+	Parent *item      `json:"parent,omitempty"`
+	DueAt  *time.Time `json:"due_at"`
+	Secret string     `json:"-"`
+	hidden string
+}
+
+type itemPage struct {
+	page
+	Items []*item `json:"items"`
+}
+
+type listParams struct {
+	ID    int    `uri:"id"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Token string `header:"X-Token" binding:"required"`
+}
+
+func (s *openAPISuite) TestSchema() {
+	builder := newSchemaBuilder()
+	schema := builder.schemaOf(reflect.TypeOf(itemPage{}))
+	s.Equal("#/components/schemas/itemPage", schema.Ref)
+
+	// the shallower items win over the embedded ones
+	itemPage := builder.schemas["itemPage"]
+	s.Equal(SchemaType{"array"}, itemPage.Properties["items"].Type)
+	s.Equal("#/components/schemas/item", itemPage.Properties["items"].Items.Ref)
+	s.Contains(itemPage.Properties, "total")
+
+	item := builder.schemas["item"]
+	s.Equal([]string{"name", "tags"}, item.Required)
+	s.Equal(1, *item.Properties["name"].MinLength)
+	s.Equal(10, *item.Properties["name"].MaxLength)
+	s.Equal([]interface{}{"a", "b"}, item.Properties["kind"].Enum)
+	s.Equal(1, *item.Properties["tags"].MinItems)
+	s.Equal([]interface{}{"x", "y"}, item.Properties["tags"].Items.Enum)
+	s.Equal("#/components/schemas/item", item.Properties["parent"].AnyOf[0].Ref)
+	s.Equal(SchemaType{"string", "null"}, item.Properties["due_at"].Type)
+	s.Equal("date-time", item.Properties["due_at"].Format)
+	s.NotContains(item.Properties, "Secret")
+	s.NotContains(item.Properties, "hidden")
+}
+
+func (s *openAPISuite) TestBuild() {
+	spec := NewSpec(Info{Title: "test", Version: "1"})
+	spec.Add("items",
+		&Route{Method: http.MethodGet, Path: "/items/:id", Params: listParams{}, Response: itemPage{}},
+		&Route{Method: http.MethodPost, Path: "/items", Body: item{}, Status: http.StatusCreated},
+		&Route{Method: http.MethodDelete, Path: "/items/:id"},
+		&Route{Method: http.MethodPut, Path: "/pages/:id", Body: page{}, Failures: map[int]interface{}{http.StatusConflict: page{}}},
+	)
+
+	r := gin.New()
+	r.GET("/items/:id", func(*gin.Context) {})
+	r.POST("/items", func(*gin.Context) {})
+	r.PUT("/items/:id", func(*gin.Context) {})
+	r.PUT("/pages/:id", func(*gin.Context) {})
+	undocumented, unregistered := spec.Drift(r.Routes())
+	s.Equal([]string{"PUT /items/:id"}, undocumented)
+	s.Equal([]string{"DELETE /items/:id"}, unregistered)
+
+	doc := spec.Build(r.Routes())
+	s.Len(doc.Paths, 3)
+	get := doc.Paths["/items/{id}"]["get"]
+	s.Equal([]string{"items"}, get.Tags)
+	s.Len(get.Parameters, 3)
+	s.Equal(&Parameter{Name: "id", In: "path", Required: true, Schema: &Schema{Type: SchemaType{"integer"}, Format: "int64"}}, get.Parameters[0])
+	s.Equal("query", get.Parameters[1].In)
+	s.False(get.Parameters[1].Required)
+	s.Equal(100.0, *get.Parameters[1].Schema.Maximum)
+	s.Equal(&Parameter{Name: "X-Token", In: "header", Required: true, Schema: &Schema{Type: SchemaType{"string"}}}, get.Parameters[2])
+
+	post := doc.Paths["/items"]["post"]
+	s.Contains(post.Responses, "201")
+	s.Contains(post.Responses, "default")
+	s.NotContains(post.Responses["201"].Content["application/json"].Schema.Properties, "data")
+	s.Equal("#/components/schemas/item", post.RequestBody.Content["application/yaml"].Schema.Ref)
+
+	// a failure out of the envelope is described apart from the default error
+	failure := doc.Paths["/pages/{id}"]["put"].Responses["409"]
+	s.Equal("#/components/schemas/page", failure.Content["application/json"].Schema.Ref)
+	s.Len(failure.Content, 1)
+
+	// an undocumented route only has the errors
+	put := doc.Paths["/items/{id}"]["put"]
+	s.Len(put.Responses, 1)
+	s.Equal("/v1/tasks/{id}/dependencies/{blocker_id}", PathOf("/v1/tasks/:id/dependencies/:blocker_id"))
+	s.Equal("/docs/{filepath}", PathOf("/docs/*filepath"))
+}
@@ -0,0 +1,293 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaType is the type of a schema, a nullable schema has two types like ["string", "null"]
+type SchemaType []string
+
+// MarshalJSON writes a single type as a string
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON reads a type written as a string or an array
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = SchemaType{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// Schema is the subset of JSON Schema 2020-12 describing the bodies and the parameters
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 SchemaType         `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaBuilder describes the go types by their json tags, the named structs become components
+type schemaBuilder struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+	enums   map[reflect.Type][]interface{}
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{
+		schemas: map[string]*Schema{},
+		names:   map[reflect.Type]string{},
+		enums:   map[reflect.Type][]interface{}{},
+	}
+}
+
+// schemaOf describes a value of t, a nil t is any value
+func (b *schemaBuilder) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if enum, ok := b.enums[t]; ok {
+		schema := basicSchema(t)
+		schema.Enum = append([]interface{}(nil), enum...)
+		return schema
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: SchemaType{"string"}, Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: SchemaType{"string"}, Format: "byte"}
+		}
+		return &Schema{Type: SchemaType{"array"}, Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: SchemaType{"object"}, AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + b.component(t)}
+	}
+	return basicSchema(t)
+}
+
+// component names a struct and describes it in the components once
+func (b *schemaBuilder) component(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, ok := b.schemas[name]; ok {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	b.names[t] = name
+	// reserved before the fields are described, so a recursive type refers to itself
+	b.schemas[name] = &Schema{}
+	*b.schemas[name] = *b.structSchema(t)
+	return name
+}
+
+// structSchema describes the fields of a struct like encoding/json sees them
+func (b *schemaBuilder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: SchemaType{"object"}, Properties: map[string]*Schema{}}
+	forEachField(t, func(name string, field reflect.StructField) {
+		fieldSchema := b.fieldSchema(field)
+		if applyBinding(fieldSchema, field.Tag.Get("binding")) {
+			schema.Required = append(schema.Required, name)
+		}
+		if fieldSchema.Enum != nil && fieldSchema.is("null") {
+			fieldSchema.Enum = append(fieldSchema.Enum, nil)
+		}
+		schema.Properties[name] = fieldSchema
+	})
+	return schema
+}
+
+// fieldSchema describes a field, a pointer field can be null
+func (b *schemaBuilder) fieldSchema(field reflect.StructField) *Schema {
+	schema := b.schemaOf(field.Type)
+	if format := field.Tag.Get("format"); format != "" {
+		schema.Format = format
+	}
+	if field.Type.Kind() == reflect.Pointer {
+		if schema.Ref != "" {
+			return &Schema{AnyOf: []*Schema{schema, {Type: SchemaType{"null"}}}}
+		}
+		if len(schema.Type) == 1 {
+			schema.Type = append(schema.Type, "null")
+		}
+	}
+	return schema
+}
+
+// forEachField calls fn with the json name of every field of a struct, the fields of the embedded structs are
+// flattened and the shallower ones win like in encoding/json
+func forEachField(t reflect.Type, fn func(name string, field reflect.StructField)) {
+	seen := map[string]bool{}
+	structs := []reflect.Type{t}
+	for len(structs) > 0 {
+		var embedded []reflect.Type
+		for _, s := range structs {
+			for i := 0; i < s.NumField(); i++ {
+				field := s.Field(i)
+				tag := field.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, _, _ := strings.Cut(tag, ",")
+				fieldType := field.Type
+				if fieldType.Kind() == reflect.Pointer {
+					fieldType = fieldType.Elem()
+				}
+				if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+					embedded = append(embedded, fieldType)
+					continue
+				}
+				if !field.IsExported() {
+					continue
+				}
+				if name == "" {
+					name = field.Name
+				}
+				if seen[name] {
+					continue
+				}
+				seen[name] = true
+				fn(name, field)
+			}
+		}
+		structs = embedded
+	}
+}
+
+// applyBinding adds the rules of a binding tag to a schema and reports whether the field is required,
+// the rules after dive apply to the items
+func applyBinding(schema *Schema, tag string) bool {
+	required, dived := false, false
+	target := schema
+	if len(schema.AnyOf) > 0 {
+		target = schema.AnyOf[0]
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		name, value, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = !dived
+		case "dive":
+			if target.Items == nil {
+				return required
+			}
+			target, dived = target.Items, true
+		case "oneof":
+			target.Enum = nil
+			for _, option := range strings.Fields(value) {
+				if target.is("integer") {
+					if n, err := strconv.Atoi(option); err == nil {
+						target.Enum = append(target.Enum, n)
+					}
+					continue
+				}
+				target.Enum = append(target.Enum, option)
+			}
+		case "min", "max":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			switch {
+			case target.is("string"):
+				setBound(name, &target.MinLength, &target.MaxLength, n)
+			case target.is("array"):
+				setBound(name, &target.MinItems, &target.MaxItems, n)
+			case target.is("integer"), target.is("number"):
+				f := float64(n)
+				if name == "min" {
+					target.Minimum = &f
+				} else {
+					target.Maximum = &f
+				}
+			}
+		case "url":
+			target.Format = "uri"
+		}
+	}
+	return required
+}
+
+func setBound(name string, min, max **int, n int) {
+	if name == "min" {
+		*min = &n
+	} else {
+		*max = &n
+	}
+}
+
+// is reports whether a schema allows a type
+func (s *Schema) is(schemaType string) bool {
+	for _, t := range s.Type {
+		if t == schemaType {
+			return true
+		}
+	}
+	return false
+}
+
+// basicSchema describes a type of a basic kind
+func basicSchema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: SchemaType{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		format := "int64"
+		if t.Kind() == reflect.Int32 || t.Kind() == reflect.Int16 || t.Kind() == reflect.Int8 {
+			format = "int32"
+		}
+		return &Schema{Type: SchemaType{"integer"}, Format: format}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: SchemaType{"integer"}, Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: SchemaType{"number"}}
+	case reflect.String:
+		return &Schema{Type: SchemaType{"string"}}
+	}
+	return &Schema{}
+}
@@ -0,0 +1,84 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// paths of the document and the Swagger UI
+const (
+	DocumentPath = "/openapi.json"
+	UIPath       = "/docs"
+)
+
+// swaggerInitializer replaces the one of the Swagger UI distribution, which loads the petstore
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "` + DocumentPath + `",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+// Serve registers the document of the routes of r at /openapi.json and the Swagger UI at /docs.
+// The document is built on the first request, when every route is registered.
+func (s *Spec) Serve(r *gin.Engine) {
+	s.Add("docs",
+		&Route{Method: http.MethodGet, Path: DocumentPath, Summary: "Get the OpenAPI document", Raw: map[string]interface{}{"application/json": map[string]interface{}{}}},
+		&Route{Method: http.MethodGet, Path: UIPath + "/*filepath", Summary: "Browse the OpenAPI document with Swagger UI", Raw: map[string]interface{}{"text/html": nil}},
+	)
+
+	handler := &docsHandler{spec: s, engine: r}
+	r.GET(DocumentPath, handler.GetDocument)
+	r.GET(UIPath+"/*filepath", handler.GetUI)
+}
+
+type docsHandler struct {
+	spec     *Spec
+	engine   *gin.Engine
+	once     sync.Once
+	document []byte
+}
+
+// GetDocument serves the document of the routes of the engine
+func (h *docsHandler) GetDocument(ctx *gin.Context) {
+	h.once.Do(func() {
+		h.document, _ = json.Marshal(h.spec.Document(h.engine))
+	})
+	ctx.Data(http.StatusOK, "application/json", h.document)
+}
+
+// GetUI serves the files of the Swagger UI, the directory serves index.html
+func (h *docsHandler) GetUI(ctx *gin.Context) {
+	name := strings.TrimPrefix(path.Clean(ctx.Param("filepath")), "/")
+	if name == "" {
+		name = "index.html"
+	}
+	if name == "swagger-initializer.js" {
+		ctx.Data(http.StatusOK, "text/javascript; charset=utf-8", []byte(swaggerInitializer))
+		return
+	}
+
+	content, err := fs.ReadFile(swaggerFiles.FS, name)
+	if err != nil {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(content)
+	}
+	ctx.Data(http.StatusOK, contentType, content)
+}
@@ -0,0 +1,419 @@
+package openapi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/media"
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+)
+
+// Validator checks the requests and the responses of an engine against the document of its routes,
+// the document is built on the first check, when every route is registered
+type Validator struct {
+	spec   *Spec
+	engine *gin.Engine
+}
+
+// Validator creates a validator of the routes of r
+func (s *Spec) Validator(r *gin.Engine) *Validator {
+	return &Validator{spec: s, engine: r}
+}
+
+// operation finds the operation of the route matched by ctx, nil when no route matches
+func (v *Validator) operation(ctx *gin.Context) (*Document, *Operation) {
+	if ctx.FullPath() == "" {
+		return nil, nil
+	}
+	doc := v.spec.Document(v.engine)
+	return doc, doc.Paths[PathOf(ctx.FullPath())][strings.ToLower(ctx.Request.Method)]
+}
+
+// ValidateRequest checks the path, query and header parameters and the JSON, YAML or MessagePack body of a request,
+// the body is left for the handlers. The bodies in the other media types are left to the handlers too.
+// The error is the one of reading the body, like the *http.MaxBytesError of a body longer than its limit.
+func (v *Validator) ValidateRequest(ctx *gin.Context) ([]*response.FieldError, error) {
+	doc, operation := v.operation(ctx)
+	if operation == nil {
+		return nil, nil
+	}
+	checker := &schemaChecker{doc: doc}
+
+	query := ctx.Request.URL.Query()
+	for _, parameter := range operation.Parameters {
+		checker.in = parameter.In
+		var values []string
+		switch parameter.In {
+		case "path":
+			values = []string{ctx.Param(parameter.Name)}
+		case "query":
+			values = query[parameter.Name]
+		case "header":
+			values = ctx.Request.Header.Values(parameter.Name)
+		}
+		if len(values) == 0 {
+			if parameter.Required {
+				checker.fail(parameter.Name, "is required")
+			}
+			continue
+		}
+		value, ok := checker.coerce(parameter.Schema, values, parameter.Name)
+		if ok {
+			checker.check(parameter.Schema, value, parameter.Name)
+		}
+	}
+
+	if operation.RequestBody != nil {
+		checker.in = "body"
+		if err := v.checkRequestBody(ctx, checker, operation.RequestBody); err != nil {
+			return nil, err
+		}
+	}
+	return checker.violations, nil
+}
+
+func (v *Validator) checkRequestBody(ctx *gin.Context, checker *schemaChecker, requestBody *RequestBody) error {
+	mediaType := media.Canonical(ctx.GetHeader("Content-Type"))
+	if mediaType == "" {
+		mediaType = media.MIMEJSON
+	}
+	content, ok := requestBody.Content[mediaType]
+	if !ok || !decodable(mediaType) || ctx.Request.Body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return err
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) == 0 {
+		if requestBody.Required {
+			checker.fail("", "is required")
+		}
+		return nil
+	}
+	var value interface{}
+	if err := media.Unmarshal(mediaType, body, &value); err != nil {
+		checker.fail("", fmt.Sprintf("is not valid %s: %v", mediaType, err))
+		return nil
+	}
+	checker.check(content.Schema, value, "")
+	return nil
+}
+
+// ValidateResponse checks a response of the route matched by ctx against the response documented for its status,
+// or the default one. The bodies which can't be decoded like csv or protobuf are not checked.
+func (v *Validator) ValidateResponse(ctx *gin.Context, status int, contentType string, body []byte) []*response.FieldError {
+	doc, operation := v.operation(ctx)
+	if operation == nil {
+		return nil
+	}
+	checker := &schemaChecker{doc: doc, in: "body"}
+
+	documented, ok := operation.Responses[strconv.Itoa(status)]
+	if !ok {
+		documented = operation.Responses["default"]
+	}
+	if documented == nil {
+		checker.fail("", fmt.Sprintf("status %d is not documented", status))
+		return checker.violations
+	}
+	if len(body) == 0 {
+		return nil
+	}
+
+	mediaType := media.Canonical(contentType)
+	content, ok := documented.Content[mediaType]
+	if !ok {
+		// only the bodies of the api itself are expected to be documented
+		if decodable(mediaType) {
+			checker.fail("", fmt.Sprintf("%s is not documented for status %d", mediaType, status))
+		}
+		return checker.violations
+	}
+
+	if mediaType == util.MIMENDJSON {
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		scanner.Buffer(nil, len(body)+1)
+		for i := 0; scanner.Scan(); i++ {
+			checker.checkBody(media.MIMEJSON, content.Schema, scanner.Bytes(), fmt.Sprintf("[%d]", i))
+		}
+		return checker.violations
+	}
+	if decodable(mediaType) {
+		checker.checkBody(mediaType, content.Schema, body, "")
+	}
+	return checker.violations
+}
+
+// decodable reports whether the bodies in a media type are decoded to be checked
+func decodable(mediaType string) bool {
+	for _, bodyType := range bodyTypes {
+		if mediaType == bodyType {
+			return true
+		}
+	}
+	return mediaType == util.MIMENDJSON
+}
+
+// schemaChecker collects the violations of the values checked against the schemas of a document
+type schemaChecker struct {
+	doc        *Document
+	in         string
+	violations []*response.FieldError
+}
+
+func (c *schemaChecker) fail(field, message string) {
+	c.violations = append(c.violations, &response.FieldError{In: c.in, Field: field, Message: message})
+}
+
+func (c *schemaChecker) checkBody(mediaType string, schema *Schema, body []byte, field string) {
+	var value interface{}
+	if err := media.Unmarshal(mediaType, body, &value); err != nil {
+		c.fail(field, fmt.Sprintf("is not valid %s: %v", mediaType, err))
+		return
+	}
+	c.check(schema, value, field)
+}
+
+// resolve follows the $ref of a schema to the components
+func (c *schemaChecker) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = c.doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+// coerce converts the strings of a parameter to the type of its schema, an array takes every value
+func (c *schemaChecker) coerce(schema *Schema, values []string, field string) (interface{}, bool) {
+	schema = c.resolve(schema)
+	if schema == nil {
+		return values[0], true
+	}
+	if schema.is("array") {
+		items := make([]interface{}, 0, len(values))
+		for i, value := range values {
+			item, ok := c.coerce(schema.Items, []string{value}, fmt.Sprintf("%s[%d]", field, i))
+			if !ok {
+				return nil, false
+			}
+			items = append(items, item)
+		}
+		return items, true
+	}
+
+	value := values[0]
+	switch {
+	case schema.is("integer"):
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			c.fail(field, "must be an integer")
+			return nil, false
+		}
+		return float64(n), true
+	case schema.is("number"):
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			c.fail(field, "must be a number")
+			return nil, false
+		}
+		return f, true
+	case schema.is("boolean"):
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			c.fail(field, "must be a boolean")
+			return nil, false
+		}
+		return b, true
+	}
+	return value, true
+}
+
+// check validates a decoded value against a schema, field is the path to the value like recurrence.frequency
+func (c *schemaChecker) check(schema *Schema, value interface{}, field string) {
+	schema = c.resolve(schema)
+	if schema == nil {
+		return
+	}
+
+	if len(schema.AnyOf) > 0 {
+		// the value is checked against the first option of its type, like the object of an anyOf[ref, null]
+		var types SchemaType
+		for _, option := range schema.AnyOf {
+			option = c.resolve(option)
+			if option == nil || len(option.Type) == 0 || typeMatches(option.Type, value) {
+				c.check(option, value, field)
+				return
+			}
+			types = append(types, option.Type...)
+		}
+		c.fail(field, fmt.Sprintf("must be %s", strings.Join(types, " or ")))
+		return
+	}
+
+	if len(schema.Type) > 0 && !typeMatches(schema.Type, value) {
+		c.fail(field, fmt.Sprintf("must be %s", strings.Join(schema.Type, " or ")))
+		return
+	}
+	if schema.Enum != nil && !inEnum(schema.Enum, value) {
+		c.fail(field, fmt.Sprintf("must be one of %s", enumString(schema.Enum)))
+		return
+	}
+
+	switch value := value.(type) {
+	case string:
+		c.checkString(schema, value, field)
+	case float64:
+		if schema.Minimum != nil && value < *schema.Minimum {
+			c.fail(field, fmt.Sprintf("must be at least %v", *schema.Minimum))
+		}
+		if schema.Maximum != nil && value > *schema.Maximum {
+			c.fail(field, fmt.Sprintf("must be at most %v", *schema.Maximum))
+		}
+	case []interface{}:
+		if schema.MinItems != nil && len(value) < *schema.MinItems {
+			c.fail(field, fmt.Sprintf("must have at least %d items", *schema.MinItems))
+		}
+		if schema.MaxItems != nil && len(value) > *schema.MaxItems {
+			c.fail(field, fmt.Sprintf("must have at most %d items", *schema.MaxItems))
+		}
+		if schema.Items != nil {
+			for i, item := range value {
+				c.check(schema.Items, item, fmt.Sprintf("%s[%d]", field, i))
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := value[name]; !ok {
+				c.fail(join(field, name), "is required")
+			}
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property := value[name]
+			if propertySchema, ok := schema.Properties[name]; ok {
+				c.check(propertySchema, property, join(field, name))
+			} else if schema.AdditionalProperties != nil {
+				c.check(schema.AdditionalProperties, property, join(field, name))
+			}
+		}
+	}
+}
+
+func (c *schemaChecker) checkString(schema *Schema, value string, field string) {
+	length := utf8.RuneCountInString(value)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		c.fail(field, fmt.Sprintf("must be at least %d characters", *schema.MinLength))
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		c.fail(field, fmt.Sprintf("must be at most %d characters", *schema.MaxLength))
+	}
+	switch schema.Format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			c.fail(field, "must be a date-time like 2006-01-02T15:04:05Z")
+		}
+	case "uri":
+		if u, err := url.ParseRequestURI(value); err != nil || u.Scheme == "" {
+			c.fail(field, "must be an absolute uri")
+		}
+	}
+}
+
+// join appends a property to the path of a field
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+// typeMatches reports whether a decoded value has one of the types, an integral number is an integer
+func typeMatches(types SchemaType, value interface{}) bool {
+	for _, t := range types {
+		switch value := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && value == math.Trunc(value)) {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inEnum compares the numbers of the enum, which are go integers in a built document, by their values
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, option := range enum {
+		if n, ok := toFloat(option); ok {
+			if f, ok := value.(float64); ok && f == n {
+				return true
+			}
+			continue
+		}
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func enumString(enum []interface{}) string {
+	options := make([]string, 0, len(enum))
+	for _, option := range enum {
+		if option == nil {
+			options = append(options, "null")
+			continue
+		}
+		options = append(options, fmt.Sprint(option))
+	}
+	return strings.Join(options, ", ")
+}
@@ -0,0 +1,108 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+)
+
+func (s *openAPISuite) TestValidateRequest() {
+	spec := NewSpec(Info{Title: "test", Version: "1"})
+	spec.Add("items",
+		&Route{Method: http.MethodGet, Path: "/items/:id", Params: listParams{}, Response: itemPage{}},
+		&Route{Method: http.MethodPost, Path: "/items", Body: item{}},
+	)
+	r := gin.New()
+	validator := spec.Validator(r)
+	var violations []*response.FieldError
+	var body string
+	check := func(c *gin.Context) {
+		var err error
+		violations, err = validator.ValidateRequest(c)
+		s.NoError(err)
+		// the body is left for the handler
+		raw, err := c.GetRawData()
+		s.NoError(err)
+		body = string(raw)
+	}
+	r.GET("/items/:id", check)
+	r.POST("/items", check)
+	serve := func(method, path, contentType, reqBody string, header http.Header) []*response.FieldError {
+		req, err := http.NewRequest(method, path, strings.NewReader(reqBody))
+		s.NoError(err)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		req.Header.Set("Content-Type", contentType)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		return violations
+	}
+	token := http.Header{"X-Token": {"token"}}
+
+	s.Empty(serve("GET", "/items/1?limit=10", "", "", token))
+	s.Equal([]*response.FieldError{
+		{In: "path", Field: "id", Message: "must be an integer"},
+		{In: "query", Field: "limit", Message: "must be at most 100"},
+		{In: "header", Field: "X-Token", Message: "is required"},
+	}, serve("GET", "/items/x?limit=101", "", "", nil))
+
+	valid := `{"name": "item", "tags": ["x"], "parent": {"name": "parent", "tags": ["y"]}, "due_at": null}`
+	s.Empty(serve("POST", "/items", "application/json", valid, nil))
+	s.Equal(valid, body)
+	s.Empty(serve("POST", "/items", "application/yaml", "name: item\ntags: [x]\n", nil))
+	s.Equal([]*response.FieldError{
+		{In: "body", Field: "due_at", Message: "must be a date-time like 2006-01-02T15:04:05Z"},
+		{In: "body", Field: "kind", Message: "must be one of a, b"},
+		{In: "body", Field: "name", Message: "must be at most 10 characters"},
+		{In: "body", Field: "parent.tags", Message: "is required"},
+		{In: "body", Field: "parent.name", Message: "must be string"},
+		{In: "body", Field: "tags[1]", Message: "must be one of x, y"},
+	}, serve("POST", "/items", "", `{"name": "a long name", "kind": "c", "tags": ["x", "z"],
+		"parent": {"name": 1}, "due_at": "tomorrow"}`, nil))
+	s.Equal([]*response.FieldError{{In: "body", Field: "parent", Message: "must be object or null"}},
+		serve("POST", "/items", "application/json", `{"name": "item", "tags": ["x"], "parent": []}`, nil))
+	s.Equal([]*response.FieldError{{In: "body", Field: "", Message: "is required"}},
+		serve("POST", "/items", "application/json", "", nil))
+	s.Len(serve("POST", "/items", "application/json", "{", nil), 1)
+	// the handlers answer the media types which are not documented
+	s.Empty(serve("POST", "/items", "text/plain", "item", nil))
+}
+
+func (s *openAPISuite) TestValidateResponse() {
+	spec := NewSpec(Info{Title: "test", Version: "1"})
+	spec.Add("items", &Route{Method: http.MethodGet, Path: "/items/:id", Params: listParams{}, Response: itemPage{}})
+	r := gin.New()
+	validator := spec.Validator(r)
+	var violations []*response.FieldError
+	var status int
+	var contentType, body string
+	r.GET("/items/:id", func(c *gin.Context) {
+		violations = validator.ValidateResponse(c, status, contentType, []byte(body))
+	})
+	respond := func(respStatus int, respContentType, respBody string) []*response.FieldError {
+		status, contentType, body = respStatus, respContentType, respBody
+		req, err := http.NewRequest("GET", "/items/1", nil)
+		s.NoError(err)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		return violations
+	}
+
+	s.Empty(respond(http.StatusOK, "application/json; charset=utf-8",
+		`{"code": 0, "data": {"items": [{"name": "item", "tags": ["x"], "due_at": null}], "total": 1}}`))
+	s.Equal([]*response.FieldError{
+		{In: "body", Field: "code", Message: "must be at most 0"},
+		{In: "body", Field: "data.items[0].tags", Message: "is required"},
+		{In: "body", Field: "data.total", Message: "must be integer"},
+	}, respond(http.StatusOK, "application/json", `{"code": 1, "data": {"items": [{"name": "item"}], "total": 1.5}}`))
+	// the errors match the default response
+	s.Empty(respond(http.StatusNotFound, "application/json", `{"status": 404, "code": 1001, "request_id": "", "message": "not found", "path": "/items/1", "timestamp": 1}`))
+	s.Equal([]*response.FieldError{{In: "body", Field: "code", Message: "must be one of " + enumString(spec.Document(r).Components.Schemas["ErrorResp"].Properties["code"].Enum)}},
+		respond(http.StatusNotFound, "application/json", `{"status": 404, "code": -1, "request_id": "", "message": "", "path": "", "timestamp": 1}`))
+	s.Equal([]*response.FieldError{{In: "body", Field: "", Message: "application/x-ndjson is not documented for status 200"}},
+		respond(http.StatusOK, "application/x-ndjson", "{}\n"))
+	s.Empty(respond(http.StatusOK, "text/csv", "name\nitem\n"))
+}
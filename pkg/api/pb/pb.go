@@ -0,0 +1,109 @@
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative task.proto task_service.proto
+
+// Package pb holds the protobuf messages of the api generated from task.proto and their conversions
+package pb
+
+import (
+	"time"
+
+	"github.com/samber/lo"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/Yu-Qi/restful_api/domain"
+)
+
+// FromTask converts a task to its message
+func FromTask(task *domain.Task) *Task {
+	status := int32(task.Status)
+	message := &Task{
+		Id:         int32(task.ID),
+		Name:       task.Name,
+		Status:     &status,
+		DueAt:      fromTime(task.DueAt),
+		DeletedAt:  fromTime(task.DeletedAt),
+		Version:    int32(task.Version),
+		OwnerId:    task.OwnerID,
+		ExternalId: task.ExternalID,
+	}
+	if recurrence := task.Recurrence; recurrence != nil {
+		message.Recurrence = &Recurrence{
+			Frequency: string(recurrence.Frequency),
+			Interval:  int32(recurrence.Interval),
+			ByWeekday: recurrence.ByWeekday,
+			StartAt:   timestamppb.New(recurrence.StartAt),
+			Until:     fromTime(recurrence.Until),
+			Count:     int32(recurrence.Count),
+		}
+	}
+	return message
+}
+
+// ToTask converts a message to a task, the missing status is incomplete and the missing start of a recurrence is zero
+func ToTask(message *Task) *domain.Task {
+	task := &domain.Task{
+		ID:         int(message.GetId()),
+		Name:       message.GetName(),
+		Status:     domain.TaskStatus(message.GetStatus()),
+		DueAt:      toTime(message.GetDueAt()),
+		DeletedAt:  toTime(message.GetDeletedAt()),
+		Version:    int(message.GetVersion()),
+		OwnerID:    message.GetOwnerId(),
+		ExternalID: message.GetExternalId(),
+	}
+	if recurrence := message.GetRecurrence(); recurrence != nil {
+		task.Recurrence = &domain.Recurrence{
+			Frequency: domain.RecurrenceFrequency(recurrence.GetFrequency()),
+			Interval:  int(recurrence.GetInterval()),
+			ByWeekday: recurrence.GetByWeekday(),
+			StartAt:   lo.FromPtr(toTime(recurrence.GetStartAt())),
+			Until:     toTime(recurrence.GetUntil()),
+			Count:     int(recurrence.GetCount()),
+		}
+	}
+	return task
+}
+
+// FromTaskEvent converts a change of a task with the id to resume from to its message
+func FromTaskEvent(id uint64, event *domain.TaskEvent) *TaskEvent {
+	return &TaskEvent{
+		Id:         id,
+		Action:     string(event.Action),
+		Task:       FromTask(event.Task),
+		Actor:      event.Actor,
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+}
+
+// NewOKResponse wraps the data of a response, only the tasks can be sent as protobuf
+func NewOKResponse(data interface{}) (*OKResponse, bool) {
+	response := &OKResponse{}
+	switch data := data.(type) {
+	case nil:
+	case *domain.Task:
+		response.Data = &OKResponse_Task{Task: FromTask(data)}
+	case []*domain.Task:
+		tasks := make([]*Task, len(data))
+		for i, task := range data {
+			tasks[i] = FromTask(task)
+		}
+		response.Data = &OKResponse_Tasks{Tasks: &TaskList{Tasks: tasks}}
+	default:
+		return nil, false
+	}
+	return response, true
+}
+
+func fromTime(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func toTime(t *timestamppb.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+	converted := t.AsTime()
+	return &converted
+}
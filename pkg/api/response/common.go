@@ -3,24 +3,37 @@ package response
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	em "emperror.dev/errors"
 
+	"github.com/Yu-Qi/restful_api/pkg/api/media"
+	"github.com/Yu-Qi/restful_api/pkg/api/pb"
 	"github.com/Yu-Qi/restful_api/pkg/code"
 	"github.com/gin-contrib/requestid"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"google.golang.org/protobuf/proto"
 )
 
 // ErrorResp is the error response struct.
 type ErrorResp struct {
-	Status    int    `json:"status"`
-	Code      int    `json:"code"`
-	RequestID string `json:"request_id"`
-	Message   string `json:"message"`
-	Path      string `json:"path"`
-	Timestamp int64  `json:"timestamp"`
+	Status    int           `json:"status"`
+	Code      int           `json:"code"`
+	RequestID string        `json:"request_id"`
+	Message   string        `json:"message"`
+	Path      string        `json:"path"`
+	Timestamp int64         `json:"timestamp"`
+	Details   []*FieldError `json:"details,omitempty"`
+}
+
+// FieldError is a violation found in a part of the request, In is path, query, header or body and Field is
+// the name of the parameter or the path to the field of the body like recurrence.start_at
+type FieldError struct {
+	In      string `json:"in"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 // AbortByAny .
@@ -40,8 +53,14 @@ func AbortByAny(c *gin.Context, anyError interface{}) {
 	}
 }
 
-// ErrorWithMsg .
+// ErrorWithMsg responds an error in the media type negotiated by the Accept header, JSON when none is acceptable
 func ErrorWithMsg(ctx *gin.Context, status int, code int, msg string) {
+	ErrorWithDetails(ctx, status, code, msg, nil)
+}
+
+// ErrorWithDetails responds an error like ErrorWithMsg listing the violations of the fields,
+// the protobuf response only has msg
+func ErrorWithDetails(ctx *gin.Context, status int, code int, msg string, details []*FieldError) {
 	if msg == "" {
 		msg = "ERROR"
 	}
@@ -52,8 +71,26 @@ func ErrorWithMsg(ctx *gin.Context, status int, code int, msg string) {
 		Message:   msg,
 		Path:      ctx.Request.RequestURI,
 		Timestamp: time.Now().Unix(),
+		Details:   details,
+	}
+	ctx.Abort()
+
+	mediaType := negotiate(ctx)
+	if mediaType == media.MIMEProtobuf {
+		render(ctx, status, mediaType, &pb.ErrorResponse{
+			Status:    int32(err.Status),
+			Code:      int32(err.Code),
+			RequestId: err.RequestID,
+			Message:   err.Message,
+			Path:      err.Path,
+			Timestamp: err.Timestamp,
+		})
+		return
+	}
+	if mediaType == "" {
+		mediaType = media.MIMEJSON
 	}
-	ctx.AbortWithStatusJSON(status, err)
+	render(ctx, status, mediaType, err)
 }
 
 // CustomError .
@@ -67,10 +104,57 @@ type OKResp struct {
 	Data interface{} `json:"data,omitempty"`
 }
 
-// OK responses code and data in JSON format.
+// OK responses code and data in the media type negotiated by the Accept header, JSON by default
 func OK(ctx *gin.Context, data interface{}) {
-	ctx.JSON(http.StatusOK, &OKResp{
-		Code: 0,
-		Data: data,
-	})
+	mediaType := negotiate(ctx)
+	switch mediaType {
+	case "":
+		ErrorWithMsg(ctx, http.StatusNotAcceptable, code.NotAcceptable,
+			fmt.Sprintf("none of the media types %s is acceptable", strings.Join(offered, ", ")))
+	case media.MIMEProtobuf:
+		message, ok := pb.NewOKResponse(data)
+		if !ok {
+			ErrorWithMsg(ctx, http.StatusNotAcceptable, code.NotAcceptable, "the response can't be sent as protobuf")
+			return
+		}
+		render(ctx, http.StatusOK, mediaType, message)
+	default:
+		render(ctx, http.StatusOK, mediaType, &OKResp{
+			Code: 0,
+			Data: data,
+		})
+	}
+}
+
+// offered are the media types of the responses, the first is the default
+var offered = []string{media.MIMEJSON, media.MIMEYAML, media.MIMEMsgPack, media.MIMEProtobuf}
+
+func negotiate(ctx *gin.Context) string {
+	// added, the compression middleware varies by Accept-Encoding too
+	ctx.Writer.Header().Add("Vary", "Accept")
+	return media.Negotiate(ctx.GetHeader("Accept"), offered...)
+}
+
+// render writes the body in the media type, v is a proto.Message for protobuf
+func render(ctx *gin.Context, status int, mediaType string, v interface{}) {
+	var body []byte
+	var err error
+	switch mediaType {
+	case media.MIMEJSON:
+		ctx.JSON(status, v)
+		return
+	case media.MIMEProtobuf:
+		body, err = proto.Marshal(v.(proto.Message))
+	case media.MIMEYAML:
+		body, err = media.Marshal(mediaType, v)
+		mediaType += "; charset=utf-8"
+	default:
+		body, err = media.Marshal(mediaType, v)
+	}
+	if err != nil {
+		_ = ctx.Error(err)
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+	ctx.Data(status, mediaType, body)
 }
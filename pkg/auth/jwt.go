@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTConfig defines the keys and the claims accepted by JWTVerifier
+type JWTConfig struct {
+	// HS256Secret verifies HS256 tokens
+	HS256Secret []byte
+	// RS256PublicKeyFile is a PEM encoded public key verifying RS256 tokens
+	RS256PublicKeyFile string
+	// JWKSFile is a local JSON Web Key Set, its RSA keys verify RS256 tokens and its oct keys verify HS256 tokens
+	JWKSFile string
+	// Issuer and Audience are checked when not empty
+	Issuer   string
+	Audience string
+}
+
+// IsEmpty reports whether no key is configured
+func (c *JWTConfig) IsEmpty() bool {
+	return len(c.HS256Secret) == 0 && c.RS256PublicKeyFile == "" && c.JWKSFile == ""
+}
+
+// JWTVerifier verifies HS256 and RS256 bearer tokens
+type JWTVerifier struct {
+	// hmacKeys and rsaKeys key: kid, empty kid is the key without kid
+	hmacKeys map[string][]byte
+	rsaKeys  map[string]*rsa.PublicKey
+	parser   *jwt.Parser
+}
+
+// NewJWTVerifier loads the keys of the config
+func NewJWTVerifier(config *JWTConfig) (*JWTVerifier, error) {
+	verifier := &JWTVerifier{
+		hmacKeys: map[string][]byte{},
+		rsaKeys:  map[string]*rsa.PublicKey{},
+	}
+
+	if len(config.HS256Secret) > 0 {
+		verifier.hmacKeys[""] = config.HS256Secret
+	}
+	if config.RS256PublicKeyFile != "" {
+		pem, err := os.ReadFile(config.RS256PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read rs256 public key: %w", err)
+		}
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("parse rs256 public key: %w", err)
+		}
+		verifier.rsaKeys[""] = publicKey
+	}
+	if config.JWKSFile != "" {
+		err := verifier.loadJWKS(config.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(verifier.hmacKeys) == 0 && len(verifier.rsaKeys) == 0 {
+		return nil, errors.New("no jwt key is configured")
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+	verifier.parser = jwt.NewParser(options...)
+	return verifier, nil
+}
+
+// Verify checks the signature and the claims of a token and returns its principal
+func (v *JWTVerifier) Verify(tokenString string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(tokenString, claims, v.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	// an optional role claim grants the initial role, e.g. to bootstrap the first admin
+	role, _ := claims["role"].(string)
+	if role != "" && !Role(role).IsValid() {
+		return nil, fmt.Errorf("unknown role %s", role)
+	}
+	tenantID, _ := claims["tenant"].(string)
+	return &Principal{
+		Subject:  subject,
+		Role:     Role(role),
+		TenantID: tenantID,
+	}, nil
+}
+
+func (v *JWTVerifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if key, ok := v.hmacKeys[kid]; ok {
+			return key, nil
+		}
+	case jwt.SigningMethodRS256.Alg():
+		if key, ok := v.rsaKeys[kid]; ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s key for kid %q", token.Method.Alg(), kid)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// oct
+	K string `json:"k"`
+}
+
+func (v *JWTVerifier) loadJWKS(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read jwks: %w", err)
+	}
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	err = json.Unmarshal(content, &jwks)
+	if err != nil {
+		return fmt.Errorf("parse jwks: %w", err)
+	}
+
+	for _, key := range jwks.Keys {
+		switch key.Kty {
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(key.N)
+			if err != nil {
+				return fmt.Errorf("parse jwks key %q: %w", key.Kid, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(key.E)
+			if err != nil {
+				return fmt.Errorf("parse jwks key %q: %w", key.Kid, err)
+			}
+			v.rsaKeys[key.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case "oct":
+			k, err := base64.RawURLEncoding.DecodeString(key.K)
+			if err != nil {
+				return fmt.Errorf("parse jwks key %q: %w", key.Kid, err)
+			}
+			v.hmacKeys[key.Kid] = k
+		}
+	}
+	return nil
+}
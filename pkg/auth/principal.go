@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+)
+
+type contextKey int
+
+const principalKey contextKey = iota
+
+// scopes granted to api keys
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// Subject identifies the caller, it owns the tasks the caller creates
+	Subject string
+	// Scopes limits what the caller can do, nil grants every scope
+	Scopes []string
+	// Role is resolved from the role assignments, empty means DefaultRole
+	Role Role
+	// TenantID is the only tenant the caller can work on, empty binds the caller to the default tenant
+	TenantID string
+	// APIKeyID is the id of the api key used by the caller, 0 if the caller is not using an api key
+	APIKeyID int
+}
+
+// HasScope reports whether the principal is granted the scope, write scopes imply the read ones
+func (p *Principal) HasScope(scope string) bool {
+	if p.Scopes == nil {
+		return true
+	}
+	for _, granted := range p.Scopes {
+		if granted == scope || (granted == ScopeTasksWrite && scope == ScopeTasksRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// BoundTenantID returns the only tenant the principal can work on
+func (p *Principal) BoundTenantID() string {
+	if p.TenantID == "" {
+		return requestctx.DefaultTenantID
+	}
+	return p.TenantID
+}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// GetPrincipal returns the principal carried by ctx, nil if the request is not authenticated
+func GetPrincipal(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey).(*Principal)
+	return principal
+}
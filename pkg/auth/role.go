@@ -0,0 +1,23 @@
+package auth
+
+// Role decides the operations a principal is allowed to do
+type Role string
+
+// roles, from the least to the most privileged
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+// DefaultRole is the role of the principals without an assigned role
+const DefaultRole = RoleEditor
+
+// IsValid reports whether the role is one of the known roles
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleViewer, RoleEditor, RoleAdmin:
+		return true
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package code
 
 import (
 	"errors"
+	"sort"
 )
 
 // constants
@@ -10,9 +11,55 @@ const (
 	ParamIncorrect       = 1000
 	NotFound             = 1001
 	Timeout              = 1002
+	BlockedByIncomplete  = 1003
+	DependencyCycle      = 1004
+	Unauthorized         = 1005
+	Forbidden            = 1006
+	TooManyRequests      = 1007
+	IdempotencyKeyReused = 1008
+	UnsupportedMediaType = 1009
+	PatchFailed          = 1010
+	NotAcceptable        = 1011
+	RequestTooLarge      = 1012
+	Conflict             = 1013
 	InternalUnknownError = 2999
 )
 
+// names of the codes, a code without a name is missing from the api documentation
+var names = map[int]string{
+	OK:                   "OK",
+	ParamIncorrect:       "ParamIncorrect",
+	NotFound:             "NotFound",
+	Timeout:              "Timeout",
+	BlockedByIncomplete:  "BlockedByIncomplete",
+	DependencyCycle:      "DependencyCycle",
+	Unauthorized:         "Unauthorized",
+	Forbidden:            "Forbidden",
+	TooManyRequests:      "TooManyRequests",
+	IdempotencyKeyReused: "IdempotencyKeyReused",
+	UnsupportedMediaType: "UnsupportedMediaType",
+	PatchFailed:          "PatchFailed",
+	NotAcceptable:        "NotAcceptable",
+	RequestTooLarge:      "RequestTooLarge",
+	Conflict:             "Conflict",
+	InternalUnknownError: "InternalUnknownError",
+}
+
+// Name returns the name of a code, "" when it is unknown
+func Name(code int) string {
+	return names[code]
+}
+
+// Codes returns every code in ascending order
+func Codes() []int {
+	codes := make([]int, 0, len(names))
+	for code := range names {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	return codes
+}
+
 // define errors
 var (
 	ErrParamIncorrect = errors.New("param incorrect")
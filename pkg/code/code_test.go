@@ -0,0 +1,43 @@
+package code
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCodeSuite(t *testing.T) {
+	suite.Run(t, new(codeSuite))
+}
+
+type codeSuite struct {
+	suite.Suite
+}
+
+// TestNames fails when a constant is added without a name
+func (s *codeSuite) TestNames() {
+	file, err := parser.ParseFile(token.NewFileSet(), "code.go", nil, 0)
+	s.NoError(err)
+
+	var constants []string
+	for _, decl := range file.Decls {
+		if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.CONST {
+			for _, spec := range decl.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					constants = append(constants, name.Name)
+				}
+			}
+		}
+	}
+
+	var named []string
+	for _, code := range Codes() {
+		named = append(named, Name(code))
+	}
+	s.ElementsMatch(constants, named)
+	s.Equal([]int{OK, ParamIncorrect}, Codes()[:2])
+	s.Equal("", Name(-1))
+}
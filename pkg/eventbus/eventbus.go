@@ -0,0 +1,87 @@
+package eventbus
+
+import "sync"
+
+// Event is a published message, the ids increase from 1 in the order of publishing
+type Event[T any] struct {
+	ID   uint64
+	Data T
+}
+
+// Bus delivers the published events to the subscribers in process and keeps the latest ones for replaying
+type Bus[T any] struct {
+	mu               sync.Mutex
+	lastID           uint64
+	replay           []Event[T] // ring buffer, replay[lastID%len] is the latest event
+	subscriberBuffer int
+	subscribers      map[chan Event[T]]struct{}
+}
+
+// New creates a bus keeping replaySize events, a subscriber falling behind by subscriberBuffer events is dropped.
+// The negative sizes are taken as 0.
+func New[T any](replaySize, subscriberBuffer int) *Bus[T] {
+	if replaySize < 0 {
+		replaySize = 0
+	}
+	if subscriberBuffer < 0 {
+		subscriberBuffer = 0
+	}
+	return &Bus[T]{
+		replay:           make([]Event[T], replaySize),
+		subscriberBuffer: subscriberBuffer,
+		subscribers:      map[chan Event[T]]struct{}{},
+	}
+}
+
+// Publish delivers data to every subscriber without waiting for them
+func (b *Bus[T]) Publish(data T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := Event[T]{ID: b.lastID, Data: data}
+	if len(b.replay) > 0 {
+		b.replay[b.lastID%uint64(len(b.replay))] = event
+	}
+
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			// the subscriber may resume from the replay buffer
+			b.unsubscribe(subscriber)
+		}
+	}
+}
+
+// Subscribe returns the kept events published after lastID and a channel of the next events.
+// The channel is closed by cancel or when the subscriber falls behind.
+func (b *Bus[T]) Subscribe(lastID uint64) (replay []Event[T], events <-chan Event[T], cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	size := uint64(len(b.replay))
+	from := lastID + 1
+	if b.lastID >= size && from <= b.lastID-size {
+		// the older events are gone
+		from = b.lastID - size + 1
+	}
+	for id := from; id <= b.lastID && id > 0; id++ {
+		replay = append(replay, b.replay[id%size])
+	}
+
+	subscriber := make(chan Event[T], b.subscriberBuffer)
+	b.subscribers[subscriber] = struct{}{}
+	return replay, subscriber, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.unsubscribe(subscriber)
+	}
+}
+
+func (b *Bus[T]) unsubscribe(subscriber chan Event[T]) {
+	if _, ok := b.subscribers[subscriber]; ok {
+		delete(b.subscribers, subscriber)
+		close(subscriber)
+	}
+}
@@ -0,0 +1,92 @@
+package eventbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type eventBusSuite struct {
+	suite.Suite
+}
+
+func TestEventBusSuite(t *testing.T) {
+	suite.Run(t, new(eventBusSuite))
+}
+
+func ids(events []Event[string]) []uint64 {
+	result := []uint64{}
+	for _, event := range events {
+		result = append(result, event.ID)
+	}
+	return result
+}
+
+func (s *eventBusSuite) TestReplay() {
+	bus := New[string](3, 10)
+	replay, _, cancel := bus.Subscribe(0)
+	defer cancel()
+	s.Empty(replay)
+
+	for _, data := range []string{"a", "b", "c", "d", "e"} {
+		bus.Publish(data)
+	}
+
+	replay, _, cancel = bus.Subscribe(3)
+	cancel()
+	s.Equal([]uint64{4, 5}, ids(replay))
+	s.Equal("d", replay[0].Data)
+
+	// the events before the buffer are gone
+	replay, _, cancel = bus.Subscribe(0)
+	cancel()
+	s.Equal([]uint64{3, 4, 5}, ids(replay))
+
+	replay, _, cancel = bus.Subscribe(5)
+	cancel()
+	s.Empty(replay)
+}
+
+func (s *eventBusSuite) TestSubscribe() {
+	bus := New[string](0, 10)
+	_, events, cancel := bus.Subscribe(0)
+
+	bus.Publish("a")
+	bus.Publish("b")
+	s.Equal(Event[string]{ID: 1, Data: "a"}, <-events)
+	s.Equal(Event[string]{ID: 2, Data: "b"}, <-events)
+
+	cancel()
+	cancel()
+	_, ok := <-events
+	s.False(ok)
+}
+
+func (s *eventBusSuite) TestSlowSubscriberIsDropped() {
+	bus := New[string](10, 1)
+	_, slow, cancel := bus.Subscribe(0)
+	defer cancel()
+
+	bus.Publish("a")
+	bus.Publish("b")
+	s.Equal("a", (<-slow).Data)
+	_, ok := <-slow
+	s.False(ok)
+
+	// it resumes from the replay buffer
+	replay, _, cancel := bus.Subscribe(1)
+	defer cancel()
+	s.Equal([]uint64{2}, ids(replay))
+}
+
+func (s *eventBusSuite) TestNegativeSizes() {
+	bus := New[string](-1, -1)
+	replay, _, cancel := bus.Subscribe(0)
+	defer cancel()
+	s.Empty(replay)
+
+	bus.Publish("a")
+	replay, _, cancel = bus.Subscribe(0)
+	defer cancel()
+	s.Empty(replay)
+}
@@ -0,0 +1,80 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the expired records are dropped
+const sweepInterval = time.Minute
+
+// Response is a response replayed to the retries of a request
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Record is the first response to an idempotency key, Fingerprint identifies the request which got it
+type Record struct {
+	Fingerprint string
+	Response    *Response
+	ExpiresAt   time.Time
+}
+
+// Store keeps the records, the in-memory store can be replaced by a shared one when running several instances
+type Store interface {
+	// Get returns the record of key, nil if there is none or it is expired
+	Get(ctx context.Context, key string, now time.Time) (*Record, error)
+	Put(ctx context.Context, key string, record *Record) error
+}
+
+// InMemoryStore keeps the records in the memory of the process
+type InMemoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+	sweptAt time.Time
+}
+
+// NewInMemoryStore creates an empty InMemoryStore
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		records: map[string]*Record{},
+	}
+}
+
+// Get returns the record of key
+func (s *InMemoryStore) Get(ctx context.Context, key string, now time.Time) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	record, ok := s.records[key]
+	if !ok || !now.Before(record.ExpiresAt) {
+		return nil, nil
+	}
+	return record, nil
+}
+
+// Put stores the record of key
+func (s *InMemoryStore) Put(ctx context.Context, key string, record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = record
+	return nil
+}
+
+func (s *InMemoryStore) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < sweepInterval {
+		return
+	}
+	s.sweptAt = now
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
@@ -6,9 +6,13 @@ import (
 	"time"
 )
 
+// lockRetryInterval is how long Lock sleeps between attempts on a held key
+const lockRetryInterval = time.Millisecond
+
 // LockMap structure stores the lock state for each key in the sync.Map
 type LockMap struct {
-	m              sync.Map // Stores the lock status
+	m sync.Map // Stores the lock status
+	// LockWaitSecond is how many seconds Lock waits for a held key before it times out
 	LockWaitSecond int
 }
 
@@ -20,31 +24,22 @@ func NewLockMap(lockWaitSecond int) *LockMap {
 	}
 }
 
-// Lock attempts to lock a specific key within the given timeout duration.
-// If the key is already locked, it waits until it is released or the timeout is reached.
+// Lock attempts to lock a specific key within LockWaitSecond seconds.
+// If the key is already locked, it tries again every lockRetryInterval until it is released or the timeout is
+// reached, a released key is always locked at once.
 func (lm *LockMap) Lock(key interface{}) error {
-	var mu *sync.Mutex
-	for {
-		actual, loaded := lm.m.LoadOrStore(key, &sync.Mutex{})
-		mu = actual.(*sync.Mutex)
-
-		if !loaded {
-			mu.Lock()
-			return nil
-		}
+	actual, _ := lm.m.LoadOrStore(key, &sync.Mutex{})
+	mu := actual.(*sync.Mutex)
 
-		// Use a select statement to wait for either the lock to be available
-		// or for the timeout to expire.
-		select {
-		case <-time.After(time.Duration(lm.LockWaitSecond)):
+	deadline := time.Now().Add(time.Duration(lm.LockWaitSecond) * time.Second)
+	for !mu.TryLock() {
+		if time.Now().After(deadline) {
 			// Timeout reached, return an error
 			return errors.New("lock timeout")
-		default:
-			// Attempt to lock
-			mu.Lock()
-			return nil
 		}
+		time.Sleep(lockRetryInterval)
 	}
+	return nil
 }
 
 // Unlock releases the lock for a specific key
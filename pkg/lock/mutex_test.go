@@ -0,0 +1,58 @@
+package lock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type lockMapSuite struct {
+	suite.Suite
+}
+
+func TestLockMapSuite(t *testing.T) {
+	suite.Run(t, new(lockMapSuite))
+}
+
+func (s *lockMapSuite) TestRelock() {
+	lm := NewLockMap(1)
+	// a released key can be locked again every time, the wait is not a coin flip with the timeout
+	for i := 0; i < 1000; i++ {
+		s.Require().NoError(lm.Lock(1))
+		lm.Unlock(1)
+	}
+}
+
+func (s *lockMapSuite) TestKeysAreIndependent() {
+	lm := NewLockMap(1)
+	s.Require().NoError(lm.Lock(1))
+	defer lm.Unlock(1)
+
+	s.NoError(lm.Lock(2))
+	lm.Unlock(2)
+}
+
+func (s *lockMapSuite) TestWaitForRelease() {
+	lm := NewLockMap(1)
+	s.Require().NoError(lm.Lock(1))
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		lm.Unlock(1)
+	}()
+
+	start := time.Now()
+	s.NoError(lm.Lock(1))
+	s.GreaterOrEqual(time.Since(start), 100*time.Millisecond)
+	lm.Unlock(1)
+}
+
+func (s *lockMapSuite) TestTimeout() {
+	lm := NewLockMap(1)
+	s.Require().NoError(lm.Lock(1))
+	defer lm.Unlock(1)
+
+	start := time.Now()
+	s.EqualError(lm.Lock(1), "lock timeout")
+	s.GreaterOrEqual(time.Since(start), time.Second)
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the buckets refilled to full are dropped
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	limit     Limit
+}
+
+// refill adds the tokens earned since the last update
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updatedAt); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Requests), b.tokens+elapsed.Seconds()*b.rate())
+		b.updatedAt = now
+	}
+}
+
+// rate is the number of tokens earned per second
+func (b *bucket) rate() float64 {
+	return float64(b.limit.Requests) / b.limit.Period.Seconds()
+}
+
+// result describes the bucket after a request was allowed or not
+func (b *bucket) result(allowed bool) *Result {
+	result := &Result{Limit: b.limit.Requests, Allowed: allowed}
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / b.rate())
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = secondsToDuration((float64(b.limit.Requests) - b.tokens) / b.rate())
+	return result
+}
+
+func (b *bucket) full() bool {
+	return b.tokens >= float64(b.limit.Requests)
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
+
+// InMemoryStore keeps the buckets in the memory of the process
+type InMemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	sweptAt time.Time
+}
+
+// NewInMemoryStore creates an empty InMemoryStore
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Take takes a token from the bucket of key
+func (s *InMemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	b, ok := s.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Requests), updatedAt: now, limit: limit}
+		s.buckets[key] = b
+	}
+	b.refill(now)
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return b.result(allowed), nil
+}
+
+// Peek returns the state of the bucket of key, a missing bucket is full
+func (s *InMemoryStore) Peek(ctx context.Context, key string, limit Limit, now time.Time) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Requests), updatedAt: now, limit: limit}
+	}
+	b.refill(now)
+	return b.result(b.tokens >= 1), nil
+}
+
+// sweep drops the buckets which are full again, they are the same as the new ones
+func (s *InMemoryStore) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < sweepInterval {
+		return
+	}
+	s.sweptAt = now
+	for key, b := range s.buckets {
+		b.refill(now)
+		if b.full() {
+			delete(s.buckets, key)
+		}
+	}
+}
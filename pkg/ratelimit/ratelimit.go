@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+)
+
+// DefaultRoute is the route of the rule applied to the routes without their own rule
+const DefaultRoute = "*"
+
+// Limit allows Requests requests per Period, a client may spend them all at once
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Result is the state of a bucket after taking a token from it
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long it takes to refill the bucket
+	Reset time.Duration
+	// RetryAfter is how long it takes to get the next token, 0 if the request is allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the token buckets, the in-memory store can be replaced by a shared one when running several instances
+type Store interface {
+	// Take takes a token from the bucket of key, which is created full with limit
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (*Result, error)
+	// Peek returns the state of the bucket of key without taking a token, Allowed reports a token is left
+	Peek(ctx context.Context, key string, limit Limit, now time.Time) (*Result, error)
+}
+
+// ClientKey identifies a client by its api key, its user or its ip when it is anonymous
+func ClientKey(principal *auth.Principal, clientIP string) string {
+	switch {
+	case principal == nil:
+		return "ip:" + clientIP
+	case principal.APIKeyID != 0:
+		return "apikey:" + strconv.Itoa(principal.APIKeyID)
+	default:
+		return "user:" + principal.Subject
+	}
+}
+
+// Rules key: "METHOD /full/path" of a gin route or DefaultRoute
+type Rules map[string]Limit
+
+// Match returns the limit of a route
+func (r Rules) Match(method, path string) (Limit, bool) {
+	if limit, ok := r[method+" "+path]; ok {
+		return limit, true
+	}
+	limit, ok := r[DefaultRoute]
+	return limit, ok
+}
+
+// ParseRules parses rules like "POST /v1/tasks=10/1m;*=100/1m", each one allows the number of requests per period to a route
+func ParseRules(s string) (Rules, error) {
+	rules := Rules{}
+	for _, rule := range strings.Split(s, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		route, value, ok := strings.Cut(rule, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate limit rule %q has no limit", rule)
+		}
+		limit, err := ParseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit rule %q: %w", rule, err)
+		}
+		rules[strings.Join(strings.Fields(route), " ")] = limit
+	}
+	return rules, nil
+}
+
+// ParseLimit parses a limit like "10/1m", the number of requests per period
+func ParseLimit(s string) (Limit, error) {
+	requests, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("limit %q has no period", s)
+	}
+
+	limit := Limit{}
+	var err error
+	if limit.Requests, err = strconv.Atoi(strings.TrimSpace(requests)); err != nil || limit.Requests <= 0 {
+		return Limit{}, fmt.Errorf("limit %q has an invalid number of requests", s)
+	}
+	if limit.Period, err = time.ParseDuration(strings.TrimSpace(period)); err != nil || limit.Period <= 0 {
+		return Limit{}, fmt.Errorf("limit %q has an invalid period", s)
+	}
+	return limit, nil
+}
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type rateLimitSuite struct {
+	suite.Suite
+}
+
+func TestRateLimitSuite(t *testing.T) {
+	suite.Run(t, new(rateLimitSuite))
+}
+
+func (s *rateLimitSuite) TestParseRules() {
+	rules, err := ParseRules(" POST  /v1/tasks=10/1m; *=100/1s ;")
+	s.NoError(err)
+	s.Equal(Rules{
+		"POST /v1/tasks": {Requests: 10, Period: time.Minute},
+		DefaultRoute:     {Requests: 100, Period: time.Second},
+	}, rules)
+
+	limit, ok := rules.Match("POST", "/v1/tasks")
+	s.True(ok)
+	s.Equal(10, limit.Requests)
+	limit, ok = rules.Match("GET", "/v1/tasks")
+	s.True(ok)
+	s.Equal(100, limit.Requests)
+
+	rules, err = ParseRules("")
+	s.NoError(err)
+	_, ok = rules.Match("GET", "/v1/tasks")
+	s.False(ok)
+
+	for _, invalid := range []string{"POST /v1/tasks", "POST /v1/tasks=10", "*=0/1m", "*=ten/1m", "*=10/minute", "*=10/-1m"} {
+		_, err = ParseRules(invalid)
+		s.Error(err, invalid)
+	}
+}
+
+func (s *rateLimitSuite) TestTokenBucket() {
+	store := NewInMemoryStore()
+	limit := Limit{Requests: 2, Period: 2 * time.Second}
+	now := time.Now()
+	ctx := context.Background()
+
+	result, err := store.Take(ctx, "alice", limit, now)
+	s.NoError(err)
+	s.True(result.Allowed)
+	s.Equal(1, result.Remaining)
+	s.Equal(time.Second, result.Reset)
+
+	result, _ = store.Take(ctx, "alice", limit, now)
+	s.True(result.Allowed)
+	s.Equal(0, result.Remaining)
+
+	result, _ = store.Take(ctx, "alice", limit, now.Add(500*time.Millisecond))
+	s.False(result.Allowed)
+	s.Equal(500*time.Millisecond, result.RetryAfter)
+	s.Equal(1500*time.Millisecond, result.Reset)
+
+	// another client has its own bucket
+	result, _ = store.Take(ctx, "bob", limit, now)
+	s.True(result.Allowed)
+
+	result, _ = store.Take(ctx, "alice", limit, now.Add(time.Second))
+	s.True(result.Allowed)
+	s.Equal(0, result.Remaining)
+}
+
+func (s *rateLimitSuite) TestSweep() {
+	store := NewInMemoryStore()
+	limit := Limit{Requests: 1, Period: time.Second}
+	now := time.Now()
+
+	_, _ = store.Take(context.Background(), "alice", limit, now)
+	_, _ = store.Take(context.Background(), "bob", limit, now.Add(sweepInterval))
+	s.Equal(1, len(store.buckets))
+	s.Contains(store.buckets, "bob")
+}
+
+func (s *rateLimitSuite) TestPeek() {
+	store := NewInMemoryStore()
+	limit := Limit{Requests: 1, Period: time.Second}
+	now := time.Now()
+	ctx := context.Background()
+
+	result, err := store.Peek(ctx, "alice", limit, now)
+	s.NoError(err)
+	s.True(result.Allowed)
+	s.Equal(1, result.Remaining)
+	// peeking neither takes a token nor creates a bucket
+	s.Empty(store.buckets)
+
+	_, _ = store.Take(ctx, "alice", limit, now)
+	result, _ = store.Peek(ctx, "alice", limit, now)
+	s.False(result.Allowed)
+	s.Equal(time.Second, result.RetryAfter)
+	result, _ = store.Peek(ctx, "alice", limit, now.Add(time.Second))
+	s.True(result.Allowed)
+}
+
+func (s *rateLimitSuite) TestParseLimit() {
+	limit, err := ParseLimit(" 20 / 1m ")
+	s.NoError(err)
+	s.Equal(Limit{Requests: 20, Period: time.Minute}, limit)
+
+	for _, invalid := range []string{"", "20", "0/1m", "20/forever"} {
+		_, err = ParseLimit(invalid)
+		s.Error(err, invalid)
+	}
+}
@@ -0,0 +1,82 @@
+package requestctx
+
+import "context"
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	requestIDKey
+	tenantIDKey
+	clientIPKey
+)
+
+// DefaultTenantID is the tenant of the requests not naming a tenant
+const DefaultTenantID = "default"
+
+// ActorSystem is the actor of the changes made by the service itself, e.g. background jobs
+const ActorSystem = "system"
+
+// actor is who makes the request, verified is false when the caller named itself without credentials
+type actor struct {
+	name     string
+	verified bool
+}
+
+// WithActor returns a copy of ctx carrying the actor who makes the request, proven by its credentials
+func WithActor(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, actorKey, actor{name: name, verified: true})
+}
+
+// WithUnverifiedActor returns a copy of ctx carrying the actor the caller claims to be
+func WithUnverifiedActor(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, actorKey, actor{name: name})
+}
+
+// GetActor returns the actor carried by ctx, empty if there is none
+func GetActor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey).(actor)
+	return actor.name
+}
+
+// IsActorVerified reports whether the actor carried by ctx is proven by credentials
+func IsActorVerified(ctx context.Context) bool {
+	actor, _ := ctx.Value(actorKey).(actor)
+	return actor.verified
+}
+
+// WithRequestID returns a copy of ctx carrying the request id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// GetRequestID returns the request id carried by ctx, empty if there is none
+func GetRequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithTenantID returns a copy of ctx carrying the tenant the request works on
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantIDKey, tenantID)
+}
+
+// GetTenantID returns the tenant carried by ctx, DefaultTenantID if there is none
+func GetTenantID(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantIDKey).(string)
+	if tenantID == "" {
+		return DefaultTenantID
+	}
+	return tenantID
+}
+
+// WithClientIP returns a copy of ctx carrying the ip address of the client
+func WithClientIP(ctx context.Context, clientIP string) context.Context {
+	return context.WithValue(ctx, clientIPKey, clientIP)
+}
+
+// GetClientIP returns the ip address of the client carried by ctx, empty if there is none
+func GetClientIP(ctx context.Context) string {
+	clientIP, _ := ctx.Value(clientIPKey).(string)
+	return clientIP
+}
@@ -0,0 +1,54 @@
+// Package testutil holds the helpers shared by the handler tests
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// NewJSONRequest builds a request with body encoded as json, a nil body sends no body
+func NewJSONRequest(t testing.TB, method, url string, body interface{}) *http.Request {
+	var reader io.Reader
+	if body != nil {
+		jsonStr, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(jsonStr)
+	}
+	return httptest.NewRequest(method, url, reader)
+}
+
+// Serve sends req to handler and records the response, headers are name and value pairs,
+// the headers with an empty value are not set
+func Serve(handler http.Handler, req *http.Request, headers ...string) *httptest.ResponseRecorder {
+	for i := 0; i+1 < len(headers); i += 2 {
+		if headers[i+1] != "" {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+// ResponseCode returns the code of a json response
+func ResponseCode(t testing.TB, w *httptest.ResponseRecorder) int {
+	var response struct {
+		Code int `json:"code"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), w.Body.String())
+	return response.Code
+}
+
+// Bearer is the Authorization header of a bearer token, empty when there is no token
+func Bearer(token string) string {
+	if token == "" {
+		return ""
+	}
+	return "Bearer " + token
+}
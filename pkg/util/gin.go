@@ -1,15 +1,21 @@
 package util
 
 import (
-	"bytes"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/Yu-Qi/restful_api/pkg/api/media"
 	"github.com/Yu-Qi/restful_api/pkg/code"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 // AfterBinding is a function that gets called after the successful binding prvovied by GinContextExt.
@@ -35,19 +41,82 @@ func ToGinContextExt(ginCtx *gin.Context) *GinContextExt {
 // Provides convenient methods for post-processing of any object.
 type GinContextExt gin.Context
 
-// Bind binds the request body to a struct and panics if there is any error.
+// Bind binds the request body to a struct by the Content-Type, YAML, MessagePack and Protobuf are bound like
+// BindJson does
 func (self *GinContextExt) Bind(v interface{}) *code.CustomError {
 	ctx := (*gin.Context)(self)
+	switch mediaType := media.Canonical(ctx.GetHeader("Content-Type")); mediaType {
+	case media.MIMEYAML, media.MIMEMsgPack, media.MIMEProtobuf:
+		return self.bindBody(ctx, mediaType, v)
+	}
 	if err := ctx.ShouldBind(v); err != nil {
-		return code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		return BodyError(err)
 	}
 	return self.afterBindingAndValidate(ctx, v)
 }
 
-// BindJson binds the request body to a struct and panics if there is any error.
+// BindJson binds the request body to a struct by its json tags. The body is JSON unless the Content-Type is YAML,
+// MessagePack or Protobuf, the other types are rejected with a 415.
 func (self *GinContextExt) BindJson(v interface{}) *code.CustomError {
 	ctx := (*gin.Context)(self)
-	if err := ctx.ShouldBindJSON(v); err != nil {
+	mediaType := media.Canonical(ctx.GetHeader("Content-Type"))
+	switch {
+	case mediaType == "" || mediaType == media.MIMEJSON || strings.HasSuffix(mediaType, "+json"):
+		if err := ctx.ShouldBindJSON(v); err != nil {
+			return BodyError(err)
+		}
+		return self.afterBindingAndValidate(ctx, v)
+	case mediaType == media.MIMEYAML || mediaType == media.MIMEMsgPack || mediaType == media.MIMEProtobuf:
+		return self.bindBody(ctx, mediaType, v)
+	}
+	return code.NewCustomError(code.UnsupportedMediaType, http.StatusUnsupportedMediaType,
+		fmt.Errorf("content type %s is not supported", mediaType))
+}
+
+// BodyError is the error of reading a request body, a 413 when the body is longer than the limit set by
+// middleware.LimitBody
+func BodyError(err error) *code.CustomError {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return code.NewCustomError(code.RequestTooLarge, http.StatusRequestEntityTooLarge,
+			fmt.Errorf("the request body is larger than %d bytes", maxBytesErr.Limit))
+	}
+	return code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+}
+
+// ProtoBinding is implemented by the structs taking a Protobuf body, the message is decoded and then bound
+// to the struct by its JSON form
+type ProtoBinding interface {
+	NewProtoMessage() proto.Message
+}
+
+// bindBody binds a YAML, MessagePack or Protobuf body and validates it like ShouldBindJSON
+func (self *GinContextExt) bindBody(ctx *gin.Context, mediaType string, v interface{}) *code.CustomError {
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return BodyError(err)
+	}
+
+	if mediaType == media.MIMEProtobuf {
+		protoBinding, ok := v.(ProtoBinding)
+		if !ok {
+			return code.NewCustomError(code.UnsupportedMediaType, http.StatusUnsupportedMediaType,
+				fmt.Errorf("the request can't be sent as protobuf"))
+		}
+		message := protoBinding.NewProtoMessage()
+		if err = proto.Unmarshal(body, message); err != nil {
+			return code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		}
+		if body, err = (protojson.MarshalOptions{UseProtoNames: true}).Marshal(message); err != nil {
+			return code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		}
+		mediaType = media.MIMEJSON
+	}
+
+	if err = media.Unmarshal(mediaType, body, v); err != nil {
+		return code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+	}
+	if err = binding.Validator.ValidateStruct(v); err != nil {
 		return code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
 	}
 	return self.afterBindingAndValidate(ctx, v)
@@ -85,7 +154,7 @@ func (self *GinContextExt) BindRawBody(v interface{}) *code.CustomError {
 	ctx := (*gin.Context)(self)
 	body, err := io.ReadAll(ctx.Request.Body)
 	if err != nil {
-		return code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		return BodyError(err)
 	}
 	err = json.Unmarshal(body, v)
 	if err != nil {
@@ -125,18 +194,148 @@ func (self *GinContextExt) afterBindingAndValidate(ginCtx *gin.Context, v interf
 
 // DownloadCSVFile export csv
 func DownloadCSVFile(ctx *gin.Context, fileName string, header []string, content [][]string) {
-	buffer := &bytes.Buffer{}
-	writer := csv.NewWriter(buffer)
+	_ = StreamCSVFile(ctx, fileName, header, func(write func(row []string) error) error {
+		for _, row := range content {
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// csvFlushRows is how many rows are written between the flushes of a streamed csv file
+const csvFlushRows = 1000
 
-	_ = writer.Write(header)
-	for _, row := range content {
-		_ = writer.Write(row)
+// StreamCSVFile export csv row by row, writeRows calls write for each row after the header.
+// The rows are sent as they are written instead of buffering the whole file, so once the first row is out
+// an error returned by writeRows can only cut the file short, the caller should log it.
+// Nothing is sent when writeRows fails before its first row, the caller can still answer an error.
+func StreamCSVFile(ctx *gin.Context, fileName string, header []string, writeRows func(write func(row []string) error) error) error {
+	writer := csv.NewWriter(ctx.Writer)
+	started := false
+	start := func() error {
+		started = true
+		ctx.Header("Content-Description", "File Transfer")
+		ctx.Header("Content-Disposition", "attachment; filename="+fileName)
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		ctx.Status(http.StatusOK)
+		return writer.Write(header)
+	}
+
+	rows := 0
+	err := writeRows(func(row []string) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+		rows++
+		if rows%csvFlushRows == 0 {
+			writer.Flush()
+			ctx.Writer.Flush()
+			return writer.Error()
+		}
+		return nil
+	})
+	if err != nil && !started {
+		return err
+	}
+	if !started {
+		if err = start(); err != nil {
+			return err
+		}
 	}
 	writer.Flush()
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// MIMENDJSON is the media type of newline delimited json
+const MIMENDJSON = "application/x-ndjson"
 
-	ctx.Header("Content-Description", "File Transfer")
-	ctx.Header("Content-Transfer-Encoding", "binary")
-	ctx.Header("Content-Disposition", "attachment; filename="+fileName)
-	ctx.Header("Content-Type", "application/octet-stream")
-	ctx.Data(http.StatusOK, "text/csv", buffer.Bytes())
+// ndjsonFlushLines is how many lines are written between the flushes of a streamed ndjson response
+const ndjsonFlushLines = 100
+
+// NDJSONWriter streams a response as newline delimited json, one value per line.
+// The status and the headers are sent with the first line, so an error found before it can still be responded.
+type NDJSONWriter struct {
+	ctx     *gin.Context
+	encoder *json.Encoder
+	lines   int
+}
+
+// NewNDJSONWriter create a writer of the response of ctx
+func NewNDJSONWriter(ctx *gin.Context) *NDJSONWriter {
+	return &NDJSONWriter{ctx: ctx}
+}
+
+// Started reports whether the response is sent
+func (w *NDJSONWriter) Started() bool {
+	return w.encoder != nil
+}
+
+// Write sends a value as a line
+func (w *NDJSONWriter) Write(v interface{}) error {
+	w.start()
+	if err := w.encoder.Encode(v); err != nil {
+		return err
+	}
+	w.lines++
+	if w.lines%ndjsonFlushLines == 0 {
+		w.ctx.Writer.Flush()
+	}
+	return nil
+}
+
+// Close sends the rest of the response, it is empty when nothing is written
+func (w *NDJSONWriter) Close() {
+	w.start()
+	w.ctx.Writer.Flush()
+}
+
+func (w *NDJSONWriter) start() {
+	if w.encoder != nil {
+		return
+	}
+	w.ctx.Header("Content-Type", MIMENDJSON)
+	w.ctx.Status(http.StatusOK)
+	w.encoder = json.NewEncoder(w.ctx.Writer)
+}
+
+// NotModified sets the ETag and Last-Modified validators of a response and answers a 304 when the conditional
+// headers of the request match them. If-None-Match is compared weakly and wins over If-Modified-Since, which
+// is only checked for a non-zero modifiedAt.
+func NotModified(ctx *gin.Context, etag string, modifiedAt time.Time) bool {
+	ctx.Header("ETag", etag)
+	if !modifiedAt.IsZero() {
+		ctx.Header("Last-Modified", modifiedAt.UTC().Format(http.TimeFormat))
+	}
+	if ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead {
+		return false
+	}
+
+	matched := false
+	if ifNoneMatch := ctx.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				matched = true
+				break
+			}
+		}
+	} else if ifModifiedSince := ctx.GetHeader("If-Modified-Since"); ifModifiedSince != "" && !modifiedAt.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		// Last-Modified is at second precision
+		matched = err == nil && !modifiedAt.Truncate(time.Second).After(since)
+	}
+	if matched {
+		ctx.Status(http.StatusNotModified)
+	}
+	return matched
 }
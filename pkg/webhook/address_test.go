@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type addressSuite struct {
+	suite.Suite
+}
+
+func TestAddressSuite(t *testing.T) {
+	suite.Run(t, new(addressSuite))
+}
+
+func (s *addressSuite) TestIsPublicIP() {
+	for address, public := range map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"224.0.0.1":        false,
+		"::ffff:127.0.0.1": false,
+	} {
+		s.Equal(public, IsPublicIP(net.ParseIP(address)), address)
+	}
+}
+
+func (s *addressSuite) TestCheckPublicHost() {
+	s.NoError(CheckPublicHost(context.Background(), "93.184.216.34"))
+	s.EqualError(CheckPublicHost(context.Background(), "127.0.0.1"), "host 127.0.0.1 resolves to 127.0.0.1 which is not a public address")
+	s.Error(CheckPublicHost(context.Background(), "localhost"))
+}
+
+func (s *addressSuite) TestPublicClient() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+
+	// the address is checked when connecting, whatever the url was checked against
+	_, err := NewPublicClient(time.Second).Get(server.URL)
+	s.ErrorContains(err, "127.0.0.1 is not a public address")
+	resp, err := http.Get(server.URL)
+	s.NoError(err)
+	s.NoError(resp.Body.Close())
+}
@@ -0,0 +1,31 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type signatureSuite struct {
+	suite.Suite
+}
+
+func TestSignatureSuite(t *testing.T) {
+	suite.Run(t, new(signatureSuite))
+}
+
+func (s *signatureSuite) TestSign() {
+	// echo -n '1700000000.{"id":1}' | openssl dgst -sha256 -hmac secret
+	s.Equal("sha256=3dd1b9aef568d75f6790a84bd2e5dfa1f44409eef3cbdbd3f10b837376100c11",
+		Sign("secret", 1700000000, []byte(`{"id":1}`)))
+}
+
+func (s *signatureSuite) TestVerify() {
+	payload := []byte(`{"id":1}`)
+	signature := Sign("secret", 1700000000, payload)
+	s.True(Verify("secret", 1700000000, payload, signature))
+	s.False(Verify("another secret", 1700000000, payload, signature))
+	s.False(Verify("secret", 1700000001, payload, signature))
+	s.False(Verify("secret", 1700000000, []byte(`{"id":2}`), signature))
+	s.False(Verify("secret", 1700000000, payload, ""))
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/Yu-Qi/restful_api/usecases/apikey/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler represent the http handler for api keys
+type APIKeyHandler struct{}
+
+// NewAPIKeyHandler will initialize the api-keys/ resources endpoint
+func NewAPIKeyHandler(r *gin.RouterGroup) {
+	v1 := r.Group("/v1")
+
+	handler := &APIKeyHandler{}
+	v1.GET("/api-keys", handler.GetAPIKeys)
+	v1.POST("/api-keys", handler.CreateAPIKey)
+	v1.DELETE("/api-keys/:id", handler.RevokeAPIKey)
+}
+
+type createAPIKeyParams struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=tasks:read tasks:write"`
+}
+
+type createAPIKeyResp struct {
+	*domain.APIKey
+	// Key is the plain api key, it is only returned once
+	Key string `json:"key"`
+}
+
+// GetAPIKeys get the api keys of the caller
+func (h *APIKeyHandler) GetAPIKeys(ctx *gin.Context) {
+	keys, customErr := usecase.GetAPIKeys(ctx)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, keys)
+}
+
+// CreateAPIKey issue an api key
+func (h *APIKeyHandler) CreateAPIKey(ctx *gin.Context) {
+	params := createAPIKeyParams{}
+	customErr := util.ToGinContextExt(ctx).BindJson(&params)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	key, plainKey, customErr := usecase.CreateAPIKey(ctx, params.Name, params.Scopes)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, &createAPIKeyResp{APIKey: key, Key: plainKey})
+}
+
+// RevokeAPIKey revoke an api key
+func (h *APIKeyHandler) RevokeAPIKey(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		customErr := code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	customErr := usecase.RevokeAPIKey(ctx, id)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, nil)
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+	_apiKeyRepo "github.com/Yu-Qi/restful_api/usecases/apikey/repository/in_memory"
+	_apiKeyUsecase "github.com/Yu-Qi/restful_api/usecases/apikey/usecase"
+	_taskHttpDelivery "github.com/Yu-Qi/restful_api/usecases/task/delivery/http"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+func TestAPIKeySuite(t *testing.T) {
+	suite.Run(t, new(apiKeySuite))
+}
+
+type apiKeySuite struct {
+	suite.Suite
+	Router   *gin.Engine
+	Ctx      context.Context
+	HS256Key []byte
+}
+
+type apiKeyResp struct {
+	ID         int        `json:"id"`
+	Key        string     `json:"key"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func (s *apiKeySuite) SetupSuite() {
+	s.HS256Key = []byte("secret")
+	verifier, err := auth.NewJWTVerifier(&auth.JWTConfig{HS256Secret: s.HS256Key})
+	s.NoError(err)
+
+	s.Router = gin.Default()
+	s.Router.ContextWithFallback = true
+	authenticate := middleware.Authenticate(
+		middleware.BearerScheme(verifier),
+		middleware.APIKeyScheme(_apiKeyUsecase.VerifyAPIKey),
+	)
+	NewAPIKeyHandler(s.Router.Group("", authenticate))
+	_taskHttpDelivery.NewTaskHandler(s.Router.Group("", authenticate, middleware.RequireScope(auth.ScopeTasksRead, auth.ScopeTasksWrite)))
+}
+
+func (s *apiKeySuite) SetupTest() {
+	_apiKeyUsecase.Init(_apiKeyUsecase.InitParam{
+		APIKeyRepo: _apiKeyRepo.NewInMemoryAPIKeyRepo(),
+	})
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: _taskRepo.NewInMemoryTaskRepo(),
+	})
+
+	s.Ctx = context.Background()
+}
+
+func (s *apiKeySuite) bearer(subject string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": subject,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(s.HS256Key)
+	s.NoError(err)
+	return "Bearer " + token
+}
+
+func (s *apiKeySuite) serve(method, url, authorization string, body interface{}) *httptest.ResponseRecorder {
+	return testutil.Serve(s.Router, testutil.NewJSONRequest(s.T(), method, url, body), "Authorization", authorization)
+}
+
+func (s *apiKeySuite) createAPIKey(authorization string, scopes ...string) *apiKeyResp {
+	w := s.serve("POST", "/v1/api-keys", authorization, map[string]interface{}{"name": "service", "scopes": scopes})
+	s.Equal(http.StatusOK, w.Code)
+	var response struct {
+		Data *apiKeyResp `json:"data"`
+	}
+	s.Nil(json.Unmarshal(w.Body.Bytes(), &response))
+	return response.Data
+}
+
+func (s *apiKeySuite) getAPIKeys(authorization string) []*apiKeyResp {
+	w := s.serve("GET", "/v1/api-keys", authorization, nil)
+	s.Equal(http.StatusOK, w.Code)
+	var response struct {
+		Data []*apiKeyResp `json:"data"`
+	}
+	s.Nil(json.Unmarshal(w.Body.Bytes(), &response))
+	return response.Data
+}
+
+func (s *apiKeySuite) TestCreateAPIKey() {
+	alice := s.bearer("alice")
+	key := s.createAPIKey(alice, auth.ScopeTasksRead)
+	s.Equal(1, key.ID)
+	s.Contains(key.Key, key.Prefix)
+
+	keys := s.getAPIKeys(alice)
+	s.Equal(1, len(keys))
+	s.Equal("", keys[0].Key)
+	s.Equal([]string{auth.ScopeTasksRead}, keys[0].Scopes)
+	s.Nil(keys[0].LastUsedAt)
+	s.Equal(0, len(s.getAPIKeys(s.bearer("bob"))))
+
+	s.Equal(http.StatusBadRequest, s.serve("POST", "/v1/api-keys", alice, map[string]interface{}{"name": "service", "scopes": []string{"admin"}}).Code)
+	s.Equal(http.StatusBadRequest, s.serve("POST", "/v1/api-keys", alice, map[string]interface{}{"name": "service"}).Code)
+}
+
+func (s *apiKeySuite) TestAPIKeyScopes() {
+	alice := s.bearer("alice")
+	readKey := "ApiKey " + s.createAPIKey(alice, auth.ScopeTasksRead).Key
+	writeKey := "ApiKey " + s.createAPIKey(alice, auth.ScopeTasksWrite).Key
+
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks", writeKey, map[string]interface{}{"name": "from service", "status": 0}).Code)
+	s.Equal(http.StatusOK, s.serve("GET", "/v1/tasks", writeKey, nil).Code)
+
+	w := s.serve("GET", "/v1/tasks", readKey, nil)
+	s.Equal(http.StatusOK, w.Code)
+	var response struct {
+		Data []struct {
+			OwnerID string `json:"owner_id"`
+		} `json:"data"`
+	}
+	s.Nil(json.Unmarshal(w.Body.Bytes(), &response))
+	s.Equal(1, len(response.Data))
+	s.Equal("alice", response.Data[0].OwnerID)
+
+	w = s.serve("PUT", "/v1/tasks/1", readKey, map[string]interface{}{"name": "renamed", "status": 0})
+	s.Equal(http.StatusForbidden, w.Code)
+	s.Equal(code.Forbidden, testutil.ResponseCode(s.T(), w))
+
+	for _, key := range s.getAPIKeys(alice) {
+		s.NotNil(key.LastUsedAt)
+	}
+
+	// api keys cannot issue api keys
+	s.Equal(http.StatusForbidden, s.serve("POST", "/v1/api-keys", writeKey, map[string]interface{}{"name": "nested", "scopes": []string{auth.ScopeTasksWrite}}).Code)
+}
+
+func (s *apiKeySuite) TestRevokeAPIKey() {
+	alice := s.bearer("alice")
+	key := s.createAPIKey(alice, auth.ScopeTasksRead)
+	s.Equal(http.StatusOK, s.serve("GET", "/v1/tasks", "ApiKey "+key.Key, nil).Code)
+
+	s.Equal(http.StatusNotFound, s.serve("DELETE", "/v1/api-keys/1", s.bearer("bob"), nil).Code)
+	s.Equal(http.StatusNotFound, s.serve("DELETE", "/v1/api-keys/100", alice, nil).Code)
+	s.Equal(http.StatusOK, s.serve("DELETE", "/v1/api-keys/1", alice, nil).Code)
+
+	w := s.serve("GET", "/v1/tasks", "ApiKey "+key.Key, nil)
+	s.Equal(http.StatusUnauthorized, w.Code)
+	s.Equal(code.Unauthorized, testutil.ResponseCode(s.T(), w))
+	s.NotNil(s.getAPIKeys(alice)[0].RevokedAt)
+}
+
+func (s *apiKeySuite) TestInvalidAPIKey() {
+	key := s.createAPIKey(s.bearer("alice"), auth.ScopeTasksRead)
+
+	for _, invalid := range []string{"ApiKey " + key.Key + "0", "ApiKey tk_" + key.Prefix, "ApiKey nonsense", "ApiKey", "Basic " + key.Key} {
+		w := s.serve("GET", "/v1/tasks", invalid, nil)
+		s.Equal(http.StatusUnauthorized, w.Code, invalid)
+	}
+}
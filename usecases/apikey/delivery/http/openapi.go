@@ -0,0 +1,22 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/openapi"
+)
+
+// apiKeyIDParams is the path parameter, for the documentation
+type apiKeyIDParams struct {
+	ID int `uri:"id"`
+}
+
+// OpenAPIRoutes documents the routes of NewAPIKeyHandler
+func OpenAPIRoutes() []*openapi.Route {
+	return []*openapi.Route{
+		{Method: http.MethodGet, Path: "/v1/api-keys", Summary: "List the api keys of the caller", Response: []*domain.APIKey{}},
+		{Method: http.MethodPost, Path: "/v1/api-keys", Summary: "Issue an api key", Body: createAPIKeyParams{}, Response: createAPIKeyResp{}},
+		{Method: http.MethodDelete, Path: "/v1/api-keys/:id", Summary: "Revoke an api key", Params: apiKeyIDParams{}},
+	}
+}
@@ -0,0 +1,146 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/domain/model"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+type inMemoryAPIKeyRepo struct {
+	StorageMap sync.Map // key: api key id, value: *model.APIKey
+	PrefixMap  sync.Map // key: prefix, value: api key id
+	// WriteLock serializes the writes, the stored models are replaced instead of mutated
+	WriteLock sync.Mutex
+	APIKeyID  int
+}
+
+// NewInMemoryAPIKeyRepo will create an object that represent the apikey.Repository interface
+func NewInMemoryAPIKeyRepo() domain.APIKeyRepository {
+	return &inMemoryAPIKeyRepo{}
+}
+
+// CreateAPIKey will create an api key
+func (i *inMemoryAPIKeyRepo) CreateAPIKey(ctx context.Context, key *domain.APIKey) *code.CustomError {
+	i.WriteLock.Lock()
+	defer i.WriteLock.Unlock()
+
+	if _, ok := i.PrefixMap.Load(key.Prefix); ok {
+		return code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, fmt.Errorf("api key prefix %s is taken", key.Prefix))
+	}
+
+	i.APIKeyID++
+	key.ID = i.APIKeyID
+	i.StorageMap.Store(key.ID, &model.APIKey{
+		Id:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		OwnerId:    key.OwnerID,
+		TenantId:   key.TenantID,
+		Scopes:     append([]string(nil), key.Scopes...),
+		Salt:       key.Salt,
+		Hash:       key.Hash,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	})
+	i.PrefixMap.Store(key.Prefix, key.ID)
+	return nil
+}
+
+// GetAPIKey will get an api key by id
+func (i *inMemoryAPIKeyRepo) GetAPIKey(ctx context.Context, id int) (*domain.APIKey, *code.CustomError) {
+	modelKey, ok := i.loadAPIKey(id)
+	if !ok {
+		return nil, newNotFoundError()
+	}
+	return toDomainAPIKey(modelKey), nil
+}
+
+// GetAPIKeyByPrefix will get an api key by the public prefix
+func (i *inMemoryAPIKeyRepo) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, *code.CustomError) {
+	id, ok := i.PrefixMap.Load(prefix)
+	if !ok {
+		return nil, newNotFoundError()
+	}
+	return i.GetAPIKey(ctx, id.(int))
+}
+
+// GetAPIKeysByOwner will get the api keys of an owner, ordered by id
+func (i *inMemoryAPIKeyRepo) GetAPIKeysByOwner(ctx context.Context, ownerID string) ([]*domain.APIKey, *code.CustomError) {
+	keys := []*domain.APIKey{}
+	i.StorageMap.Range(func(_, value interface{}) bool {
+		modelKey := value.(*model.APIKey)
+		if modelKey.OwnerId == ownerID {
+			keys = append(keys, toDomainAPIKey(modelKey))
+		}
+		return true
+	})
+	sort.Slice(keys, func(a, b int) bool { return keys[a].ID < keys[b].ID })
+	return keys, nil
+}
+
+// RevokeAPIKey will revoke an api key, revoking a revoked key keeps the first revocation time
+func (i *inMemoryAPIKeyRepo) RevokeAPIKey(ctx context.Context, id int, revokedAt time.Time) *code.CustomError {
+	return i.update(id, func(key *model.APIKey) {
+		if key.RevokedAt == nil {
+			key.RevokedAt = &revokedAt
+		}
+	})
+}
+
+// TouchAPIKey will record the last time an api key was used
+func (i *inMemoryAPIKeyRepo) TouchAPIKey(ctx context.Context, id int, usedAt time.Time) *code.CustomError {
+	return i.update(id, func(key *model.APIKey) {
+		key.LastUsedAt = &usedAt
+	})
+}
+
+// update replaces the stored key by a modified copy
+func (i *inMemoryAPIKeyRepo) update(id int, modify func(*model.APIKey)) *code.CustomError {
+	i.WriteLock.Lock()
+	defer i.WriteLock.Unlock()
+
+	modelKey, ok := i.loadAPIKey(id)
+	if !ok {
+		return newNotFoundError()
+	}
+	updated := *modelKey
+	modify(&updated)
+	i.StorageMap.Store(id, &updated)
+	return nil
+}
+
+func (i *inMemoryAPIKeyRepo) loadAPIKey(id int) (*model.APIKey, bool) {
+	value, ok := i.StorageMap.Load(id)
+	if !ok {
+		return nil, false
+	}
+	return value.(*model.APIKey), true
+}
+
+func newNotFoundError() *code.CustomError {
+	return code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("api key not found"))
+}
+
+func toDomainAPIKey(modelKey *model.APIKey) *domain.APIKey {
+	return &domain.APIKey{
+		ID:         modelKey.Id,
+		Name:       modelKey.Name,
+		Prefix:     modelKey.Prefix,
+		OwnerID:    modelKey.OwnerId,
+		TenantID:   modelKey.TenantId,
+		Scopes:     append([]string(nil), modelKey.Scopes...),
+		Salt:       modelKey.Salt,
+		Hash:       modelKey.Hash,
+		CreatedAt:  modelKey.CreatedAt,
+		LastUsedAt: modelKey.LastUsedAt,
+		RevokedAt:  modelKey.RevokedAt,
+	}
+}
@@ -0,0 +1,72 @@
+package inmemory
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/stretchr/testify/suite"
+)
+
+type apiKeySuite struct {
+	suite.Suite
+	apiKeyRepo domain.APIKeyRepository
+}
+
+func TestAPIKeySuite(t *testing.T) {
+	suite.Run(t, new(apiKeySuite))
+}
+
+func (s *apiKeySuite) SetupTest() {
+	s.apiKeyRepo = NewInMemoryAPIKeyRepo()
+
+	ctx := context.Background()
+	s.Nil(s.apiKeyRepo.CreateAPIKey(ctx, &domain.APIKey{Name: "ci", Prefix: "p1", OwnerID: "alice", Scopes: []string{"tasks:read"}}))
+	s.Nil(s.apiKeyRepo.CreateAPIKey(ctx, &domain.APIKey{Name: "cron", Prefix: "p2", OwnerID: "bob", Scopes: []string{"tasks:write"}}))
+}
+
+func (s *apiKeySuite) TestCreateAPIKey() {
+	key := &domain.APIKey{Name: "deploy", Prefix: "p3", OwnerID: "alice"}
+	s.Nil(s.apiKeyRepo.CreateAPIKey(context.Background(), key))
+	s.Equal(3, key.ID)
+
+	customErr := s.apiKeyRepo.CreateAPIKey(context.Background(), &domain.APIKey{Prefix: "p3"})
+	s.NotNil(customErr)
+	s.Equal(http.StatusBadRequest, customErr.HttpStatus)
+}
+
+func (s *apiKeySuite) TestGetAPIKeys() {
+	key, customErr := s.apiKeyRepo.GetAPIKeyByPrefix(context.Background(), "p2")
+	s.Nil(customErr)
+	s.Equal(2, key.ID)
+	s.Equal("cron", key.Name)
+
+	_, customErr = s.apiKeyRepo.GetAPIKeyByPrefix(context.Background(), "unknown")
+	s.NotNil(customErr)
+	s.Equal(http.StatusNotFound, customErr.HttpStatus)
+
+	keys, customErr := s.apiKeyRepo.GetAPIKeysByOwner(context.Background(), "alice")
+	s.Nil(customErr)
+	s.Equal(1, len(keys))
+	s.Equal("ci", keys[0].Name)
+}
+
+func (s *apiKeySuite) TestRevokeAndTouchAPIKey() {
+	ctx := context.Background()
+	revokedAt := time.Now()
+	s.Nil(s.apiKeyRepo.RevokeAPIKey(ctx, 1, revokedAt))
+	s.Nil(s.apiKeyRepo.RevokeAPIKey(ctx, 1, revokedAt.Add(time.Hour)))
+	usedAt := revokedAt.Add(time.Minute)
+	s.Nil(s.apiKeyRepo.TouchAPIKey(ctx, 1, usedAt))
+
+	key, customErr := s.apiKeyRepo.GetAPIKey(ctx, 1)
+	s.Nil(customErr)
+	s.True(revokedAt.Equal(*key.RevokedAt))
+	s.True(usedAt.Equal(*key.LastUsedAt))
+
+	customErr = s.apiKeyRepo.RevokeAPIKey(ctx, 100, revokedAt)
+	s.NotNil(customErr)
+	s.Equal(http.StatusNotFound, customErr.HttpStatus)
+}
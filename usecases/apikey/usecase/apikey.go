@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	customlog "github.com/Yu-Qi/restful_api/pkg/custom_log"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+)
+
+// an api key looks like tk_<prefix>_<secret>, both parts are hex encoded random bytes
+const (
+	keyTag         = "tk"
+	prefixBytes    = 6
+	secretBytes    = 32
+	saltBytes      = 16
+	keyPartsSplits = 3
+)
+
+// CreateAPIKey issue an api key owned by the caller, the returned plain key is never shown again
+func CreateAPIKey(ctx context.Context, name string, scopes []string) (*domain.APIKey, string, *code.CustomError) {
+	principal, customErr := getKeyManager(ctx)
+	if customErr != nil {
+		return nil, "", customErr
+	}
+
+	prefix, err := randomHex(prefixBytes)
+	if err != nil {
+		return nil, "", code.NewCustomError(code.InternalUnknownError, http.StatusInternalServerError, err)
+	}
+	secret, err := randomHex(secretBytes)
+	if err != nil {
+		return nil, "", code.NewCustomError(code.InternalUnknownError, http.StatusInternalServerError, err)
+	}
+	salt := make([]byte, saltBytes)
+	if _, err = rand.Read(salt); err != nil {
+		return nil, "", code.NewCustomError(code.InternalUnknownError, http.StatusInternalServerError, err)
+	}
+
+	key := &domain.APIKey{
+		Name:      name,
+		Prefix:    prefix,
+		OwnerID:   principal.Subject,
+		TenantID:  requestctx.GetTenantID(ctx),
+		Scopes:    scopes,
+		Salt:      salt,
+		Hash:      hashSecret(salt, secret),
+		CreatedAt: time.Now(),
+	}
+	if customErr = apiKeyRepo.CreateAPIKey(ctx, key); customErr != nil {
+		return nil, "", customErr
+	}
+
+	return key, strings.Join([]string{keyTag, prefix, secret}, "_"), nil
+}
+
+// GetAPIKeys get the api keys of the caller
+func GetAPIKeys(ctx context.Context) ([]*domain.APIKey, *code.CustomError) {
+	principal, customErr := getKeyManager(ctx)
+	if customErr != nil {
+		return nil, customErr
+	}
+	return apiKeyRepo.GetAPIKeysByOwner(ctx, principal.Subject)
+}
+
+// RevokeAPIKey revoke an api key of the caller, the key is kept for auditing
+func RevokeAPIKey(ctx context.Context, id int) *code.CustomError {
+	principal, customErr := getKeyManager(ctx)
+	if customErr != nil {
+		return customErr
+	}
+
+	key, customErr := apiKeyRepo.GetAPIKey(ctx, id)
+	if customErr != nil {
+		return customErr
+	}
+	if key.OwnerID != principal.Subject {
+		// the same as for a missing key, so that the caller cannot tell which ids exist
+		return code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("api key not found"))
+	}
+
+	return apiKeyRepo.RevokeAPIKey(ctx, id, time.Now())
+}
+
+// VerifyAPIKey resolve the principal of a plain api key and record the key is used
+func VerifyAPIKey(ctx context.Context, plainKey string) (*auth.Principal, *code.CustomError) {
+	invalidErr := code.NewCustomError(code.Unauthorized, http.StatusUnauthorized, fmt.Errorf("invalid api key"))
+
+	parts := strings.SplitN(plainKey, "_", keyPartsSplits)
+	if len(parts) != keyPartsSplits || parts[0] != keyTag {
+		return nil, invalidErr
+	}
+	key, customErr := apiKeyRepo.GetAPIKeyByPrefix(ctx, parts[1])
+	if customErr != nil {
+		return nil, invalidErr
+	}
+	if subtle.ConstantTimeCompare(key.Hash, hashSecret(key.Salt, parts[2])) != 1 {
+		return nil, invalidErr
+	}
+	if key.RevokedAt != nil {
+		return nil, code.NewCustomError(code.Unauthorized, http.StatusUnauthorized, fmt.Errorf("api key is revoked"))
+	}
+
+	if customErr = apiKeyRepo.TouchAPIKey(ctx, key.ID, time.Now()); customErr != nil {
+		// the request is still authenticated
+		customlog.ErrorfCtx(ctx, "record last used time of api key %d: %v", key.ID, customErr.Error)
+	}
+
+	return &auth.Principal{
+		Subject:  key.OwnerID,
+		Scopes:   key.Scopes,
+		TenantID: key.TenantID,
+		APIKeyID: key.ID,
+	}, nil
+}
+
+// getKeyManager returns the caller who can manage api keys, the callers using an api key cannot
+func getKeyManager(ctx context.Context) (*auth.Principal, *code.CustomError) {
+	principal := auth.GetPrincipal(ctx)
+	if principal == nil {
+		return nil, code.NewCustomError(code.Unauthorized, http.StatusUnauthorized, fmt.Errorf("authentication is required to manage api keys"))
+	}
+	if principal.APIKeyID != 0 {
+		return nil, code.NewCustomError(code.Forbidden, http.StatusForbidden, fmt.Errorf("api keys cannot manage api keys"))
+	}
+	return principal, nil
+}
+
+func hashSecret(salt []byte, secret string) []byte {
+	hash := sha256.New()
+	hash.Write(salt)
+	hash.Write([]byte(secret))
+	return hash.Sum(nil)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
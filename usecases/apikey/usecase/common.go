@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"github.com/Yu-Qi/restful_api/domain"
+)
+
+var (
+	apiKeyRepo domain.APIKeyRepository
+)
+
+// InitParam defines the parameters for initializing the service.
+type InitParam struct {
+	APIKeyRepo domain.APIKeyRepository
+}
+
+// Init injects implementations into the service.
+func Init(param InitParam) {
+	apiKeyRepo = param.APIKeyRepo
+}
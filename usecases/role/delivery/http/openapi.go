@@ -0,0 +1,16 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/openapi"
+)
+
+// OpenAPIRoutes documents the routes of NewRoleHandler
+func OpenAPIRoutes() []*openapi.Route {
+	return []*openapi.Route{
+		{Method: http.MethodGet, Path: "/v1/roles", Summary: "List the role assignments", Response: []*domain.RoleAssignment{}},
+		{Method: http.MethodPut, Path: "/v1/roles/:subject", Summary: "Assign a role to a subject", Body: assignRoleParams{}, Response: &domain.RoleAssignment{}},
+	}
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/Yu-Qi/restful_api/usecases/role/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// RoleHandler represent the http handler for roles
+type RoleHandler struct{}
+
+// NewRoleHandler will initialize the roles/ resources endpoint
+func NewRoleHandler(r *gin.RouterGroup) {
+	v1 := r.Group("/v1")
+
+	handler := &RoleHandler{}
+	v1.GET("/roles", handler.GetRoleAssignments)
+	v1.PUT("/roles/:subject", handler.AssignRole)
+}
+
+type assignRoleParams struct {
+	Role auth.Role `json:"role" binding:"required,oneof=viewer editor admin"`
+}
+
+// GetRoleAssignments get the role assignments of the tenant of the caller
+func (h *RoleHandler) GetRoleAssignments(ctx *gin.Context) {
+	assignments, customErr := usecase.GetRoleAssignments(ctx)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, assignments)
+}
+
+// AssignRole assign a role to a subject
+func (h *RoleHandler) AssignRole(ctx *gin.Context) {
+	params := assignRoleParams{}
+	customErr := util.ToGinContextExt(ctx).BindJson(&params)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	assignment, customErr := usecase.AssignRole(ctx, ctx.Param("subject"), params.Role)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, assignment)
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+	_roleRepo "github.com/Yu-Qi/restful_api/usecases/role/repository/in_memory"
+	_roleUsecase "github.com/Yu-Qi/restful_api/usecases/role/usecase"
+	_taskHttpDelivery "github.com/Yu-Qi/restful_api/usecases/task/delivery/http"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+func TestRoleSuite(t *testing.T) {
+	suite.Run(t, new(roleSuite))
+}
+
+type roleSuite struct {
+	suite.Suite
+	Router   *gin.Engine
+	Ctx      context.Context
+	HS256Key []byte
+}
+
+func (s *roleSuite) SetupSuite() {
+	s.HS256Key = []byte("secret")
+	verifier, err := auth.NewJWTVerifier(&auth.JWTConfig{HS256Secret: s.HS256Key})
+	s.NoError(err)
+
+	s.Router = gin.Default()
+	s.Router.ContextWithFallback = true
+	group := s.Router.Group("", middleware.Authenticate(middleware.BearerScheme(verifier)), middleware.ResolveRole(_roleUsecase.ResolveRole))
+	NewRoleHandler(group)
+	_taskHttpDelivery.NewTaskHandler(group)
+}
+
+func (s *roleSuite) SetupTest() {
+	_roleUsecase.Init(_roleUsecase.InitParam{
+		RoleRepo: _roleRepo.NewInMemoryRoleRepo(),
+	})
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: _taskRepo.NewInMemoryTaskRepo(),
+	})
+
+	s.Ctx = context.Background()
+}
+
+func (s *roleSuite) token(subject string, role auth.Role) string {
+	return s.tenantToken(subject, role, "")
+}
+
+func (s *roleSuite) tenantToken(subject string, role auth.Role, tenantID string) string {
+	claims := jwt.MapClaims{
+		"sub": subject,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	if role != "" {
+		claims["role"] = string(role)
+	}
+	if tenantID != "" {
+		claims["tenant"] = tenantID
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.HS256Key)
+	s.NoError(err)
+	return token
+}
+
+func (s *roleSuite) serve(method, url, token string, body interface{}) *httptest.ResponseRecorder {
+	return testutil.Serve(s.Router, testutil.NewJSONRequest(s.T(), method, url, body), "Authorization", testutil.Bearer(token))
+}
+
+func (s *roleSuite) TestAssignRole() {
+	admin := s.token("root", auth.RoleAdmin)
+	editor := s.token("alice", "")
+
+	w := s.serve("PUT", "/v1/roles/bob", editor, map[string]interface{}{"role": "admin"})
+	s.Equal(http.StatusForbidden, w.Code)
+	s.Equal(code.Forbidden, testutil.ResponseCode(s.T(), w))
+	s.Equal(http.StatusForbidden, s.serve("GET", "/v1/roles", editor, nil).Code)
+	s.Equal(http.StatusBadRequest, s.serve("PUT", "/v1/roles/bob", admin, map[string]interface{}{"role": "owner"}).Code)
+
+	s.Equal(http.StatusOK, s.serve("PUT", "/v1/roles/bob", admin, map[string]interface{}{"role": "viewer"}).Code)
+	w = s.serve("GET", "/v1/roles", admin, nil)
+	s.Equal(http.StatusOK, w.Code)
+	var response struct {
+		Data []struct {
+			Subject    string    `json:"subject"`
+			Role       auth.Role `json:"role"`
+			AssignedBy string    `json:"assigned_by"`
+		} `json:"data"`
+	}
+	s.Nil(json.Unmarshal(w.Body.Bytes(), &response))
+	s.Equal(1, len(response.Data))
+	s.Equal("bob", response.Data[0].Subject)
+	s.Equal(auth.RoleViewer, response.Data[0].Role)
+	s.Equal("root", response.Data[0].AssignedBy)
+
+	// the assigned role wins over the claimed one
+	s.Equal(http.StatusOK, s.serve("PUT", "/v1/roles/root", admin, map[string]interface{}{"role": "editor"}).Code)
+	s.Equal(http.StatusForbidden, s.serve("GET", "/v1/roles", admin, nil).Code)
+}
+
+func (s *roleSuite) TestRolesArePerTenant() {
+	acmeAdmin := s.tenantToken("root", auth.RoleAdmin, "acme")
+	globexAdmin := s.tenantToken("root", auth.RoleAdmin, "globex")
+	s.Equal(http.StatusOK, s.serve("PUT", "/v1/roles/bob", acmeAdmin, map[string]interface{}{"role": "admin"}).Code)
+
+	// bob is an admin of acme only
+	s.Equal(http.StatusOK, s.serve("GET", "/v1/roles", s.tenantToken("bob", "", "acme"), nil).Code)
+	s.Equal(http.StatusForbidden, s.serve("GET", "/v1/roles", s.tenantToken("bob", "", "globex"), nil).Code)
+
+	var response struct {
+		Data []struct {
+			TenantID string `json:"tenant_id"`
+			Subject  string `json:"subject"`
+		} `json:"data"`
+	}
+	s.Nil(json.Unmarshal(s.serve("GET", "/v1/roles", globexAdmin, nil).Body.Bytes(), &response))
+	s.Empty(response.Data)
+	s.Nil(json.Unmarshal(s.serve("GET", "/v1/roles", acmeAdmin, nil).Body.Bytes(), &response))
+	s.Equal(1, len(response.Data))
+	s.Equal("acme", response.Data[0].TenantID)
+	s.Equal("bob", response.Data[0].Subject)
+}
+
+func (s *roleSuite) TestViewerCannotWrite() {
+	admin := s.token("root", auth.RoleAdmin)
+	viewer := s.token("bob", "")
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks", viewer, map[string]interface{}{"name": "bob task", "status": 0}).Code)
+	s.Equal(http.StatusOK, s.serve("PUT", "/v1/roles/bob", admin, map[string]interface{}{"role": "viewer"}).Code)
+
+	s.Equal(http.StatusOK, s.serve("GET", "/v1/tasks", viewer, nil).Code)
+	w := s.serve("POST", "/v1/tasks", viewer, map[string]interface{}{"name": "another", "status": 0})
+	s.Equal(http.StatusForbidden, w.Code)
+	s.Equal(code.Forbidden, testutil.ResponseCode(s.T(), w))
+	s.Equal(http.StatusForbidden, s.serve("PUT", "/v1/tasks/1", viewer, map[string]interface{}{"name": "renamed", "status": 0}).Code)
+	s.Equal(http.StatusForbidden, s.serve("DELETE", "/v1/tasks/1", viewer, nil).Code)
+}
+
+func (s *roleSuite) TestAdminManagesEveryTask() {
+	admin := s.token("root", auth.RoleAdmin)
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks", s.token("alice", ""), map[string]interface{}{"name": "alice task", "status": 0}).Code)
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks", s.token("bob", ""), map[string]interface{}{"name": "bob task", "status": 0}).Code)
+
+	w := s.serve("GET", "/v1/tasks", admin, nil)
+	s.Equal(http.StatusOK, w.Code)
+	var response struct {
+		Data []struct {
+			ID int `json:"id"`
+		} `json:"data"`
+	}
+	s.Nil(json.Unmarshal(w.Body.Bytes(), &response))
+	s.Equal(2, len(response.Data))
+
+	s.Equal(http.StatusOK, s.serve("PUT", "/v1/tasks/1", admin, map[string]interface{}{"name": "renamed", "status": 0}).Code)
+	s.Equal(http.StatusOK, s.serve("DELETE", "/v1/tasks/2", admin, nil).Code)
+	s.Equal(http.StatusNotFound, s.serve("PUT", "/v1/tasks/1", s.token("bob", ""), map[string]interface{}{"name": "hijacked", "status": 0}).Code)
+
+	task, customErr := _taskUsecase.GetTask(s.Ctx, 1)
+	s.Nil(customErr)
+	s.Equal("renamed", task.Name)
+	s.Equal("alice", task.OwnerID)
+}
@@ -0,0 +1,73 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/domain/model"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+type inMemoryRoleRepo struct {
+	StorageMap sync.Map // key: roleKey, value: *model.RoleAssignment
+}
+
+// roleKey scopes the assignments to a tenant, a subject has a role per tenant
+type roleKey struct {
+	tenantID string
+	subject  string
+}
+
+// NewInMemoryRoleRepo will create an object that represent the role.Repository interface
+func NewInMemoryRoleRepo() domain.RoleRepository {
+	return &inMemoryRoleRepo{}
+}
+
+// GetRoleAssignment will get the role assignment of a subject in a tenant
+func (i *inMemoryRoleRepo) GetRoleAssignment(ctx context.Context, tenantID, subject string) (*domain.RoleAssignment, *code.CustomError) {
+	value, ok := i.StorageMap.Load(roleKey{tenantID: tenantID, subject: subject})
+	if !ok {
+		return nil, code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("no role is assigned to %s", subject))
+	}
+	return toDomainRoleAssignment(value.(*model.RoleAssignment)), nil
+}
+
+// GetRoleAssignmentsByTenant will get the role assignments of a tenant, ordered by subject
+func (i *inMemoryRoleRepo) GetRoleAssignmentsByTenant(ctx context.Context, tenantID string) ([]*domain.RoleAssignment, *code.CustomError) {
+	assignments := []*domain.RoleAssignment{}
+	i.StorageMap.Range(func(_, value interface{}) bool {
+		modelAssignment := value.(*model.RoleAssignment)
+		if modelAssignment.TenantId == tenantID {
+			assignments = append(assignments, toDomainRoleAssignment(modelAssignment))
+		}
+		return true
+	})
+	sort.Slice(assignments, func(a, b int) bool { return assignments[a].Subject < assignments[b].Subject })
+	return assignments, nil
+}
+
+// AssignRole will assign a role to a subject in the tenant of the assignment
+func (i *inMemoryRoleRepo) AssignRole(ctx context.Context, assignment *domain.RoleAssignment) *code.CustomError {
+	i.StorageMap.Store(roleKey{tenantID: assignment.TenantID, subject: assignment.Subject}, &model.RoleAssignment{
+		TenantId:   assignment.TenantID,
+		Subject:    assignment.Subject,
+		Role:       assignment.Role,
+		AssignedBy: assignment.AssignedBy,
+		AssignedAt: assignment.AssignedAt,
+	})
+	return nil
+}
+
+func toDomainRoleAssignment(modelAssignment *model.RoleAssignment) *domain.RoleAssignment {
+	return &domain.RoleAssignment{
+		TenantID:   modelAssignment.TenantId,
+		Subject:    modelAssignment.Subject,
+		Role:       modelAssignment.Role,
+		AssignedBy: modelAssignment.AssignedBy,
+		AssignedAt: modelAssignment.AssignedAt,
+	}
+}
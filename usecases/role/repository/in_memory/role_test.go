@@ -0,0 +1,63 @@
+package inmemory
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/stretchr/testify/suite"
+)
+
+type roleSuite struct {
+	suite.Suite
+	roleRepo domain.RoleRepository
+}
+
+func TestRoleSuite(t *testing.T) {
+	suite.Run(t, new(roleSuite))
+}
+
+func (s *roleSuite) SetupTest() {
+	s.roleRepo = NewInMemoryRoleRepo()
+}
+
+func (s *roleSuite) TestAssignRole() {
+	ctx := context.Background()
+	_, customErr := s.roleRepo.GetRoleAssignment(ctx, "acme", "alice")
+	s.NotNil(customErr)
+	s.Equal(http.StatusNotFound, customErr.HttpStatus)
+
+	s.Nil(s.roleRepo.AssignRole(ctx, &domain.RoleAssignment{TenantID: "acme", Subject: "bob", Role: auth.RoleViewer}))
+	s.Nil(s.roleRepo.AssignRole(ctx, &domain.RoleAssignment{TenantID: "acme", Subject: "alice", Role: auth.RoleViewer}))
+	s.Nil(s.roleRepo.AssignRole(ctx, &domain.RoleAssignment{TenantID: "acme", Subject: "alice", Role: auth.RoleAdmin, AssignedBy: "root"}))
+
+	assignment, customErr := s.roleRepo.GetRoleAssignment(ctx, "acme", "alice")
+	s.Nil(customErr)
+	s.Equal(auth.RoleAdmin, assignment.Role)
+	s.Equal("root", assignment.AssignedBy)
+
+	assignments, customErr := s.roleRepo.GetRoleAssignmentsByTenant(ctx, "acme")
+	s.Nil(customErr)
+	s.Equal(2, len(assignments))
+	s.Equal("alice", assignments[0].Subject)
+	s.Equal("bob", assignments[1].Subject)
+}
+
+func (s *roleSuite) TestTenantsAreApart() {
+	ctx := context.Background()
+	s.Nil(s.roleRepo.AssignRole(ctx, &domain.RoleAssignment{TenantID: "acme", Subject: "alice", Role: auth.RoleAdmin}))
+	s.Nil(s.roleRepo.AssignRole(ctx, &domain.RoleAssignment{TenantID: "globex", Subject: "alice", Role: auth.RoleViewer}))
+
+	assignment, customErr := s.roleRepo.GetRoleAssignment(ctx, "globex", "alice")
+	s.Nil(customErr)
+	s.Equal(auth.RoleViewer, assignment.Role)
+	_, customErr = s.roleRepo.GetRoleAssignment(ctx, "initech", "alice")
+	s.Equal(http.StatusNotFound, customErr.HttpStatus)
+
+	assignments, customErr := s.roleRepo.GetRoleAssignmentsByTenant(ctx, "globex")
+	s.Nil(customErr)
+	s.Equal(1, len(assignments))
+	s.Equal(auth.RoleViewer, assignments[0].Role)
+}
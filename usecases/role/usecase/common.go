@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"github.com/Yu-Qi/restful_api/domain"
+)
+
+var (
+	roleRepo domain.RoleRepository
+)
+
+// InitParam defines the parameters for initializing the service.
+type InitParam struct {
+	RoleRepo domain.RoleRepository
+}
+
+// Init injects implementations into the service.
+func Init(param InitParam) {
+	roleRepo = param.RoleRepo
+}
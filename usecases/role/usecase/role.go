@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// ResolveRole get the role of a principal in its tenant, an assigned role wins over the role claimed by the token
+func ResolveRole(ctx context.Context, principal *auth.Principal) (auth.Role, *code.CustomError) {
+	assignment, customErr := roleRepo.GetRoleAssignment(ctx, principal.BoundTenantID(), principal.Subject)
+	if customErr == nil {
+		return assignment.Role, nil
+	}
+	if customErr.Code != code.NotFound {
+		return "", customErr
+	}
+
+	if principal.Role != "" {
+		return principal.Role, nil
+	}
+	return auth.DefaultRole, nil
+}
+
+// GetRoleAssignments get the role assignments of the tenant of the caller
+func GetRoleAssignments(ctx context.Context) ([]*domain.RoleAssignment, *code.CustomError) {
+	admin, customErr := requireAdmin(ctx)
+	if customErr != nil {
+		return nil, customErr
+	}
+	return roleRepo.GetRoleAssignmentsByTenant(ctx, admin.BoundTenantID())
+}
+
+// AssignRole assign a role to a subject in the tenant of the caller
+func AssignRole(ctx context.Context, subject string, role auth.Role) (*domain.RoleAssignment, *code.CustomError) {
+	admin, customErr := requireAdmin(ctx)
+	if customErr != nil {
+		return nil, customErr
+	}
+	if !role.IsValid() {
+		return nil, code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, fmt.Errorf("unknown role %s", role))
+	}
+
+	assignment := &domain.RoleAssignment{
+		TenantID:   admin.BoundTenantID(),
+		Subject:    subject,
+		Role:       role,
+		AssignedBy: admin.Subject,
+		AssignedAt: time.Now(),
+	}
+	if customErr = roleRepo.AssignRole(ctx, assignment); customErr != nil {
+		return nil, customErr
+	}
+	return assignment, nil
+}
+
+// requireAdmin returns the caller if it is an admin, the callers using an api key cannot manage roles
+func requireAdmin(ctx context.Context) (*auth.Principal, *code.CustomError) {
+	principal := auth.GetPrincipal(ctx)
+	if principal == nil {
+		return nil, code.NewCustomError(code.Unauthorized, http.StatusUnauthorized, fmt.Errorf("authentication is required to manage roles"))
+	}
+	if principal.APIKeyID != 0 || principal.Role != auth.RoleAdmin {
+		return nil, code.NewCustomError(code.Forbidden, http.StatusForbidden, fmt.Errorf("admin role is required to manage roles"))
+	}
+	return principal, nil
+}
@@ -0,0 +1,214 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graphql
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// An RRULE-style schedule of a recurring task
+type Recurrence struct {
+	Frequency RecurrenceFrequency `json:"frequency"`
+	// repeats every interval frequencies, 0 when it is omitted which repeats every frequency
+	Interval int `json:"interval"`
+	// limits the occurrences to the weekdays, e.g. MO, TU
+	ByWeekday []string   `json:"byWeekday"`
+	StartAt   time.Time  `json:"startAt"`
+	Until     *time.Time `json:"until,omitempty"`
+	// bounds the series, 0 when it is unbounded
+	Count int `json:"count"`
+}
+
+type RecurrenceInput struct {
+	Frequency RecurrenceFrequency `json:"frequency"`
+	Interval  *int                `json:"interval,omitempty"`
+	ByWeekday []string            `json:"byWeekday,omitempty"`
+	StartAt   time.Time           `json:"startAt"`
+	Until     *time.Time          `json:"until,omitempty"`
+	Count     *int                `json:"count,omitempty"`
+}
+
+type Task struct {
+	ID         int         `json:"id"`
+	Name       string      `json:"name"`
+	Status     TaskStatus  `json:"status"`
+	DueAt      *time.Time  `json:"dueAt,omitempty"`
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+	// increases on every update
+	Version int `json:"version"`
+	// the subject of the principal who created the task
+	OwnerID string `json:"ownerId"`
+}
+
+// A change of a task
+type TaskEvent struct {
+	// the id to subscribe again from
+	ID     string     `json:"id"`
+	Action TaskAction `json:"action"`
+	// the task after the change, or before it when the task is purged
+	Task       *Task     `json:"task"`
+	Actor      string    `json:"actor"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// Selects the tasks, an omitted field matches every task
+type TaskFilter struct {
+	Statuses []TaskStatus `json:"statuses,omitempty"`
+	// keeps the tasks blocked or not by an incomplete task, the ones not blocked are incomplete
+	Blocked *bool `json:"blocked,omitempty"`
+	// keeps the tasks whose name contains it, ignoring the case
+	NameContains *string    `json:"nameContains,omitempty"`
+	DueBefore    *time.Time `json:"dueBefore,omitempty"`
+	DueAfter     *time.Time `json:"dueAfter,omitempty"`
+}
+
+type TaskInput struct {
+	Name       string           `json:"name"`
+	Status     TaskStatus       `json:"status"`
+	DueAt      *time.Time       `json:"dueAt,omitempty"`
+	Recurrence *RecurrenceInput `json:"recurrence,omitempty"`
+}
+
+// A page of the tasks ordered by id
+type TaskPage struct {
+	Items    []*Task `json:"items"`
+	Total    int     `json:"total"`
+	Page     int     `json:"page"`
+	PageSize int     `json:"pageSize"`
+}
+
+type RecurrenceFrequency string
+
+const (
+	RecurrenceFrequencyDaily   RecurrenceFrequency = "DAILY"
+	RecurrenceFrequencyWeekly  RecurrenceFrequency = "WEEKLY"
+	RecurrenceFrequencyMonthly RecurrenceFrequency = "MONTHLY"
+)
+
+var AllRecurrenceFrequency = []RecurrenceFrequency{
+	RecurrenceFrequencyDaily,
+	RecurrenceFrequencyWeekly,
+	RecurrenceFrequencyMonthly,
+}
+
+func (e RecurrenceFrequency) IsValid() bool {
+	switch e {
+	case RecurrenceFrequencyDaily, RecurrenceFrequencyWeekly, RecurrenceFrequencyMonthly:
+		return true
+	}
+	return false
+}
+
+func (e RecurrenceFrequency) String() string {
+	return string(e)
+}
+
+func (e *RecurrenceFrequency) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RecurrenceFrequency(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RecurrenceFrequency", str)
+	}
+	return nil
+}
+
+func (e RecurrenceFrequency) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TaskAction string
+
+const (
+	TaskActionCreate  TaskAction = "CREATE"
+	TaskActionUpdate  TaskAction = "UPDATE"
+	TaskActionDelete  TaskAction = "DELETE"
+	TaskActionRestore TaskAction = "RESTORE"
+	TaskActionPurge   TaskAction = "PURGE"
+	TaskActionRevert  TaskAction = "REVERT"
+)
+
+var AllTaskAction = []TaskAction{
+	TaskActionCreate,
+	TaskActionUpdate,
+	TaskActionDelete,
+	TaskActionRestore,
+	TaskActionPurge,
+	TaskActionRevert,
+}
+
+func (e TaskAction) IsValid() bool {
+	switch e {
+	case TaskActionCreate, TaskActionUpdate, TaskActionDelete, TaskActionRestore, TaskActionPurge, TaskActionRevert:
+		return true
+	}
+	return false
+}
+
+func (e TaskAction) String() string {
+	return string(e)
+}
+
+func (e *TaskAction) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TaskAction(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TaskAction", str)
+	}
+	return nil
+}
+
+func (e TaskAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TaskStatus string
+
+const (
+	TaskStatusIncomplete TaskStatus = "INCOMPLETE"
+	TaskStatusCompleted  TaskStatus = "COMPLETED"
+)
+
+var AllTaskStatus = []TaskStatus{
+	TaskStatusIncomplete,
+	TaskStatusCompleted,
+}
+
+func (e TaskStatus) IsValid() bool {
+	switch e {
+	case TaskStatusIncomplete, TaskStatusCompleted:
+		return true
+	}
+	return false
+}
+
+func (e TaskStatus) String() string {
+	return string(e)
+}
+
+func (e *TaskStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TaskStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TaskStatus", str)
+	}
+	return nil
+}
+
+func (e TaskStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
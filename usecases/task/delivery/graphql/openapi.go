@@ -0,0 +1,37 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/media"
+	"github.com/Yu-Qi/restful_api/pkg/api/openapi"
+)
+
+// graphqlRequest is the body of POST /graphql
+type graphqlRequest struct {
+	Query         string                  `json:"query" binding:"required"`
+	OperationName *string                 `json:"operationName"`
+	Variables     *map[string]interface{} `json:"variables"`
+	Extensions    *map[string]interface{} `json:"extensions"`
+}
+
+// graphqlResponse is the body of the responses to POST /graphql, the data follows the GraphQL schema
+type graphqlResponse struct {
+	Data       json.RawMessage        `json:"data"`
+	Errors     gqlerror.List          `json:"errors,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// OpenAPIRoutes documents the routes of NewTaskHandler, the operations are described by schema.graphqls
+func OpenAPIRoutes() []*openapi.Route {
+	return []*openapi.Route{
+		{Method: http.MethodPost, Path: "/graphql", Summary: "Execute a GraphQL query or mutation",
+			Body: graphqlRequest{}, BodyTypes: []string{media.MIMEJSON}, Raw: map[string]interface{}{media.MIMEJSON: graphqlResponse{}},
+			Failures: map[int]interface{}{http.StatusUnprocessableEntity: graphqlResponse{}}},
+		{Method: http.MethodGet, Path: "/graphql", Summary: "Upgrade to a WebSocket taking GraphQL subscriptions",
+			Status: http.StatusSwitchingProtocols},
+	}
+}
@@ -0,0 +1,5 @@
+//go:generate gqlgen generate
+package graphql
+
+// Resolver resolves the operations on the tasks with the task usecases
+type Resolver struct{}
@@ -0,0 +1,171 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.40
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/samber/lo"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/graphqlapi"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// CreateTask is the resolver for the createTask field.
+func (r *mutationResolver) CreateTask(ctx context.Context, input TaskInput) (*Task, error) {
+	task, customErr := toTask(&input)
+	if customErr != nil {
+		return nil, graphqlapi.Error(customErr)
+	}
+
+	customErr = usecase.CreateTask(ctx, task)
+	if customErr != nil {
+		return nil, graphqlapi.Error(customErr)
+	}
+	return fromTask(task), nil
+}
+
+// UpdateTask is the resolver for the updateTask field.
+func (r *mutationResolver) UpdateTask(ctx context.Context, id int, input TaskInput) (*Task, error) {
+	task, customErr := toTask(&input)
+	if customErr != nil {
+		return nil, graphqlapi.Error(customErr)
+	}
+	task.ID = id
+
+	replaced, customErr := usecase.ReplaceTask(ctx, task)
+	if customErr != nil {
+		return nil, graphqlapi.Error(customErr)
+	}
+	return fromTask(replaced), nil
+}
+
+// DeleteTask is the resolver for the deleteTask field.
+func (r *mutationResolver) DeleteTask(ctx context.Context, id int) (int, error) {
+	customErr := usecase.DeleteTask(ctx, id)
+	if customErr != nil {
+		return 0, graphqlapi.Error(customErr)
+	}
+	return id, nil
+}
+
+// Task is the resolver for the task field.
+func (r *queryResolver) Task(ctx context.Context, id int) (*Task, error) {
+	task, customErr := usecase.GetTask(ctx, id)
+	if customErr != nil {
+		return nil, graphqlapi.Error(customErr)
+	}
+	return fromTask(task), nil
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *queryResolver) Tasks(ctx context.Context, filter *TaskFilter, page int, pageSize int) (*TaskPage, error) {
+	if page < 1 || pageSize < 1 || pageSize > maxPageSize {
+		return nil, graphqlapi.Error(code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest,
+			fmt.Errorf("page must be at least 1 and pageSize between 1 and %d", maxPageSize)))
+	}
+
+	var tasks []*domain.Task
+	var customErr *code.CustomError
+	if filter != nil && filter.Blocked != nil {
+		tasks, customErr = usecase.GetTasksByBlocked(ctx, *filter.Blocked)
+	} else {
+		tasks, customErr = usecase.GetTasks(ctx)
+	}
+	if customErr != nil {
+		return nil, graphqlapi.Error(customErr)
+	}
+
+	tasks = lo.Filter(tasks, func(task *domain.Task, _ int) bool {
+		return filter.match(task)
+	})
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+	pagination := domain.Pagination{Page: page, PageSize: pageSize}
+	start, end := pagination.Bounds(len(tasks))
+	return &TaskPage{
+		Items:    lo.Map(tasks[start:end], func(task *domain.Task, _ int) *Task { return fromTask(task) }),
+		Total:    len(tasks),
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// TaskEvents is the resolver for the taskEvents field.
+func (r *subscriptionResolver) TaskEvents(ctx context.Context, statuses []TaskStatus, taskIds []int, lastEventID *string) (<-chan *TaskEvent, error) {
+	filter := &usecase.TaskEventFilter{TaskIDs: taskIds}
+	for _, status := range statuses {
+		taskStatus, customErr := toTaskStatus(status)
+		if customErr != nil {
+			return nil, graphqlapi.Error(customErr)
+		}
+		filter.Statuses = append(filter.Statuses, taskStatus)
+	}
+	var lastID uint64
+	if lastEventID != nil {
+		var err error
+		if lastID, err = strconv.ParseUint(*lastEventID, 10, 64); err != nil {
+			return nil, graphqlapi.Error(code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest,
+				fmt.Errorf("invalid lastEventId %q", *lastEventID)))
+		}
+	}
+
+	subscription, customErr := usecase.SubscribeTaskEvents(ctx, lastID, filter)
+	if customErr != nil {
+		return nil, graphqlapi.Error(customErr)
+	}
+
+	events := make(chan *TaskEvent)
+	go func() {
+		defer close(events)
+		defer subscription.Cancel()
+
+		send := func(event usecase.TaskEvent) bool {
+			select {
+			case events <- fromTaskEvent(event):
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for _, event := range subscription.Replay {
+			if !send(event) {
+				return
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-subscription.Events:
+				// a closed subscription fell behind, completing tells the client to subscribe again
+				if !ok || !send(event) {
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
@@ -0,0 +1,142 @@
+package graphql
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// maxPageSize bounds the pages of the tasks like the ones of the http api
+const maxPageSize = 100
+
+// TaskHandler represent the GraphQL endpoint for tasks
+type TaskHandler struct {
+	srv *handler.Server
+}
+
+// NewTaskHandler will serve the operations of srv on /graphql, the subscriptions upgrade a GET to a WebSocket
+func NewTaskHandler(r *gin.RouterGroup, srv *handler.Server) {
+	taskHandler := &TaskHandler{srv: srv}
+	r.POST("/graphql", taskHandler.ExecuteGraphQL)
+	r.GET("/graphql", taskHandler.SubscribeGraphQL)
+}
+
+// ExecuteGraphQL execute a query or a mutation
+func (t *TaskHandler) ExecuteGraphQL(ctx *gin.Context) {
+	t.srv.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// SubscribeGraphQL take the subscriptions of a WebSocket
+func (t *TaskHandler) SubscribeGraphQL(ctx *gin.Context) {
+	t.srv.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// Schema is the executable schema of the tasks, a page of tasks costs its fields once per task
+func Schema() graphql.ExecutableSchema {
+	config := Config{Resolvers: &Resolver{}}
+	config.Complexity.Query.Tasks = func(childComplexity int, _ *TaskFilter, _ int, pageSize int) int {
+		return 1 + childComplexity*pageSize
+	}
+	return NewExecutableSchema(config)
+}
+
+// match reports whether the filter selects a task, except for Blocked which is left to the usecase
+func (f *TaskFilter) match(task *domain.Task) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Statuses) > 0 && !lo.Contains(f.Statuses, fromTaskStatus(task.Status)) {
+		return false
+	}
+	if f.NameContains != nil && !strings.Contains(strings.ToLower(task.Name), strings.ToLower(*f.NameContains)) {
+		return false
+	}
+	if f.DueBefore != nil && (task.DueAt == nil || !task.DueAt.Before(*f.DueBefore)) {
+		return false
+	}
+	if f.DueAfter != nil && (task.DueAt == nil || !task.DueAt.After(*f.DueAfter)) {
+		return false
+	}
+	return true
+}
+
+func fromTaskStatus(status domain.TaskStatus) TaskStatus {
+	return TaskStatus(strings.ToUpper(status.String()))
+}
+
+func toTaskStatus(status TaskStatus) (domain.TaskStatus, *code.CustomError) {
+	taskStatus, err := domain.ParseTaskStatus(strings.ToLower(string(status)))
+	if err != nil {
+		return taskStatus, code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+	}
+	return taskStatus, nil
+}
+
+func fromTask(task *domain.Task) *Task {
+	t := &Task{
+		ID:      task.ID,
+		Name:    task.Name,
+		Status:  fromTaskStatus(task.Status),
+		DueAt:   task.DueAt,
+		Version: task.Version,
+		OwnerID: task.OwnerID,
+	}
+	if recurrence := task.Recurrence; recurrence != nil {
+		t.Recurrence = &Recurrence{
+			Frequency: RecurrenceFrequency(strings.ToUpper(string(recurrence.Frequency))),
+			Interval:  recurrence.Interval,
+			ByWeekday: append([]string{}, recurrence.ByWeekday...),
+			StartAt:   recurrence.StartAt,
+			Until:     recurrence.Until,
+			Count:     recurrence.Count,
+		}
+	}
+	return t
+}
+
+func fromTaskEvent(event usecase.TaskEvent) *TaskEvent {
+	return &TaskEvent{
+		ID:         strconv.FormatUint(event.ID, 10),
+		Action:     TaskAction(strings.ToUpper(string(event.Data.Action))),
+		Task:       fromTask(event.Data.Task),
+		Actor:      event.Data.Actor,
+		OccurredAt: event.Data.OccurredAt,
+	}
+}
+
+// toTask converts a task input and checks it like the json body of POST /v1/tasks
+func toTask(input *TaskInput) (*domain.Task, *code.CustomError) {
+	status, customErr := toTaskStatus(input.Status)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	task := &domain.Task{
+		Name:   input.Name,
+		Status: status,
+		DueAt:  input.DueAt,
+	}
+	if recurrence := input.Recurrence; recurrence != nil {
+		task.Recurrence = &domain.Recurrence{
+			Frequency: domain.RecurrenceFrequency(strings.ToLower(string(recurrence.Frequency))),
+			Interval:  lo.FromPtr(recurrence.Interval),
+			ByWeekday: recurrence.ByWeekday,
+			StartAt:   recurrence.StartAt,
+			Until:     recurrence.Until,
+			Count:     lo.FromPtr(recurrence.Count),
+		}
+	}
+	if customErr := usecase.ValidateTask(task); customErr != nil {
+		return nil, customErr
+	}
+	return task, nil
+}
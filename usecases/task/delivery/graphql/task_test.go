@@ -0,0 +1,409 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/graphqlapi"
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/eventbus"
+	"github.com/Yu-Qi/restful_api/pkg/ratelimit"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// POST /graphql and GET /graphql
+func TestGraphQLSuite(t *testing.T) {
+	suite.Run(t, new(graphqlSuite))
+}
+
+type graphqlSuite struct {
+	suite.Suite
+	Server *httptest.Server
+}
+
+// gqlResponse is a response of the server, the data is decoded by the tests
+type gqlResponse struct {
+	Status int             `json:"-"`
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string        `json:"message"`
+		Path       []interface{} `json:"path"`
+		Extensions struct {
+			Code   int    `json:"code"`
+			Reason string `json:"reason"`
+		} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// the limits of the test server, a task with its recurrence is as deep as the limit
+const (
+	testMaxDepth      = 3
+	testMaxComplexity = 500
+)
+
+func (s *graphqlSuite) SetupSuite() {
+	// the token is the subject, readers only get the read scope
+	scheme := middleware.AuthScheme{
+		Name: "Test",
+		Verify: func(ctx context.Context, token string) (*auth.Principal, *code.CustomError) {
+			principal := &auth.Principal{Subject: token, Role: auth.RoleEditor}
+			if strings.HasPrefix(token, "reader") {
+				principal.Subject = strings.TrimPrefix(token, "reader-")
+				principal.Scopes = []string{auth.ScopeTasksRead}
+			}
+			return principal, nil
+		},
+	}
+
+	srv := graphqlapi.NewServer(Schema(), testMaxDepth, testMaxComplexity)
+	srv.Use(graphqlapi.RequireScope{ReadScope: auth.ScopeTasksRead, WriteScope: auth.ScopeTasksWrite})
+	router := gin.Default()
+	router.ContextWithFallback = true
+	NewTaskHandler(router.Group("", middleware.Authenticate(scheme)), srv)
+	s.Server = httptest.NewServer(router)
+}
+
+func (s *graphqlSuite) TearDownSuite() {
+	s.Server.Close()
+}
+
+func (s *graphqlSuite) SetupTest() {
+	taskEvents := eventbus.New[*domain.TaskEvent](100, 10)
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo:   _taskRepo.NewInMemoryTaskRepo(_taskRepo.WithEventPublisher(taskEvents.Publish)),
+		TaskEvents: taskEvents,
+	})
+}
+
+func (s *graphqlSuite) post(token, query string, variables map[string]interface{}) *gqlResponse {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	s.Require().NoError(err)
+	req, err := http.NewRequest(http.MethodPost, s.Server.URL+"/graphql", bytes.NewReader(body))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Test "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	result := &gqlResponse{Status: resp.StatusCode}
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(result))
+	return result
+}
+
+// data checks the response has no error and decodes its data into v
+func (s *graphqlSuite) data(resp *gqlResponse, v interface{}) {
+	s.Require().Equal(http.StatusOK, resp.Status)
+	s.Require().Empty(resp.Errors)
+	s.Require().NoError(json.Unmarshal(resp.Data, v))
+}
+
+// requireCode checks the response has a single error with the code
+func (s *graphqlSuite) requireCode(resp *gqlResponse, customCode int) {
+	s.Require().Len(resp.Errors, 1, string(resp.Data))
+	s.Equal(customCode, resp.Errors[0].Extensions.Code, resp.Errors[0].Message)
+	s.Equal(code.Name(customCode), resp.Errors[0].Extensions.Reason)
+}
+
+const createTask = `mutation ($input: TaskInput!) { createTask(input: $input) { id name status dueAt version } }`
+
+func (s *graphqlSuite) create(token, name, status string) int {
+	var data struct {
+		CreateTask Task `json:"createTask"`
+	}
+	s.data(s.post(token, createTask, map[string]interface{}{
+		"input": map[string]interface{}{"name": name, "status": status},
+	}), &data)
+	return data.CreateTask.ID
+}
+
+func (s *graphqlSuite) TestCRUD() {
+	var created struct {
+		CreateTask Task `json:"createTask"`
+	}
+	s.data(s.post("user", createTask, map[string]interface{}{
+		"input": map[string]interface{}{"name": "task", "status": "INCOMPLETE", "dueAt": "2030-01-02T03:04:05Z"},
+	}), &created)
+	s.NotZero(created.CreateTask.ID)
+	s.Equal("task", created.CreateTask.Name)
+	s.Equal(TaskStatusIncomplete, created.CreateTask.Status)
+	s.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), created.CreateTask.DueAt.UTC())
+	id := created.CreateTask.ID
+
+	// only the selected fields are returned
+	resp := s.post("user", `query ($id: Int!) { task(id: $id) { name ownerId } }`, map[string]interface{}{"id": id})
+	s.Require().Empty(resp.Errors)
+	s.JSONEq(`{"task": {"name": "task", "ownerId": "user"}}`, string(resp.Data))
+
+	var updated struct {
+		UpdateTask Task `json:"updateTask"`
+	}
+	s.data(s.post("user", `mutation ($id: Int!, $input: TaskInput!) {
+		updateTask(id: $id, input: $input) { id status dueAt recurrence { frequency interval byWeekday startAt } }
+	}`, map[string]interface{}{"id": id, "input": map[string]interface{}{
+		"name": "task", "status": "COMPLETED",
+		"recurrence": map[string]interface{}{"frequency": "WEEKLY", "byWeekday": []string{"MO"}, "startAt": "2030-01-06T00:00:00Z"},
+	}}), &updated)
+	s.Equal(TaskStatusCompleted, updated.UpdateTask.Status)
+	// the omitted due date becomes the first occurrence
+	s.Equal(time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC), updated.UpdateTask.DueAt.UTC())
+	s.Require().NotNil(updated.UpdateTask.Recurrence)
+	s.Equal(RecurrenceFrequencyWeekly, updated.UpdateTask.Recurrence.Frequency)
+	// the omitted interval is not filled in, like the json of the task
+	s.Zero(updated.UpdateTask.Recurrence.Interval)
+	s.Equal([]string{"MO"}, updated.UpdateTask.Recurrence.ByWeekday)
+
+	var deleted struct {
+		DeleteTask int `json:"deleteTask"`
+	}
+	s.data(s.post("user", `mutation ($id: Int!) { deleteTask(id: $id) }`, map[string]interface{}{"id": id}), &deleted)
+	s.Equal(id, deleted.DeleteTask)
+
+	resp = s.post("user", `query ($id: Int!) { task(id: $id) { id } }`, map[string]interface{}{"id": id})
+	s.Equal(http.StatusOK, resp.Status)
+	s.requireCode(resp, code.NotFound)
+	s.Equal([]interface{}{"task"}, resp.Errors[0].Path)
+	s.JSONEq(`{"task": null}`, string(resp.Data))
+}
+
+func (s *graphqlSuite) TestTasks() {
+	for i := 1; i <= 5; i++ {
+		status := "INCOMPLETE"
+		if i%2 == 0 {
+			status = "COMPLETED"
+		}
+		s.create("user", fmt.Sprintf("Task %d", i), status)
+	}
+	s.create("other", "Task of other", "INCOMPLETE")
+	blocked := s.create("user", "blocked", "INCOMPLETE")
+	s.Require().Nil(_taskUsecase.AddDependency(context.Background(), blocked, 1))
+
+	query := `query ($filter: TaskFilter, $page: Int!, $pageSize: Int!) {
+		tasks(filter: $filter, page: $page, pageSize: $pageSize) { items { id name } total page pageSize }
+	}`
+	tasks := func(filter map[string]interface{}, page, pageSize int) *TaskPage {
+		var data struct {
+			Tasks TaskPage `json:"tasks"`
+		}
+		s.data(s.post("user", query, map[string]interface{}{"filter": filter, "page": page, "pageSize": pageSize}), &data)
+		return &data.Tasks
+	}
+	names := func(page *TaskPage) []string {
+		var result []string
+		for _, task := range page.Items {
+			result = append(result, task.Name)
+		}
+		return result
+	}
+
+	// the tasks of the caller ordered by id
+	page := tasks(nil, 2, 2)
+	s.Equal(6, page.Total)
+	s.Equal(2, page.Page)
+	s.Equal(2, page.PageSize)
+	s.Equal([]string{"Task 3", "Task 4"}, names(page))
+	s.Empty(tasks(nil, 4, 2).Items)
+
+	s.Equal([]string{"Task 2", "Task 4"}, names(tasks(map[string]interface{}{"statuses": []string{"COMPLETED"}}, 1, 20)))
+	s.Equal([]string{"Task 1", "Task 3", "Task 5", "blocked"},
+		names(tasks(map[string]interface{}{"statuses": []string{"INCOMPLETE"}}, 1, 20)))
+	s.Equal([]string{"blocked"}, names(tasks(map[string]interface{}{"blocked": true}, 1, 20)))
+	s.Equal([]string{"Task 1", "Task 3", "Task 5"}, names(tasks(map[string]interface{}{"blocked": false}, 1, 20)))
+	s.Equal([]string{"Task 2"}, names(tasks(map[string]interface{}{"nameContains": "task 2"}, 1, 20)))
+	s.Empty(tasks(map[string]interface{}{"dueBefore": "2030-01-01T00:00:00Z"}, 1, 20).Items)
+
+	resp := s.post("user", query, map[string]interface{}{"page": 1, "pageSize": 101})
+	s.requireCode(resp, code.ParamIncorrect)
+}
+
+func (s *graphqlSuite) TestValidation() {
+	for _, input := range []map[string]interface{}{
+		{"name": "", "status": "INCOMPLETE"},
+		{"name": "task", "status": "INCOMPLETE", "recurrence": map[string]interface{}{"frequency": "DAILY", "startAt": "2030-01-01T00:00:00Z", "count": 0, "interval": -1}},
+		{"name": "task", "status": "INCOMPLETE", "recurrence": map[string]interface{}{"frequency": "DAILY", "startAt": "2030-01-01T00:00:00Z", "byWeekday": []string{"XX"}}},
+	} {
+		resp := s.post("user", createTask, map[string]interface{}{"input": input})
+		s.Equal(http.StatusOK, resp.Status)
+		s.requireCode(resp, code.ParamIncorrect)
+		s.JSONEq(`null`, string(resp.Data))
+	}
+
+	// the operations rejected before they run are answered with 422
+	for _, query := range []string{
+		`{ tasks { items { id } `,
+		`{ tasks { items { unknown } } }`,
+		`mutation { createTask(input: {name: "task", status: UNKNOWN}) { id } }`,
+	} {
+		resp := s.post("user", query, nil)
+		s.Equal(http.StatusUnprocessableEntity, resp.Status, query)
+		s.Require().NotEmpty(resp.Errors)
+		s.Equal(code.ParamIncorrect, resp.Errors[0].Extensions.Code, query)
+	}
+}
+
+func (s *graphqlSuite) TestLimits() {
+	resp := s.post("user", `{ tasks { items { recurrence { frequency } } } }`, nil)
+	s.Equal(http.StatusUnprocessableEntity, resp.Status)
+	s.requireCode(resp, code.ParamIncorrect)
+	s.Contains(resp.Errors[0].Message, "depth 4")
+
+	// the fragments don't add a level
+	resp = s.post("user", `{ tasks { ...page } } fragment page on TaskPage { items { ... on Task { id } } }`, nil)
+	s.Require().Empty(resp.Errors)
+
+	// a page costs its fields once per task
+	resp = s.post("user", `{ tasks(pageSize: 100) { items { id name status dueAt version ownerId } } }`, nil)
+	s.Equal(http.StatusUnprocessableEntity, resp.Status)
+	s.requireCode(resp, code.ParamIncorrect)
+	s.Contains(resp.Errors[0].Message, "complexity 701")
+	resp = s.post("user", `{ tasks(pageSize: 10) { items { id name status dueAt version ownerId } } }`, nil)
+	s.Require().Empty(resp.Errors)
+
+	// the introspection is as deep as it needs
+	resp = s.post("user", `{ __schema { queryType { fields { name type { kind ofType { kind ofType { name } } } } } } }`, nil)
+	s.Require().Empty(resp.Errors)
+	s.Contains(string(resp.Data), `"tasks"`)
+}
+
+func (s *graphqlSuite) TestCreateLimit() {
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo:      _taskRepo.NewInMemoryTaskRepo(),
+		CreateLimiter: ratelimit.NewInMemoryStore(),
+		CreateLimit:   ratelimit.Limit{Requests: 2, Period: time.Hour},
+	})
+
+	// the aliased mutations of one operation count one by one
+	resp := s.post("user", `mutation {
+		a: createTask(input: {name: "a", status: INCOMPLETE}) { id }
+		b: createTask(input: {name: "b", status: INCOMPLETE}) { id }
+		c: createTask(input: {name: "c", status: INCOMPLETE}) { id }
+	}`, nil)
+	s.requireCode(resp, code.TooManyRequests)
+	s.Equal([]interface{}{"c"}, resp.Errors[0].Path)
+
+	// the limit is per client
+	s.create("other", "task", "INCOMPLETE")
+}
+
+func (s *graphqlSuite) TestScope() {
+	id := s.create("user", "task", "INCOMPLETE")
+
+	// readers can query but not mutate
+	resp := s.post("reader-user", `query ($id: Int!) { task(id: $id) { id } }`, map[string]interface{}{"id": id})
+	s.Require().Empty(resp.Errors)
+	resp = s.post("reader-user", `mutation ($id: Int!) { deleteTask(id: $id) }`, map[string]interface{}{"id": id})
+	s.requireCode(resp, code.Forbidden)
+
+	// the tasks of the others look missing
+	resp = s.post("other", `query ($id: Int!) { task(id: $id) { id } }`, map[string]interface{}{"id": id})
+	s.requireCode(resp, code.NotFound)
+}
+
+// wsMessage is a message of the graphql-transport-ws protocol
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+func (s *graphqlSuite) dial(token string) *websocket.Conn {
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-transport-ws"}}
+	header := http.Header{"Authorization": {"Test " + token}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(s.Server.URL, "http")+"/graphql", header)
+	s.Require().NoError(err)
+
+	s.Require().NoError(conn.WriteJSON(&wsMessage{Type: "connection_init"}))
+	s.Equal("connection_ack", s.read(conn).Type)
+	return conn
+}
+
+func (s *graphqlSuite) subscribe(conn *websocket.Conn, id, query string) {
+	payload, err := json.Marshal(map[string]interface{}{"query": query})
+	s.Require().NoError(err)
+	s.Require().NoError(conn.WriteJSON(&wsMessage{ID: id, Type: "subscribe", Payload: payload}))
+}
+
+func (s *graphqlSuite) read(conn *websocket.Conn) *wsMessage {
+	s.Require().NoError(conn.SetReadDeadline(time.Now().Add(time.Second)))
+	message := &wsMessage{}
+	s.Require().NoError(conn.ReadJSON(message))
+	return message
+}
+
+// readEvent reads the next event of a subscription
+func (s *graphqlSuite) readEvent(conn *websocket.Conn, id string) *TaskEvent {
+	message := s.read(conn)
+	s.Require().Equal("next", message.Type, string(message.Payload))
+	s.Equal(id, message.ID)
+	var payload struct {
+		Data struct {
+			TaskEvents TaskEvent `json:"taskEvents"`
+		} `json:"data"`
+	}
+	s.Require().NoError(json.Unmarshal(message.Payload, &payload))
+	return &payload.Data.TaskEvents
+}
+
+func (s *graphqlSuite) TestSubscription() {
+	conn := s.dial("user")
+	defer conn.Close()
+	s.subscribe(conn, "1", `subscription { taskEvents(statuses: [COMPLETED]) { id action actor task { id name status } } }`)
+	// without lastEventId the kept events are replayed, so the events published before the subscription starts are seen
+	s.create("user", "incomplete", "INCOMPLETE")
+	id := s.create("user", "completed", "COMPLETED")
+	s.create("other", "completed of other", "COMPLETED")
+
+	event := s.readEvent(conn, "1")
+	s.Equal(TaskActionCreate, event.Action)
+	s.Equal("user", event.Actor)
+	s.Equal(id, event.Task.ID)
+	s.Equal(TaskStatusCompleted, event.Task.Status)
+
+	// the events after the last one are replayed
+	s.post("user", `mutation ($id: Int!) { deleteTask(id: $id) }`, map[string]interface{}{"id": id})
+	replayed := s.dial("user")
+	defer replayed.Close()
+	s.subscribe(replayed, "2", fmt.Sprintf(`subscription { taskEvents(taskIds: [%d], lastEventId: "%s") { action task { id } } }`, id, event.ID))
+	s.Equal(TaskActionDelete, s.readEvent(replayed, "2").Action)
+
+	s.Require().NoError(replayed.WriteJSON(&wsMessage{ID: "2", Type: "complete"}))
+
+	// a reader can subscribe, the errors carry the codes
+	reader := s.dial("reader-user")
+	defer reader.Close()
+	s.subscribe(reader, "3", `subscription { taskEvents(lastEventId: "x") { id } }`)
+	message := s.read(reader)
+	s.Equal("next", message.Type)
+	resp := &gqlResponse{}
+	s.Require().NoError(json.Unmarshal(message.Payload, resp))
+	s.requireCode(resp, code.ParamIncorrect)
+	s.Equal("complete", s.read(reader).Type)
+
+	// the invalid subscriptions are rejected before they start
+	s.subscribe(reader, "4", `subscription { taskEvents { unknown } }`)
+	message = s.read(reader)
+	s.Equal("error", message.Type)
+	var errs []struct {
+		Extensions struct {
+			Code int `json:"code"`
+		} `json:"extensions"`
+	}
+	s.Require().NoError(json.Unmarshal(message.Payload, &errs))
+	s.Require().Len(errs, 1)
+	s.Equal(code.ParamIncorrect, errs[0].Extensions.Code)
+}
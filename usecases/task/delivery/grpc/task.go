@@ -0,0 +1,169 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/grpcapi"
+	"github.com/Yu-Qi/restful_api/pkg/api/pb"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// TaskServer represent the gRPC service for tasks
+type TaskServer struct {
+	pb.UnimplementedTaskServiceServer
+}
+
+// NewTaskServer will register the TaskService
+func NewTaskServer(s *grpc.Server) {
+	pb.RegisterTaskServiceServer(s, &TaskServer{})
+}
+
+// ReadMethods are the methods which only read the tasks, for the scopes
+func ReadMethods() []string {
+	return []string{
+		pb.TaskService_GetTask_FullMethodName,
+		pb.TaskService_ListTasks_FullMethodName,
+		pb.TaskService_WatchTasks_FullMethodName,
+	}
+}
+
+// GetTask get a task
+func (t *TaskServer) GetTask(ctx context.Context, req *pb.GetTaskRequest) (*pb.Task, error) {
+	task, customErr := usecase.GetTask(ctx, int(req.GetId()))
+	if customErr != nil {
+		return nil, grpcapi.Error(ctx, customErr)
+	}
+	return pb.FromTask(task), nil
+}
+
+// ListTasks get all tasks, or the ones blocked or not by an incomplete task
+func (t *TaskServer) ListTasks(ctx context.Context, req *pb.ListTasksRequest) (*pb.TaskList, error) {
+	var tasks []*domain.Task
+	var customErr *code.CustomError
+	if req.Blocked != nil {
+		tasks, customErr = usecase.GetTasksByBlocked(ctx, req.GetBlocked())
+	} else {
+		tasks, customErr = usecase.GetTasks(ctx)
+	}
+	if customErr != nil {
+		return nil, grpcapi.Error(ctx, customErr)
+	}
+
+	list := &pb.TaskList{Tasks: make([]*pb.Task, len(tasks))}
+	for i, task := range tasks {
+		list.Tasks[i] = pb.FromTask(task)
+	}
+	return list, nil
+}
+
+// CreateTask create a task
+func (t *TaskServer) CreateTask(ctx context.Context, req *pb.CreateTaskRequest) (*pb.Task, error) {
+	task, customErr := toTask(req.GetTask())
+	if customErr != nil {
+		return nil, grpcapi.Error(ctx, customErr)
+	}
+
+	customErr = usecase.CreateTask(ctx, task)
+	if customErr != nil {
+		return nil, grpcapi.Error(ctx, customErr)
+	}
+	return pb.FromTask(task), nil
+}
+
+// UpdateTask replace a task, the omitted due date and recurrence are cleared
+func (t *TaskServer) UpdateTask(ctx context.Context, req *pb.UpdateTaskRequest) (*pb.Task, error) {
+	task, customErr := toTask(req.GetTask())
+	if customErr != nil {
+		return nil, grpcapi.Error(ctx, customErr)
+	}
+	task.ID = int(req.GetId())
+
+	replaced, customErr := usecase.ReplaceTask(ctx, task)
+	if customErr != nil {
+		return nil, grpcapi.Error(ctx, customErr)
+	}
+	return pb.FromTask(replaced), nil
+}
+
+// DeleteTask delete a task
+func (t *TaskServer) DeleteTask(ctx context.Context, req *pb.DeleteTaskRequest) (*emptypb.Empty, error) {
+	customErr := usecase.DeleteTask(ctx, int(req.GetId()))
+	if customErr != nil {
+		return nil, grpcapi.Error(ctx, customErr)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// WatchTasks stream the changes of the tasks, the stream ends with Aborted when it falls behind,
+// the client watches again from the id of the last event it got
+func (t *TaskServer) WatchTasks(req *pb.WatchTasksRequest, stream pb.TaskService_WatchTasksServer) error {
+	ctx := stream.Context()
+	filter := &usecase.TaskEventFilter{}
+	for _, taskStatus := range req.GetStatuses() {
+		filter.Statuses = append(filter.Statuses, domain.TaskStatus(taskStatus))
+	}
+	for _, id := range req.GetTaskIds() {
+		filter.TaskIDs = append(filter.TaskIDs, int(id))
+	}
+
+	subscription, customErr := usecase.SubscribeTaskEvents(ctx, req.GetLastEventId(), filter)
+	if customErr != nil {
+		return grpcapi.Error(ctx, customErr)
+	}
+	defer subscription.Cancel()
+
+	for _, event := range subscription.Replay {
+		if err := stream.Send(pb.FromTaskEvent(event.ID, event.Data)); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return status.Error(codes.Aborted, "the watch fell behind, watch again from the last event id")
+			}
+			if err := stream.Send(pb.FromTaskEvent(event.ID, event.Data)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// toTask converts a task message and checks it like the json body of POST /v1/tasks
+func toTask(message *pb.Task) (*domain.Task, *code.CustomError) {
+	var err error
+	switch {
+	case message == nil:
+		err = fmt.Errorf("task is required")
+	case message.Status == nil:
+		err = fmt.Errorf("status is required")
+	}
+	if err != nil {
+		return nil, code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+	}
+
+	// the other fields are set by the server
+	converted := pb.ToTask(message)
+	task := &domain.Task{
+		Name:       converted.Name,
+		Status:     converted.Status,
+		DueAt:      converted.DueAt,
+		Recurrence: converted.Recurrence,
+	}
+	if customErr := usecase.ValidateTask(task); customErr != nil {
+		return nil, customErr
+	}
+	return task, nil
+}
@@ -0,0 +1,288 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/grpcapi"
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/api/pb"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/eventbus"
+	"github.com/Yu-Qi/restful_api/pkg/ratelimit"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+func TestTaskServerSuite(t *testing.T) {
+	suite.Run(t, new(taskServerSuite))
+}
+
+type taskServerSuite struct {
+	suite.Suite
+	Server *grpc.Server
+	Conn   *grpc.ClientConn
+	Client pb.TaskServiceClient
+}
+
+// testScheme authenticates the same user granted tasks:read by the reader credentials and every scope by the writer ones
+var testScheme = middleware.AuthScheme{
+	Name: "Test",
+	Verify: func(ctx context.Context, credentials string) (*auth.Principal, *code.CustomError) {
+		switch credentials {
+		case "reader":
+			return &auth.Principal{Subject: "user", Scopes: []string{auth.ScopeTasksRead}}, nil
+		case "writer":
+			return &auth.Principal{Subject: "user"}, nil
+		}
+		return nil, code.NewCustomError(code.Unauthorized, http.StatusUnauthorized, fmt.Errorf("invalid credentials"))
+	},
+}
+
+func (s *taskServerSuite) SetupSuite() {
+	listener := bufconn.Listen(1 << 20)
+	stages := []grpcapi.Stage{
+		grpcapi.RequestContext,
+		grpcapi.Authenticate(testScheme),
+		grpcapi.RequireScope(auth.ScopeTasksRead, auth.ScopeTasksWrite, ReadMethods()...),
+	}
+	s.Server = grpc.NewServer(
+		grpc.UnaryInterceptor(grpcapi.UnaryInterceptor(stages...)),
+		grpc.StreamInterceptor(grpcapi.StreamInterceptor(stages...)),
+	)
+	NewTaskServer(s.Server)
+	go func() {
+		_ = s.Server.Serve(listener)
+	}()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	s.Require().NoError(err)
+	s.Conn = conn
+	s.Client = pb.NewTaskServiceClient(conn)
+}
+
+func (s *taskServerSuite) TearDownSuite() {
+	_ = s.Conn.Close()
+	s.Server.Stop()
+}
+
+func (s *taskServerSuite) SetupTest() {
+	taskEvents := eventbus.New[*domain.TaskEvent](100, 10)
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo:   _taskRepo.NewInMemoryTaskRepo(_taskRepo.WithEventPublisher(taskEvents.Publish)),
+		TaskEvents: taskEvents,
+	})
+}
+
+// as returns a context calling with the credentials and the other metadata in pairs
+func (s *taskServerSuite) as(credentials string, pairs ...string) context.Context {
+	return metadata.NewOutgoingContext(context.Background(),
+		metadata.Pairs(append([]string{"authorization", "Test " + credentials}, pairs...)...))
+}
+
+func (s *taskServerSuite) create(name string, taskStatus int32) *pb.Task {
+	task, err := s.Client.CreateTask(s.as("writer"), &pb.CreateTaskRequest{Task: &pb.Task{Name: name, Status: proto.Int32(taskStatus)}})
+	s.Require().NoError(err)
+	return task
+}
+
+// requireCode checks the status code of err and the code in its ErrorInfo
+func (s *taskServerSuite) requireCode(err error, statusCode codes.Code, customCode int) {
+	s.Require().Error(err)
+	s.Equal(statusCode, status.Code(err), err.Error())
+	c, ok := grpcapi.CodeOf(err)
+	s.True(ok)
+	s.Equal(customCode, c)
+}
+
+func (s *taskServerSuite) TestCRUD() {
+	dueAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	created, err := s.Client.CreateTask(s.as("writer"), &pb.CreateTaskRequest{Task: &pb.Task{
+		Name:   "task",
+		Status: proto.Int32(int32(domain.TaskStatusIncomplete)),
+		DueAt:  timestamppb.New(dueAt),
+		// set by the server
+		Id:      100,
+		OwnerId: "someone",
+	}})
+	s.Require().NoError(err)
+	s.NotEqual(int32(100), created.GetId())
+	s.Equal("user", created.GetOwnerId())
+	s.True(dueAt.Equal(created.GetDueAt().AsTime()))
+
+	got, err := s.Client.GetTask(s.as("reader"), &pb.GetTaskRequest{Id: created.GetId()})
+	s.Require().NoError(err)
+	s.Equal(created.GetName(), got.GetName())
+	s.Equal(created.GetOwnerId(), got.GetOwnerId())
+	s.Equal(int32(1), got.GetVersion())
+
+	updated, err := s.Client.UpdateTask(s.as("writer"), &pb.UpdateTaskRequest{Id: created.GetId(), Task: &pb.Task{
+		Name:   "renamed",
+		Status: proto.Int32(int32(domain.TaskStatusCompleted)),
+	}})
+	s.Require().NoError(err)
+	s.Equal("renamed", updated.GetName())
+	s.Equal(int32(domain.TaskStatusCompleted), updated.GetStatus())
+	// the omitted due date is cleared
+	s.Nil(updated.GetDueAt())
+
+	s.create("other", int32(domain.TaskStatusIncomplete))
+	list, err := s.Client.ListTasks(s.as("reader"), &pb.ListTasksRequest{})
+	s.Require().NoError(err)
+	s.Len(list.GetTasks(), 2)
+	list, err = s.Client.ListTasks(s.as("reader"), &pb.ListTasksRequest{Blocked: proto.Bool(true)})
+	s.Require().NoError(err)
+	s.Empty(list.GetTasks())
+
+	_, err = s.Client.DeleteTask(s.as("writer"), &pb.DeleteTaskRequest{Id: created.GetId()})
+	s.Require().NoError(err)
+	_, err = s.Client.GetTask(s.as("reader", grpcapi.MetadataRequestID, "request-1"), &pb.GetTaskRequest{Id: created.GetId()})
+	s.requireCode(err, codes.NotFound, code.NotFound)
+
+	// the details carry the name of the code and the request id
+	var info *errdetails.ErrorInfo
+	var requestInfo *errdetails.RequestInfo
+	for _, detail := range status.Convert(err).Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			info = detail
+		case *errdetails.RequestInfo:
+			requestInfo = detail
+		}
+	}
+	s.Require().NotNil(info)
+	s.Equal("NotFound", info.GetReason())
+	s.Equal(grpcapi.ErrorDomain, info.GetDomain())
+	s.Equal("404", info.GetMetadata()[grpcapi.MetadataHTTPStatus])
+	s.Require().NotNil(requestInfo)
+	s.Equal("request-1", requestInfo.GetRequestId())
+}
+
+func (s *taskServerSuite) TestValidation() {
+	for _, task := range []*pb.Task{
+		nil,
+		{Status: proto.Int32(0)},
+		{Name: "task"},
+		{Name: "task", Status: proto.Int32(2)},
+		{Name: "task", Status: proto.Int32(0), Recurrence: &pb.Recurrence{Frequency: "daily"}},
+		{Name: "task", Status: proto.Int32(0), Recurrence: &pb.Recurrence{Frequency: "hourly", StartAt: timestamppb.Now()}},
+	} {
+		_, err := s.Client.CreateTask(s.as("writer"), &pb.CreateTaskRequest{Task: task})
+		s.requireCode(err, codes.InvalidArgument, code.ParamIncorrect)
+	}
+
+	recurring, err := s.Client.CreateTask(s.as("writer"), &pb.CreateTaskRequest{Task: &pb.Task{
+		Name:       "recurring",
+		Status:     proto.Int32(0),
+		Recurrence: &pb.Recurrence{Frequency: "weekly", Interval: 2, StartAt: timestamppb.New(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))},
+	}})
+	s.Require().NoError(err)
+	s.Equal("weekly", recurring.GetRecurrence().GetFrequency())
+	s.NotNil(recurring.GetDueAt())
+
+	_, err = s.Client.UpdateTask(s.as("writer"), &pb.UpdateTaskRequest{Id: 999, Task: &pb.Task{Name: "task", Status: proto.Int32(0)}})
+	s.requireCode(err, codes.NotFound, code.NotFound)
+}
+
+func (s *taskServerSuite) TestAuth() {
+	_, err := s.Client.ListTasks(context.Background(), &pb.ListTasksRequest{})
+	s.requireCode(err, codes.Unauthenticated, code.Unauthorized)
+	_, err = s.Client.ListTasks(s.as("nobody"), &pb.ListTasksRequest{})
+	s.requireCode(err, codes.Unauthenticated, code.Unauthorized)
+
+	// a reader can't write
+	_, err = s.Client.CreateTask(s.as("reader"), &pb.CreateTaskRequest{Task: &pb.Task{Name: "task", Status: proto.Int32(0)}})
+	s.requireCode(err, codes.PermissionDenied, code.Forbidden)
+	_, err = s.Client.ListTasks(s.as("reader"), &pb.ListTasksRequest{})
+	s.NoError(err)
+
+	_, err = s.Client.ListTasks(s.as("reader", "x-tenant-id", "not a tenant"), &pb.ListTasksRequest{})
+	s.requireCode(err, codes.InvalidArgument, code.ParamIncorrect)
+
+	// a token without a tenant is bound to the default tenant
+	s.create("task", 0)
+	_, err = s.Client.ListTasks(s.as("reader", "x-tenant-id", "other"), &pb.ListTasksRequest{})
+	s.requireCode(err, codes.PermissionDenied, code.Forbidden)
+	list, err := s.Client.ListTasks(s.as("reader", "x-tenant-id", requestctx.DefaultTenantID), &pb.ListTasksRequest{})
+	s.Require().NoError(err)
+	s.Len(list.GetTasks(), 1)
+
+	stream, err := s.Client.WatchTasks(context.Background(), &pb.WatchTasksRequest{})
+	s.Require().NoError(err)
+	_, err = stream.Recv()
+	s.requireCode(err, codes.Unauthenticated, code.Unauthorized)
+}
+
+func (s *taskServerSuite) TestCreateLimit() {
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo:      _taskRepo.NewInMemoryTaskRepo(),
+		CreateLimiter: ratelimit.NewInMemoryStore(),
+		CreateLimit:   ratelimit.Limit{Requests: 1, Period: time.Hour},
+	})
+
+	s.create("task", 0)
+	_, err := s.Client.CreateTask(s.as("writer"), &pb.CreateTaskRequest{Task: &pb.Task{Name: "task", Status: proto.Int32(0)}})
+	s.requireCode(err, codes.ResourceExhausted, code.TooManyRequests)
+}
+
+func (s *taskServerSuite) TestWatch() {
+	first := s.create("first", 0)
+
+	ctx, cancel := context.WithCancel(s.as("reader"))
+	defer cancel()
+	stream, err := s.Client.WatchTasks(ctx, &pb.WatchTasksRequest{Statuses: []int32{int32(domain.TaskStatusCompleted)}, LastEventId: 0})
+	s.Require().NoError(err)
+	all, err := s.Client.WatchTasks(ctx, &pb.WatchTasksRequest{})
+	s.Require().NoError(err)
+
+	// the kept event is replayed, it tells the watch is subscribed
+	event, err := all.Recv()
+	s.Require().NoError(err)
+	s.Equal(uint64(1), event.GetId())
+	s.Equal(string(domain.TaskActionCreate), event.GetAction())
+	s.Equal(first.GetId(), event.GetTask().GetId())
+	s.Equal("user", event.GetActor())
+
+	_, err = s.Client.UpdateTask(s.as("writer"), &pb.UpdateTaskRequest{Id: first.GetId(), Task: &pb.Task{
+		Name:   "first",
+		Status: proto.Int32(int32(domain.TaskStatusCompleted)),
+	}})
+	s.Require().NoError(err)
+	event, err = all.Recv()
+	s.Require().NoError(err)
+	s.Equal(uint64(2), event.GetId())
+	s.Equal(string(domain.TaskActionUpdate), event.GetAction())
+
+	// the incomplete task of the first event is filtered out
+	event, err = stream.Recv()
+	s.Require().NoError(err)
+	s.Equal(uint64(2), event.GetId())
+	s.Equal(int32(domain.TaskStatusCompleted), event.GetTask().GetStatus())
+
+	cancel()
+	_, err = all.Recv()
+	s.Equal(codes.Canceled, status.Code(err))
+}
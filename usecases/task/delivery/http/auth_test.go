@@ -0,0 +1,159 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// bearer token authentication and task ownership
+func TestAuthSuite(t *testing.T) {
+	suite.Run(t, new(authSuite))
+}
+
+type authSuite struct {
+	suite.Suite
+	Router     *gin.Engine
+	Ctx        context.Context
+	HS256Key   []byte
+	RS256Key   *rsa.PrivateKey
+	RS256KeyID string
+}
+
+func (s *authSuite) SetupSuite() {
+	s.HS256Key = []byte("secret")
+	s.RS256KeyID = "rsa-1"
+	var err error
+	s.RS256Key, err = rsa.GenerateKey(rand.Reader, 2048)
+	s.NoError(err)
+
+	jwks, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": s.RS256KeyID,
+				"n":   base64.RawURLEncoding.EncodeToString(s.RS256Key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.RS256Key.E)).Bytes()),
+			},
+		},
+	})
+	s.NoError(err)
+	jwksFile := filepath.Join(s.T().TempDir(), "jwks.json")
+	s.NoError(os.WriteFile(jwksFile, jwks, 0o600))
+
+	verifier, err := auth.NewJWTVerifier(&auth.JWTConfig{
+		HS256Secret: s.HS256Key,
+		JWKSFile:    jwksFile,
+	})
+	s.NoError(err)
+
+	s.Router = gin.Default()
+	s.Router.ContextWithFallback = true
+	NewTaskHandler(s.Router.Group("", middleware.Authenticate(middleware.BearerScheme(verifier))))
+}
+
+func (s *authSuite) SetupTest() {
+	taskRepo := _taskRepo.NewInMemoryTaskRepo()
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: taskRepo,
+	})
+
+	s.Ctx = context.Background()
+}
+
+func (s *authSuite) hs256Token(subject string, expiresIn time.Duration) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": subject,
+		"exp": time.Now().Add(expiresIn).Unix(),
+	}).SignedString(s.HS256Key)
+	s.NoError(err)
+	return token
+}
+
+func (s *authSuite) rs256Token(subject string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub": subject,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = s.RS256KeyID
+	signed, err := token.SignedString(s.RS256Key)
+	s.NoError(err)
+	return signed
+}
+
+func (s *authSuite) serve(method, url, token string, body interface{}) *httptest.ResponseRecorder {
+	return testutil.Serve(s.Router, testutil.NewJSONRequest(s.T(), method, url, body), "Authorization", testutil.Bearer(token))
+}
+
+func (s *authSuite) TestUnauthorized() {
+	w := s.serve("GET", "/v1/tasks", "", nil)
+	s.Equal(http.StatusUnauthorized, w.Code)
+	s.Equal(code.Unauthorized, testutil.ResponseCode(s.T(), w))
+
+	w = s.serve("GET", "/v1/tasks", s.hs256Token("alice", -time.Minute), nil)
+	s.Equal(http.StatusUnauthorized, w.Code)
+	s.Equal(code.Unauthorized, testutil.ResponseCode(s.T(), w))
+
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("another secret"))
+	s.NoError(err)
+	w = s.serve("GET", "/v1/tasks", forged, nil)
+	s.Equal(http.StatusUnauthorized, w.Code)
+}
+
+func (s *authSuite) TestTasksAreScopedToOwner() {
+	alice := s.hs256Token("alice", time.Hour)
+	bob := s.rs256Token("bob")
+
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks", alice, map[string]interface{}{"name": "alice task", "status": 0}).Code)
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks", bob, map[string]interface{}{"name": "bob task", "status": 0}).Code)
+
+	w := s.serve("GET", "/v1/tasks", bob, nil)
+	s.Equal(http.StatusOK, w.Code)
+	var response struct {
+		Data []struct {
+			Name    string `json:"name"`
+			OwnerID string `json:"owner_id"`
+		} `json:"data"`
+	}
+	s.Nil(json.Unmarshal(w.Body.Bytes(), &response))
+	s.Equal(1, len(response.Data))
+	s.Equal("bob task", response.Data[0].Name)
+	s.Equal("bob", response.Data[0].OwnerID)
+
+	// alice's task is task 1, it looks missing to bob so that he can't tell it exists
+	w = s.serve("PUT", "/v1/tasks/1", bob, map[string]interface{}{"name": "hijacked", "status": 0})
+	s.Equal(http.StatusNotFound, w.Code)
+	s.Equal(code.NotFound, testutil.ResponseCode(s.T(), w))
+	s.Contains(w.Body.String(), `"message":"task not found"`)
+	s.Equal(http.StatusNotFound, s.serve("DELETE", "/v1/tasks/1", bob, nil).Code)
+	s.Equal(http.StatusNotFound, s.serve("GET", "/v1/tasks/1/history", bob, nil).Code)
+	s.Equal(http.StatusNotFound, s.serve("POST", "/v1/tasks/2/dependencies", bob, map[string]interface{}{"blocker_id": 1}).Code)
+
+	s.Equal(http.StatusOK, s.serve("PUT", "/v1/tasks/1", alice, map[string]interface{}{"name": "renamed", "status": 0}).Code)
+	task, customErr := _taskUsecase.GetTask(s.Ctx, 1)
+	s.Nil(customErr)
+	s.Equal("renamed", task.Name)
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/Yu-Qi/restful_api/usecases/task/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// GetDependencies get the tasks blocking a task
+func (t *TaskHandler) GetDependencies(ctx *gin.Context) {
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		customErr := code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	blockers, customErr := usecase.GetBlockers(ctx, taskID)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, blockers)
+}
+
+type addDependencyParams struct {
+	BlockerID int `json:"blocker_id" binding:"required"`
+}
+
+// AddDependency make a task blocked by another task
+func (t *TaskHandler) AddDependency(ctx *gin.Context) {
+	params := addDependencyParams{}
+	customErr := util.ToGinContextExt(ctx).BindJson(&params)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		customErr = code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	customErr = usecase.AddDependency(ctx, taskID, params.BlockerID)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, params)
+}
+
+// RemoveDependency remove the blocked-by relation between two tasks
+func (t *TaskHandler) RemoveDependency(ctx *gin.Context) {
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		customErr := code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		response.CustomError(ctx, customErr)
+		return
+	}
+	blockerID, err := strconv.Atoi(ctx.Param("blocker_id"))
+	if err != nil {
+		customErr := code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	customErr := usecase.RemoveDependency(ctx, taskID, blockerID)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, nil)
+}
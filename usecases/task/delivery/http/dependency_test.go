@@ -0,0 +1,184 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/domain/seed"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// /v1/tasks/:id/dependencies
+func TestDependencySuite(t *testing.T) {
+	suite.Run(t, new(dependencySuite))
+}
+
+type dependencySuite struct {
+	suite.Suite
+	Router    *gin.Engine
+	UrlFormat string
+	Ctx       context.Context
+}
+
+func (s *dependencySuite) SetupSuite() {
+	s.Router = gin.Default()
+	NewTaskHandler(s.Router.Group(""))
+
+	s.UrlFormat = "/v1/tasks/%v/dependencies"
+}
+
+func (s *dependencySuite) SetupTest() {
+	taskRepo := _taskRepo.NewInMemoryTaskRepo()
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: taskRepo,
+	})
+
+	s.Ctx = context.Background()
+
+	for _, task := range seed.Tasks() {
+		customErr := _taskUsecase.CreateTask(s.Ctx, task)
+		s.Nil(customErr)
+	}
+}
+
+func (s *dependencySuite) addDependency(taskID, blockerID int) *httptest.ResponseRecorder {
+	jsonStr, err := json.Marshal(map[string]interface{}{
+		"blocker_id": blockerID,
+	})
+	s.NoError(err)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", fmt.Sprintf(s.UrlFormat, taskID), bytes.NewBuffer(jsonStr))
+	s.NoError(err)
+	s.Router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *dependencySuite) TestAddAndGet() {
+	s.Equal(http.StatusOK, s.addDependency(3, 1).Code)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", fmt.Sprintf(s.UrlFormat, 3), nil)
+	s.NoError(err)
+	s.Router.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+
+	var response struct {
+		Code int `json:"code"`
+		Data []struct {
+			ID int `json:"id"`
+		} `json:"data"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	s.Nil(err)
+	s.Equal(1, len(response.Data))
+	s.Equal(1, response.Data[0].ID)
+}
+
+func (s *dependencySuite) TestCycle() {
+	s.Equal(http.StatusOK, s.addDependency(3, 1).Code)
+
+	w := s.addDependency(1, 3)
+	s.Equal(http.StatusConflict, w.Code)
+
+	var response struct {
+		Code int `json:"code"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	s.Nil(err)
+	s.Equal(code.DependencyCycle, response.Code)
+}
+
+func (s *dependencySuite) TestRemove() {
+	s.Equal(http.StatusOK, s.addDependency(3, 1).Code)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("DELETE", fmt.Sprintf(s.UrlFormat+"/%v", 3, 1), nil)
+	s.NoError(err)
+	s.Router.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+
+	blockers, customErr := _taskUsecase.GetBlockers(s.Ctx, 3)
+	s.Nil(customErr)
+	s.Empty(blockers)
+}
+
+func (s *dependencySuite) TestCompleteBlockedTask() {
+	s.Equal(http.StatusOK, s.addDependency(3, 1).Code)
+
+	jsonStr, err := json.Marshal(map[string]interface{}{
+		"status": 1,
+	})
+	s.NoError(err)
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("PATCH", "/v1/tasks/3", bytes.NewBuffer(jsonStr))
+	s.NoError(err)
+	req.Header.Set("Content-Type", MIMEMergePatch)
+	s.Router.ServeHTTP(w, req)
+	s.Equal(http.StatusConflict, w.Code)
+
+	var response struct {
+		Code int `json:"code"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	s.Nil(err)
+	s.Equal(code.BlockedByIncomplete, response.Code)
+
+	// task 2 is a completed blocker, so it does not block task 4
+	s.Equal(http.StatusOK, s.addDependency(4, 2).Code)
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest("PATCH", "/v1/tasks/4", bytes.NewBuffer(jsonStr))
+	s.NoError(err)
+	req.Header.Set("Content-Type", MIMEMergePatch)
+	s.Router.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+}
+
+func (s *dependencySuite) TestGetUnblockedTasks() {
+	// 4 blocked by 3, 3 blocked by 1
+	s.Equal(http.StatusOK, s.addDependency(4, 3).Code)
+	s.Equal(http.StatusOK, s.addDependency(3, 1).Code)
+
+	getIDs := func(blocked bool) []int {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", fmt.Sprintf("/v1/tasks?blocked=%v", blocked), nil)
+		s.NoError(err)
+		s.Router.ServeHTTP(w, req)
+		s.Equal(http.StatusOK, w.Code)
+
+		var response struct {
+			Data []struct {
+				ID int `json:"id"`
+			} `json:"data"`
+		}
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		s.Nil(err)
+		ids := []int{}
+		for _, task := range response.Data {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	s.Equal([]int{1}, getIDs(false))
+	s.Equal([]int{3, 4}, getIDs(true))
+
+	customErr := _taskUsecase.UpdateTask(s.Ctx, &domain.UpdateTaskParams{
+		ID:     1,
+		Status: util.Ptr(domain.TaskStatusCompleted),
+	})
+	s.Nil(customErr)
+	s.Equal([]int{3}, getIDs(false))
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/Yu-Qi/restful_api/usecases/task/usecase"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// heartbeatInterval is how often a comment is sent on an idle stream, so the proxies keep it open
+var heartbeatInterval = 15 * time.Second
+
+type streamTaskEventsParams struct {
+	Status []domain.TaskStatus `form:"status" binding:"dive,min=0,max=1"`
+	// LastEventID is used when the Last-Event-ID header is absent
+	LastEventID uint64 `form:"last_event_id"`
+}
+
+// StreamTaskEvents stream the changes of the tasks as server-sent events
+func (t *TaskHandler) StreamTaskEvents(ctx *gin.Context) {
+	params := streamTaskEventsParams{}
+	customErr := util.ToGinContextExt(ctx).BindQuery(&params)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	if header := ctx.GetHeader("Last-Event-ID"); header != "" {
+		lastEventID, err := strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			customErr = code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+			response.CustomError(ctx, customErr)
+			return
+		}
+		params.LastEventID = lastEventID
+	}
+
+	subscription, customErr := usecase.SubscribeTaskEvents(ctx, params.LastEventID, &usecase.TaskEventFilter{Statuses: params.Status})
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	defer subscription.Cancel()
+
+	ctx.Header("Content-Type", sse.ContentType)
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	for _, event := range subscription.Replay {
+		writeTaskEvent(ctx, event)
+	}
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// fell behind, the client reconnects with Last-Event-ID
+				return
+			}
+			writeTaskEvent(ctx, event)
+		case <-heartbeat.C:
+			_, _ = ctx.Writer.WriteString(": heartbeat\n\n")
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+func writeTaskEvent(ctx *gin.Context, event usecase.TaskEvent) {
+	ctx.Render(-1, sse.Event{
+		Id:    strconv.FormatUint(event.ID, 10),
+		Event: string(event.Data.Action),
+		Data:  event.Data,
+	})
+}
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/eventbus"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// GET /v1/tasks/events
+func TestTaskEventSuite(t *testing.T) {
+	suite.Run(t, new(taskEventSuite))
+}
+
+type taskEventSuite struct {
+	suite.Suite
+	Server *httptest.Server
+	Ctx    context.Context
+}
+
+// sseEvent is an event or a comment read from the stream
+type sseEvent struct {
+	ID      string
+	Event   string
+	Data    string
+	Comment string
+}
+
+func (s *taskEventSuite) SetupSuite() {
+	heartbeatInterval = 50 * time.Millisecond
+	router := gin.Default()
+	router.ContextWithFallback = true
+	NewTaskHandler(router.Group(""))
+	s.Server = httptest.NewServer(router)
+}
+
+func (s *taskEventSuite) TearDownSuite() {
+	s.Server.Close()
+}
+
+func (s *taskEventSuite) SetupTest() {
+	taskEvents := eventbus.New[*domain.TaskEvent](100, 10)
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo:   _taskRepo.NewInMemoryTaskRepo(_taskRepo.WithEventPublisher(taskEvents.Publish)),
+		TaskEvents: taskEvents,
+	})
+	s.Ctx = context.Background()
+}
+
+// stream opens a stream and returns the channel of the events read from it
+func (s *taskEventSuite) stream(query string, header http.Header) (<-chan sseEvent, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, "GET", s.Server.URL+"/v1/tasks/events"+query, nil)
+	s.NoError(err)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	s.NoError(err)
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan sseEvent, 100)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		event := sseEvent{}
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				events <- event
+				event = sseEvent{}
+				continue
+			}
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "":
+				event.Comment = value
+			case "id":
+				event.ID = value
+			case "event":
+				event.Event = value
+			case "data":
+				event.Data = value
+			}
+		}
+	}()
+	return events, cancel
+}
+
+// next returns the next event, skipping the heartbeats
+func (s *taskEventSuite) next(events <-chan sseEvent) sseEvent {
+	for {
+		select {
+		case event := <-events:
+			if event.Comment == "" {
+				return event
+			}
+		case <-time.After(time.Second):
+			s.FailNow("no event")
+		}
+	}
+}
+
+func (s *taskEventSuite) TestStreamChanges() {
+	events, cancel := s.stream("", nil)
+	defer cancel()
+
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "task"}))
+	s.Nil(_taskUsecase.UpdateTask(s.Ctx, &domain.UpdateTaskParams{ID: 1, Name: util.Ptr("renamed")}))
+	s.Nil(_taskUsecase.DeleteTask(s.Ctx, 1))
+
+	for i, action := range []domain.TaskAction{domain.TaskActionCreate, domain.TaskActionUpdate, domain.TaskActionDelete} {
+		event := s.next(events)
+		s.Equal(string(action), event.Event)
+		s.Equal(string(rune('1'+i)), event.ID)
+
+		var data domain.TaskEvent
+		s.Nil(json.Unmarshal([]byte(event.Data), &data))
+		s.Equal(action, data.Action)
+		s.Equal(1, data.Task.ID)
+	}
+}
+
+func (s *taskEventSuite) TestHeartbeat() {
+	events, cancel := s.stream("", nil)
+	defer cancel()
+
+	select {
+	case event := <-events:
+		s.Equal("heartbeat", event.Comment)
+	case <-time.After(time.Second):
+		s.Fail("no heartbeat")
+	}
+}
+
+func (s *taskEventSuite) TestResumeAndFilter() {
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "incomplete"}))
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "completed", Status: domain.TaskStatusCompleted}))
+	s.Nil(_taskUsecase.CreateTask(requestctx.WithTenantID(s.Ctx, "acme"), &domain.Task{Name: "another tenant"}))
+
+	// the events after 1 are replayed
+	events, cancel := s.stream("", http.Header{"Last-Event-Id": {"1"}})
+	event := s.next(events)
+	s.Equal("2", event.ID)
+	s.Contains(event.Data, `"completed"`)
+	cancel()
+
+	events, cancel = s.stream("?status=1", nil)
+	defer cancel()
+	s.Equal("2", s.next(events).ID)
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "skipped"}))
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "streamed", Status: domain.TaskStatusCompleted}))
+	s.Equal("5", s.next(events).ID)
+}
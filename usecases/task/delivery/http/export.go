@@ -0,0 +1,117 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	customlog "github.com/Yu-Qi/restful_api/pkg/custom_log"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/Yu-Qi/restful_api/usecases/task/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// taskCSVColumns renders each column of the task export
+var taskCSVColumns = map[string]func(task *domain.Task) string{
+	"id":     func(task *domain.Task) string { return strconv.Itoa(task.ID) },
+	"name":   func(task *domain.Task) string { return csvText(task.Name) },
+	"status": func(task *domain.Task) string { return task.Status.String() },
+	"due_at": func(task *domain.Task) string {
+		if task.DueAt == nil {
+			return ""
+		}
+		return task.DueAt.Format(time.RFC3339)
+	},
+	"recurrence": func(task *domain.Task) string {
+		if task.Recurrence == nil {
+			return ""
+		}
+		return string(task.Recurrence.Frequency)
+	},
+	"version":  func(task *domain.Task) string { return strconv.Itoa(task.Version) },
+	"owner_id": func(task *domain.Task) string { return csvText(task.OwnerID) },
+}
+
+const defaultTaskCSVColumns = "id,name,status,due_at"
+
+type exportTasksParams struct {
+	getTasksParams
+	// Columns is a comma separated list of the keys of taskCSVColumns
+	Columns string `form:"columns"`
+}
+
+// ExportTasks export the tasks matching the filters of GetTasks as a csv file ordered by id,
+// the rows are written as the tasks are read
+func (t *TaskHandler) ExportTasks(ctx *gin.Context) {
+	params := exportTasksParams{Columns: defaultTaskCSVColumns}
+	customErr := util.ToGinContextExt(ctx).BindQuery(&params)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	columns := strings.Split(params.Columns, ",")
+	for _, column := range columns {
+		if _, ok := taskCSVColumns[column]; !ok {
+			customErr = code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, fmt.Errorf("unknown column %q", column))
+			response.CustomError(ctx, customErr)
+			return
+		}
+	}
+
+	rangeTasks := func(fn func(task *domain.Task) bool) *code.CustomError {
+		return usecase.RangeTasks(ctx, fn)
+	}
+	if params.Blocked != nil {
+		// the blocked filter needs the whole graph, its tasks are collected
+		tasks, customErr := usecase.GetTasksByBlocked(ctx, *params.Blocked)
+		if customErr != nil {
+			response.CustomError(ctx, customErr)
+			return
+		}
+		sort.Slice(tasks, func(a, b int) bool { return tasks[a].ID < tasks[b].ID })
+		rangeTasks = func(fn func(task *domain.Task) bool) *code.CustomError {
+			for _, task := range tasks {
+				if !fn(task) {
+					break
+				}
+			}
+			return nil
+		}
+	}
+
+	err := util.StreamCSVFile(ctx, "tasks.csv", columns, func(write func(row []string) error) error {
+		row := make([]string, len(columns))
+		var writeErr error
+		customErr = rangeTasks(func(task *domain.Task) bool {
+			for i, column := range columns {
+				row[i] = taskCSVColumns[column](task)
+			}
+			writeErr = write(row)
+			return writeErr == nil
+		})
+		if customErr != nil {
+			return customErr.Error
+		}
+		return writeErr
+	})
+	switch {
+	case customErr != nil && !ctx.Writer.Written():
+		response.CustomError(ctx, customErr)
+	case err != nil:
+		customlog.ErrorfCtx(ctx, "export tasks: %v", err)
+	}
+}
+
+// csvText keeps a spreadsheet from running a free text cell as a formula
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// GET /v1/tasks/export.csv
+func TestExportTaskSuite(t *testing.T) {
+	suite.Run(t, new(exportTaskSuite))
+}
+
+type exportTaskSuite struct {
+	suite.Suite
+	Router *gin.Engine
+	Ctx    context.Context
+}
+
+func (s *exportTaskSuite) SetupSuite() {
+	s.Router = gin.Default()
+	NewTaskHandler(s.Router.Group(""))
+}
+
+func (s *exportTaskSuite) SetupTest() {
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: _taskRepo.NewInMemoryTaskRepo(),
+	})
+	s.Ctx = context.Background()
+}
+
+func (s *exportTaskSuite) export(query string) (*httptest.ResponseRecorder, [][]string) {
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/v1/tasks/export.csv"+query, nil)
+	s.NoError(err)
+	s.Router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		return w, nil
+	}
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	s.NoError(err)
+	return w, records
+}
+
+func (s *exportTaskSuite) TestExport() {
+	dueAt := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "write, \"quoted\"", DueAt: &dueAt}))
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "=HYPERLINK(\"x\")", Status: domain.TaskStatusCompleted}))
+
+	w, records := s.export("")
+	s.Equal("text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	s.Equal("attachment; filename=tasks.csv", w.Header().Get("Content-Disposition"))
+	s.Equal([][]string{
+		{"id", "name", "status", "due_at"},
+		{"1", "write, \"quoted\"", "incomplete", "2030-01-01T09:00:00Z"},
+		{"2", "'=HYPERLINK(\"x\")", "completed", ""},
+	}, records)
+
+	_, records = s.export("?columns=status,version,id")
+	s.Equal([][]string{
+		{"status", "version", "id"},
+		{"incomplete", "1", "1"},
+		{"completed", "1", "2"},
+	}, records)
+
+	w, _ = s.export("?columns=id,secret")
+	s.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (s *exportTaskSuite) TestFilters() {
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "blocked"}))
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "blocker"}))
+	s.Nil(_taskUsecase.AddDependency(s.Ctx, 1, 2))
+
+	_, records := s.export("?blocked=true&columns=name")
+	s.Equal([][]string{{"name"}, {"blocked"}}, records)
+	_, records = s.export("?blocked=false&columns=name")
+	s.Equal([][]string{{"name"}, {"blocker"}}, records)
+}
+
+func (s *exportTaskSuite) TestLargeExport() {
+	const count = 2500
+	for i := 0; i < count; i++ {
+		s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "task " + strconv.Itoa(i)}))
+	}
+
+	s.Nil(_taskUsecase.DeleteTask(s.Ctx, 2))
+
+	_, records := s.export("?columns=id,name")
+	s.Len(records, count)
+	// the tasks are streamed in id order
+	for i := 2; i < len(records); i++ {
+		previous, err := strconv.Atoi(records[i-1][0])
+		s.NoError(err)
+		id, err := strconv.Atoi(records[i][0])
+		s.NoError(err)
+		s.Less(previous, id)
+	}
+	s.Equal([]string{strconv.Itoa(count), "task " + strconv.Itoa(count-1)}, records[count-1])
+}
+
+func (s *exportTaskSuite) TestEmptyExport() {
+	w, records := s.export("")
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal([][]string{{"id", "name", "status", "due_at"}}, records)
+}
+
+func (s *exportTaskSuite) TestDownloadCSVFile() {
+	router := gin.New()
+	router.GET("/report.csv", func(ctx *gin.Context) {
+		util.DownloadCSVFile(ctx, "report.csv", []string{"a", "b"}, [][]string{{"1", "2"}})
+	})
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/report.csv", nil)
+	s.NoError(err)
+	router.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal("a,b\n1,2\n", w.Body.String())
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/Yu-Qi/restful_api/usecases/task/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultPageSize = 20
+
+type paginationParams struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+func (p paginationParams) toDomain() domain.Pagination {
+	pagination := domain.Pagination{Page: p.Page, PageSize: p.PageSize}
+	if pagination.Page == 0 {
+		pagination.Page = 1
+	}
+	if pagination.PageSize == 0 {
+		pagination.PageSize = defaultPageSize
+	}
+	return pagination
+}
+
+type pageResp struct {
+	Items    interface{} `json:"items"`
+	Total    int         `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+}
+
+// GetTaskHistory get the change history of a task
+func (t *TaskHandler) GetTaskHistory(ctx *gin.Context) {
+	params := paginationParams{}
+	customErr := util.ToGinContextExt(ctx).BindQuery(&params)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		customErr = code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	pagination := params.toDomain()
+	histories, total, customErr := usecase.GetTaskHistory(ctx, taskID, pagination)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, &pageResp{
+		Items:    histories,
+		Total:    total,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+	})
+}
+
+type getAuditLogsParams struct {
+	paginationParams
+	Actor *string    `form:"actor"`
+	From  *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To    *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// GetAuditLogs get the change history of all tasks
+func (t *TaskHandler) GetAuditLogs(ctx *gin.Context) {
+	params := getAuditLogsParams{}
+	customErr := util.ToGinContextExt(ctx).BindQuery(&params)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	pagination := params.toDomain()
+	histories, total, customErr := usecase.GetAuditLogs(ctx, &domain.GetAuditLogsParams{
+		Actor:      params.Actor,
+		From:       params.From,
+		To:         params.To,
+		Pagination: pagination,
+	})
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, &pageResp{
+		Items:    histories,
+		Total:    total,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+	})
+}
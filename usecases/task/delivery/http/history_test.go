@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-contrib/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// GET /v1/tasks/:id/history and GET /v1/audit
+func TestHistorySuite(t *testing.T) {
+	suite.Run(t, new(historySuite))
+}
+
+type historySuite struct {
+	suite.Suite
+	Router *gin.Engine
+	Ctx    context.Context
+}
+
+type historyResp struct {
+	Code int `json:"code"`
+	Data struct {
+		Items []struct {
+			TaskID        int    `json:"task_id"`
+			Action        string `json:"action"`
+			Actor         string `json:"actor"`
+			ActorVerified bool   `json:"actor_verified"`
+			RequestID     string `json:"request_id"`
+			Before        *task  `json:"before"`
+			After         *task  `json:"after"`
+		} `json:"items"`
+		Total int `json:"total"`
+	} `json:"data"`
+}
+
+func (s *historySuite) SetupSuite() {
+	s.Router = gin.Default()
+	s.Router.ContextWithFallback = true
+	s.Router.Use(requestid.New(), middleware.RequestContext)
+	NewTaskHandler(s.Router.Group(""))
+}
+
+func (s *historySuite) SetupTest() {
+	taskRepo := _taskRepo.NewInMemoryTaskRepo()
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: taskRepo,
+	})
+
+	s.Ctx = context.Background()
+}
+
+func (s *historySuite) serve(method, url, actor string, body interface{}) *httptest.ResponseRecorder {
+	return testutil.Serve(s.Router, testutil.NewJSONRequest(s.T(), method, url, body),
+		middleware.HeaderActor, actor, "X-Request-ID", actor+"-request")
+}
+
+func (s *historySuite) TestTaskHistory() {
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks", "alice", map[string]interface{}{"name": "test", "status": 0}).Code)
+	s.Equal(http.StatusOK, s.serve("PUT", "/v1/tasks/1", "bob", map[string]interface{}{"name": "test_new", "status": 1}).Code)
+
+	w := s.serve("GET", "/v1/tasks/1/history?page_size=1", "", nil)
+	s.Equal(http.StatusOK, w.Code)
+
+	var response historyResp
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	s.Nil(err)
+	s.Equal(2, response.Data.Total)
+	s.Equal(1, len(response.Data.Items))
+
+	update := response.Data.Items[0]
+	s.Equal("update", update.Action)
+	s.Equal("bob", update.Actor)
+	// the actor is named by the header, not by credentials
+	s.False(update.ActorVerified)
+	s.Equal("bob-request", update.RequestID)
+	s.Equal(task{Name: "test", Status: 0}, *update.Before)
+	s.Equal(task{Name: "test_new", Status: 1}, *update.After)
+
+	s.Equal(http.StatusNotFound, s.serve("GET", "/v1/tasks/2/history", "", nil).Code)
+	s.Equal(http.StatusBadRequest, s.serve("GET", "/v1/tasks/1/history?page_size=1000", "", nil).Code)
+}
+
+func (s *historySuite) TestAuditLogs() {
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks", "alice", map[string]interface{}{"name": "test1", "status": 0}).Code)
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks", "bob", map[string]interface{}{"name": "test2", "status": 0}).Code)
+	s.Equal(http.StatusOK, s.serve("DELETE", "/v1/tasks/2", "alice", nil).Code)
+
+	w := s.serve("GET", "/v1/audit?actor=alice", "", nil)
+	s.Equal(http.StatusOK, w.Code)
+
+	var response historyResp
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	s.Nil(err)
+	s.Equal(2, response.Data.Total)
+	s.Equal("delete", response.Data.Items[0].Action)
+	s.Equal(2, response.Data.Items[0].TaskID)
+	s.Equal("create", response.Data.Items[1].Action)
+
+	w = s.serve("GET", "/v1/audit?from=2000-01-01T00:00:00Z&to=2001-01-01T00:00:00Z", "", nil)
+	s.Equal(http.StatusOK, w.Code)
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	s.Nil(err)
+	s.Equal(0, response.Data.Total)
+
+	s.Equal(http.StatusBadRequest, s.serve("GET", "/v1/audit?from=yesterday", "", nil).Code)
+}
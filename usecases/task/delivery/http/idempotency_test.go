@@ -0,0 +1,135 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/idempotency"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+func TestIdempotencySuite(t *testing.T) {
+	suite.Run(t, new(idempotencySuite))
+}
+
+type idempotencySuite struct {
+	suite.Suite
+	Router *gin.Engine
+}
+
+func (s *idempotencySuite) SetupTest() {
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: _taskRepo.NewInMemoryTaskRepo(),
+	})
+
+	s.Router = gin.Default()
+	NewTaskHandler(s.Router.Group("", middleware.LimitBody(maxTestBodySize), middleware.Idempotency(idempotency.NewInMemoryStore(), 100*time.Millisecond)))
+}
+
+// maxTestBodySize is the body limit of the suite, the bodies of createTask with a short name fit
+const maxTestBodySize = 1 << 10
+
+func (s *idempotencySuite) createTask(key, name string) *httptest.ResponseRecorder {
+	jsonStr, err := json.Marshal(map[string]interface{}{"name": name, "status": 0})
+	s.NoError(err)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", "/v1/tasks", bytes.NewBuffer(jsonStr))
+	s.NoError(err)
+	if key != "" {
+		req.Header.Set(middleware.HeaderIdempotencyKey, key)
+	}
+	s.Router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *idempotencySuite) countTasks() int {
+	tasks, customErr := _taskUsecase.GetTasks(context.Background())
+	s.Nil(customErr)
+	return len(tasks)
+}
+
+func (s *idempotencySuite) TestReplay() {
+	first := s.createTask("key-1", "task")
+	s.Equal(http.StatusOK, first.Code)
+	s.Equal("", first.Header().Get(middleware.HeaderIdempotentReplayed))
+
+	retry := s.createTask("key-1", "task")
+	s.Equal(http.StatusOK, retry.Code)
+	s.Equal("true", retry.Header().Get(middleware.HeaderIdempotentReplayed))
+	s.Equal(first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+	s.Equal(first.Body.String(), retry.Body.String())
+	s.Equal(1, s.countTasks())
+
+	s.Equal(http.StatusOK, s.createTask("key-2", "task").Code)
+	s.Equal(http.StatusOK, s.createTask("", "task").Code)
+	s.Equal(3, s.countTasks())
+}
+
+func (s *idempotencySuite) TestKeyReusedWithAnotherBody() {
+	s.Equal(http.StatusOK, s.createTask("key-1", "task").Code)
+
+	w := s.createTask("key-1", "another task")
+	s.Equal(http.StatusUnprocessableEntity, w.Code)
+	var response struct {
+		Code int `json:"code"`
+	}
+	s.Nil(json.Unmarshal(w.Body.Bytes(), &response))
+	s.Equal(code.IdempotencyKeyReused, response.Code)
+	s.Equal(1, s.countTasks())
+}
+
+func (s *idempotencySuite) TestExpiredKey() {
+	s.Equal(http.StatusOK, s.createTask("key-1", "task").Code)
+	time.Sleep(150 * time.Millisecond)
+
+	w := s.createTask("key-1", "task")
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal("", w.Header().Get(middleware.HeaderIdempotentReplayed))
+	s.Equal(2, s.countTasks())
+}
+
+func (s *idempotencySuite) TestConcurrentRetries() {
+	var wg sync.WaitGroup
+	bodies := make([]string, 10)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bodies[i] = s.createTask("key-1", "task").Body.String()
+		}(i)
+	}
+	wg.Wait()
+
+	s.Equal(1, s.countTasks())
+	for _, body := range bodies {
+		s.Equal(bodies[0], body)
+	}
+}
+
+func (s *idempotencySuite) TestBodyTooLarge() {
+	name := strings.Repeat("a", maxTestBodySize)
+	s.Equal(http.StatusRequestEntityTooLarge, s.createTask("key-1", name).Code)
+
+	// without a Content-Length the body fails to read past the limit
+	body := testutil.NewJSONRequest(s.T(), "POST", "/v1/tasks", map[string]interface{}{"name": name, "status": 0})
+	body.ContentLength = -1
+	w := testutil.Serve(s.Router, body, middleware.HeaderIdempotencyKey, "key-2")
+	s.Equal(http.StatusRequestEntityTooLarge, w.Code)
+	s.Equal(code.RequestTooLarge, testutil.ResponseCode(s.T(), w))
+	s.Equal(0, s.countTasks())
+}
@@ -0,0 +1,341 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/Yu-Qi/restful_api/usecases/task/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+)
+
+var (
+	// maxImportSize is the largest request taken by ImportTasks in bytes
+	maxImportSize int64 = 10 << 20
+	// maxImportRows is the most tasks an import can carry
+	maxImportRows = 10000
+)
+
+const maxExternalIDLength = 255
+
+const (
+	importFormatCSV  = "csv"
+	importFormatJSON = "json"
+)
+
+// import actions of a row
+const (
+	importActionCreate = "create"
+	importActionUpdate = "update"
+	importActionFail   = "fail"
+)
+
+type importTasksParams struct {
+	DryRun bool `form:"dry_run"`
+}
+
+// importTaskParams is a row of an import, it is validated like createTaskParams
+type importTaskParams struct {
+	createTaskParams
+	ExternalID string `json:"external_id"`
+}
+
+func (p *importTaskParams) validate() error {
+	if len(p.ExternalID) > maxExternalIDLength {
+		return fmt.Errorf("external_id is longer than %d characters", maxExternalIDLength)
+	}
+	return p.createTaskParams.validate()
+}
+
+// importEntry is a decoded row, err is set when it can't be imported
+type importEntry struct {
+	params *importTaskParams
+	err    error
+}
+
+type importRowResp struct {
+	// Row is the position of the row in the file starting from 1, the csv header is not counted
+	Row        int    `json:"row"`
+	ExternalID string `json:"external_id,omitempty"`
+	Action     string `json:"action"`
+	TaskID     int    `json:"task_id,omitempty"`
+	Code       int    `json:"code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type importTasksResp struct {
+	DryRun  bool             `json:"dry_run"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Failed  int              `json:"failed"`
+	Rows    []*importRowResp `json:"rows"`
+}
+
+// ImportTasks create or replace the tasks of an uploaded csv or json file by external id,
+// the rows failing are reported without stopping the others
+func (t *TaskHandler) ImportTasks(ctx *gin.Context) {
+	params := importTasksParams{}
+	customErr := util.ToGinContextExt(ctx).BindQuery(&params)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportSize)
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		response.CustomError(ctx, util.BodyError(err))
+		return
+	}
+
+	entries, customErr := decodeImportFile(fileHeader)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	rows := make([]*importRowResp, len(entries))
+	var tasks []*usecase.ImportTask
+	var taskRows []*importRowResp
+	for i, entry := range entries {
+		row := &importRowResp{Row: i + 1, ExternalID: entry.params.ExternalID}
+		rows[i] = row
+		if entry.err != nil {
+			row.Action = importActionFail
+			row.Code = code.ParamIncorrect
+			row.Error = entry.err.Error()
+			continue
+		}
+
+		tasks = append(tasks, &usecase.ImportTask{
+			Task: &domain.Task{
+				Name:       entry.params.Name,
+				Status:     *entry.params.Status,
+				DueAt:      entry.params.DueAt,
+				Recurrence: entry.params.Recurrence,
+				ExternalID: entry.params.ExternalID,
+			},
+			Row: row.Row,
+		})
+		taskRows = append(taskRows, row)
+	}
+
+	results, customErr := usecase.ImportTasks(ctx, tasks, params.DryRun)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	for i, result := range results {
+		row := taskRows[i]
+		switch {
+		case result.Err != nil:
+			row.Action = importActionFail
+			row.Code = result.Err.Code
+			row.Error = result.Err.Error.Error()
+		case result.Created:
+			row.Action = importActionCreate
+			row.TaskID = tasks[i].Task.ID
+		default:
+			row.Action = importActionUpdate
+			row.TaskID = tasks[i].Task.ID
+		}
+	}
+
+	resp := &importTasksResp{DryRun: params.DryRun, Rows: rows}
+	for _, row := range rows {
+		switch row.Action {
+		case importActionCreate:
+			resp.Created++
+		case importActionUpdate:
+			resp.Updated++
+		default:
+			resp.Failed++
+		}
+	}
+	response.OK(ctx, resp)
+}
+
+// decodeImportFile decodes the rows of an uploaded file by the format given by its content type or extension
+func decodeImportFile(fileHeader *multipart.FileHeader) ([]*importEntry, *code.CustomError) {
+	format := importFormat(fileHeader)
+	if format == "" {
+		return nil, code.NewCustomError(code.UnsupportedMediaType, http.StatusUnsupportedMediaType,
+			fmt.Errorf("the file must be csv or json"))
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, code.NewCustomError(code.InternalUnknownError, http.StatusInternalServerError, err)
+	}
+	defer file.Close()
+
+	var entries []*importEntry
+	if format == importFormatCSV {
+		entries, err = decodeCSVImport(file)
+	} else {
+		entries, err = decodeJSONImport(file)
+	}
+	if err != nil {
+		return nil, code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+	}
+	return entries, nil
+}
+
+func importFormat(fileHeader *multipart.FileHeader) string {
+	mediaType, _, _ := mime.ParseMediaType(fileHeader.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return importFormatCSV
+	case "application/json":
+		return importFormatJSON
+	}
+
+	switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+	case ".csv":
+		return importFormatCSV
+	case ".json":
+		return importFormatJSON
+	}
+	return ""
+}
+
+// decodeJSONImport decodes an array of tasks
+func decodeJSONImport(r io.Reader) ([]*importEntry, error) {
+	var raws []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raws); err != nil {
+		return nil, fmt.Errorf("the file must be a json array of tasks: %w", err)
+	}
+	if len(raws) > maxImportRows {
+		return nil, fmt.Errorf("the file has more than %d tasks", maxImportRows)
+	}
+
+	entries := make([]*importEntry, len(raws))
+	for i, raw := range raws {
+		params := &importTaskParams{}
+		err := json.Unmarshal(raw, params)
+		if err == nil {
+			err = params.validate()
+		}
+		entries[i] = &importEntry{params: params, err: err}
+	}
+	return entries, nil
+}
+
+// csvImportColumns parses each column of a csv import into the params of a row
+var csvImportColumns = map[string]func(params *importTaskParams, value string) error{
+	"name": func(params *importTaskParams, value string) error {
+		params.Name = csvUntext(value)
+		return nil
+	},
+	"status": func(params *importTaskParams, value string) error {
+		if value == "" {
+			return nil
+		}
+		if number, err := strconv.Atoi(value); err == nil {
+			params.Status = util.Ptr(domain.TaskStatus(number))
+			return nil
+		}
+		status, err := domain.ParseTaskStatus(value)
+		if err != nil {
+			return fmt.Errorf("status is invalid")
+		}
+		params.Status = &status
+		return nil
+	},
+	"due_at": func(params *importTaskParams, value string) error {
+		if value == "" {
+			return nil
+		}
+		dueAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("due_at is not a RFC 3339 time: %w", err)
+		}
+		params.DueAt = &dueAt
+		return nil
+	},
+	"external_id": func(params *importTaskParams, value string) error {
+		params.ExternalID = value
+		return nil
+	},
+}
+
+// decodeCSVImport decodes a csv file whose header names the columns among the keys of csvImportColumns
+func decodeCSVImport(r io.Reader) ([]*importEntry, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("the file has no header")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, column := range header {
+		if i == 0 {
+			// the byte order mark written by spreadsheets
+			column = strings.TrimPrefix(column, "\ufeff")
+		}
+		column = strings.ToLower(strings.TrimSpace(column))
+		if _, ok := csvImportColumns[column]; !ok {
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+		for _, previous := range header[:i] {
+			if previous == column {
+				return nil, fmt.Errorf("column %q is repeated", column)
+			}
+		}
+		header[i] = column
+	}
+	for _, column := range []string{"name", "status"} {
+		if !lo.Contains(header, column) {
+			return nil, fmt.Errorf("column %q is required", column)
+		}
+	}
+
+	var entries []*importEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, err
+		}
+		if len(entries) == maxImportRows {
+			return nil, fmt.Errorf("the file has more than %d tasks", maxImportRows)
+		}
+
+		entry := &importEntry{params: &importTaskParams{}, err: err}
+		for i, value := range record {
+			if entry.err != nil || i >= len(header) {
+				break
+			}
+			entry.err = csvImportColumns[header[i]](entry.params, value)
+		}
+		if entry.err == nil {
+			entry.err = entry.params.validate()
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// csvUntext reverts csvText
+func csvUntext(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
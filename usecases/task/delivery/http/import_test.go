@@ -0,0 +1,291 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/ratelimit"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// POST /v1/tasks/import
+func TestImportTaskSuite(t *testing.T) {
+	suite.Run(t, new(importTaskSuite))
+}
+
+type importTaskSuite struct {
+	suite.Suite
+	Router *gin.Engine
+	Ctx    context.Context
+}
+
+type importResp struct {
+	Code int             `json:"code"`
+	Data importTasksResp `json:"data"`
+}
+
+func (s *importTaskSuite) SetupSuite() {
+	// the token is the subject of the caller
+	scheme := middleware.AuthScheme{
+		Name: "Test",
+		Verify: func(ctx context.Context, token string) (*auth.Principal, *code.CustomError) {
+			return &auth.Principal{Subject: token, Role: auth.RoleEditor}, nil
+		},
+	}
+	s.Router = gin.Default()
+	s.Router.ContextWithFallback = true
+	NewTaskHandler(s.Router.Group("", middleware.Authenticate(scheme)))
+}
+
+func (s *importTaskSuite) SetupTest() {
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: _taskRepo.NewInMemoryTaskRepo(),
+	})
+	s.Ctx = context.Background()
+}
+
+// upload posts a file as the file field of a multipart form
+func (s *importTaskSuite) upload(query, fileName, contentType, content, token string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+fileName+`"`)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	part, err := writer.CreatePart(header)
+	s.NoError(err)
+	_, err = part.Write([]byte(content))
+	s.NoError(err)
+	s.NoError(writer.Close())
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", "/v1/tasks/import"+query, body)
+	s.NoError(err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Test "+token)
+	s.Router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *importTaskSuite) importFile(query, fileName, content string) *importTasksResp {
+	w := s.upload(query, fileName, "", content, "alice")
+	s.Equal(http.StatusOK, w.Code, w.Body.String())
+	resp := importResp{}
+	s.NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	return &resp.Data
+}
+
+func (s *importTaskSuite) TestCSV() {
+	resp := s.importFile("", "tasks.csv", strings.Join([]string{
+		"\ufeffExternal_ID,name,status,due_at",
+		"ext-1,write report,incomplete,2030-01-01T09:00:00Z",
+		"ext-2,'=SUM(A1),1,",
+		"ext-3,bad status,done,",
+		"ext-4,,0,",
+		"ext-5,bad due date,0,tomorrow",
+		"ext-1,repeated,0,",
+		",no external id,0,",
+		"ext-6,too many,0,,x",
+	}, "\n"))
+
+	s.Equal(3, resp.Created)
+	s.Equal(0, resp.Updated)
+	s.Equal(5, resp.Failed)
+	s.Len(resp.Rows, 8)
+	s.Equal(&importRowResp{Row: 1, ExternalID: "ext-1", Action: importActionCreate, TaskID: 1}, resp.Rows[0])
+	s.Equal(&importRowResp{Row: 2, ExternalID: "ext-2", Action: importActionCreate, TaskID: 2}, resp.Rows[1])
+	for _, row := range []*importRowResp{resp.Rows[2], resp.Rows[3], resp.Rows[4], resp.Rows[5], resp.Rows[7]} {
+		s.Equal(importActionFail, row.Action)
+		s.Equal(code.ParamIncorrect, row.Code)
+		s.NotEmpty(row.Error)
+	}
+	s.Contains(resp.Rows[5].Error, "repeated")
+	s.Equal(&importRowResp{Row: 7, Action: importActionCreate, TaskID: 3}, resp.Rows[6])
+
+	task, customErr := _taskUsecase.GetTask(s.Ctx, 1)
+	s.Nil(customErr)
+	s.Equal("write report", task.Name)
+	s.Equal("ext-1", task.ExternalID)
+	s.Equal(time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC), task.DueAt.UTC())
+	task, customErr = _taskUsecase.GetTask(s.Ctx, 2)
+	s.Nil(customErr)
+	s.Equal("=SUM(A1)", task.Name)
+	s.Equal(domain.TaskStatusCompleted, task.Status)
+
+	// the same external ids update the tasks
+	resp = s.importFile("", "tasks.csv", "name,status,external_id\nrenamed,completed,ext-1\nnew,0,ext-7\n")
+	s.Equal(1, resp.Created)
+	s.Equal(1, resp.Updated)
+	s.Equal(&importRowResp{Row: 1, ExternalID: "ext-1", Action: importActionUpdate, TaskID: 1}, resp.Rows[0])
+	task, customErr = _taskUsecase.GetTask(s.Ctx, 1)
+	s.Nil(customErr)
+	s.Equal("renamed", task.Name)
+	s.Equal(domain.TaskStatusCompleted, task.Status)
+	s.Nil(task.DueAt)
+	s.Equal(2, task.Version)
+}
+
+func (s *importTaskSuite) TestJSON() {
+	resp := s.importFile("", "tasks.json", `[
+		{"external_id": "ext-1", "name": "daily", "status": 0, "recurrence": {"frequency": "daily", "start_at": "2030-01-01T09:00:00Z"}},
+		{"external_id": "ext-2", "name": "bad status", "status": 3},
+		{"external_id": "ext-3", "status": 0},
+		{"external_id": "ext-4", "name": 1, "status": 0},
+		"not a task"
+	]`)
+	s.Equal(1, resp.Created)
+	s.Equal(4, resp.Failed)
+	s.Equal("ext-4", resp.Rows[3].ExternalID)
+	s.Equal(importActionFail, resp.Rows[4].Action)
+
+	task, customErr := _taskUsecase.GetTask(s.Ctx, 1)
+	s.Nil(customErr)
+	s.Equal(domain.RecurrenceFrequencyDaily, task.Recurrence.Frequency)
+	s.NotNil(task.DueAt)
+}
+
+func (s *importTaskSuite) TestRepeatedExternalID() {
+	resp := s.importFile("", "tasks.csv", "name,status,external_id\n,0,ext-0\nfirst,0,ext-1\nrepeated,0,ext-1\n")
+	s.Equal(1, resp.Created)
+	// the row of the file is named, the invalid rows before it count
+	s.Equal(code.ParamIncorrect, resp.Rows[2].Code)
+	s.Equal("external id ext-1 is repeated, see row 2 of the import", resp.Rows[2].Error)
+}
+
+func (s *importTaskSuite) TestCreateLimit() {
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo:      _taskRepo.NewInMemoryTaskRepo(),
+		CreateLimiter: ratelimit.NewInMemoryStore(),
+		CreateLimit:   ratelimit.Limit{Requests: 2, Period: time.Hour},
+	})
+
+	// an import counts once whatever its number of rows
+	rows := []string{"name,status,external_id"}
+	for i := 1; i <= 100; i++ {
+		rows = append(rows, fmt.Sprintf("task %d,0,ext-%d", i, i))
+	}
+	resp := s.importFile("", "tasks.csv", strings.Join(rows, "\n"))
+	s.Equal(100, resp.Created)
+	s.Equal(0, resp.Failed)
+
+	// a dry run doesn't count but reports the limit like the import
+	resp = s.importFile("?dry_run=true", "tasks.csv", "name,status,external_id\nrenamed,0,ext-1\n")
+	s.Equal(1, resp.Updated)
+	resp = s.importFile("", "tasks.csv", "name,status,external_id\nrenamed,0,ext-1\n")
+	s.Equal(1, resp.Updated)
+	for _, query := range []string{"?dry_run=true", ""} {
+		w := s.upload(query, "tasks.csv", "", "name,status,external_id\nrenamed,0,ext-1\n", "alice")
+		s.Equal(http.StatusTooManyRequests, w.Code, query)
+		s.Equal(code.TooManyRequests, testutil.ResponseCode(s.T(), w))
+	}
+}
+
+func (s *importTaskSuite) TestDryRun() {
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "blocker"}))
+	resp := s.importFile("", "tasks.csv", "name,status,external_id\nblocked,0,ext-1\n")
+	s.Equal(1, resp.Created)
+	s.Nil(_taskUsecase.AddDependency(s.Ctx, 2, 1))
+
+	resp = s.importFile("?dry_run=true", "tasks.csv", "name,status,external_id\nblocked,completed,ext-1\nrenamed,0,ext-1\nnew,0,ext-2\n")
+	s.True(resp.DryRun)
+	s.Equal(1, resp.Created)
+	s.Equal(0, resp.Updated)
+	s.Equal(2, resp.Failed)
+	s.Equal(code.BlockedByIncomplete, resp.Rows[0].Code)
+	s.Contains(resp.Rows[1].Error, "repeated")
+	s.Equal(&importRowResp{Row: 3, ExternalID: "ext-2", Action: importActionCreate}, resp.Rows[2])
+
+	resp = s.importFile("?dry_run=true", "tasks.csv", "name,status,external_id\nrenamed,0,ext-1\n")
+	s.Equal(&importRowResp{Row: 1, ExternalID: "ext-1", Action: importActionUpdate, TaskID: 2}, resp.Rows[0])
+
+	// nothing is written
+	tasks, customErr := _taskUsecase.GetTasks(s.Ctx)
+	s.Nil(customErr)
+	s.Len(tasks, 2)
+	task, customErr := _taskUsecase.GetTask(s.Ctx, 2)
+	s.Nil(customErr)
+	s.Equal("blocked", task.Name)
+}
+
+func (s *importTaskSuite) TestTrashedExternalID() {
+	resp := s.importFile("", "tasks.csv", "name,status,external_id\ntrashed,0,ext-1\n")
+	s.Equal(1, resp.Created)
+	s.Nil(_taskUsecase.DeleteTask(s.Ctx, 1))
+
+	// the dry run reports the conflict of the import
+	for _, query := range []string{"?dry_run=true", ""} {
+		resp = s.importFile(query, "tasks.csv", "name,status,external_id\nagain,0,ext-1\n")
+		s.Equal(1, resp.Failed, query)
+		s.Equal(code.Conflict, resp.Rows[0].Code, query)
+		s.Equal("external id ext-1 is used by a task in the trash", resp.Rows[0].Error, query)
+	}
+}
+
+func (s *importTaskSuite) TestOwnership() {
+	w := s.upload("", "tasks.csv", "", "name,status,external_id\nmine,0,ext-1\n", "alice")
+	s.Equal(http.StatusOK, w.Code)
+
+	w = s.upload("", "tasks.csv", "", "name,status,external_id\ntheirs,0,ext-1\n", "bob")
+	s.Equal(http.StatusOK, w.Code)
+	resp := importResp{}
+	s.NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	s.Equal(1, resp.Data.Failed)
+	s.Equal(code.NotFound, resp.Data.Rows[0].Code)
+
+	task, customErr := _taskUsecase.GetTask(s.Ctx, 1)
+	s.Nil(customErr)
+	s.Equal("mine", task.Name)
+	s.Equal("alice", task.OwnerID)
+}
+
+func (s *importTaskSuite) TestFileErrors() {
+	// the content type wins over the extension
+	s.Equal(http.StatusOK, s.upload("", "tasks.txt", "text/csv", "name,status\na,0\n", "alice").Code)
+	s.Equal(http.StatusUnsupportedMediaType, s.upload("", "tasks.txt", "", "name,status\na,0\n", "alice").Code)
+	s.Equal(http.StatusBadRequest, s.upload("", "tasks.csv", "", "name,status,owner_id\na,0,bob\n", "alice").Code)
+	s.Equal(http.StatusBadRequest, s.upload("", "tasks.csv", "", "name,external_id\na,ext-1\n", "alice").Code)
+	s.Equal(http.StatusBadRequest, s.upload("", "tasks.csv", "", "", "alice").Code)
+	s.Equal(http.StatusBadRequest, s.upload("", "tasks.json", "", `{"name": "a", "status": 0}`, "alice").Code)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", "/v1/tasks/import", strings.NewReader(`[]`))
+	s.NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Test alice")
+	s.Router.ServeHTTP(w, req)
+	s.Equal(http.StatusBadRequest, w.Code)
+
+	defer func(size int64, rows int) {
+		maxImportSize, maxImportRows = size, rows
+	}(maxImportSize, maxImportRows)
+	maxImportRows = 1
+	s.Equal(http.StatusBadRequest, s.upload("", "tasks.csv", "", "name,status\na,0\nb,0\n", "alice").Code)
+	s.Equal(http.StatusBadRequest, s.upload("", "tasks.json", "", `[{"name": "a", "status": 0}, {"name": "b", "status": 0}]`, "alice").Code)
+	maxImportSize = 64
+	w = s.upload("", "tasks.csv", "", "name,status\n"+strings.Repeat("a", 100)+",0\n", "alice")
+	s.Equal(http.StatusRequestEntityTooLarge, w.Code)
+	s.Equal(code.RequestTooLarge, testutil.ResponseCode(s.T(), w))
+
+	tasks, customErr := _taskUsecase.GetTasks(s.Ctx)
+	s.Nil(customErr)
+	s.Len(tasks, 1)
+}
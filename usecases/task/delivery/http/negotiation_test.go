@@ -0,0 +1,174 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/media"
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/api/pb"
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// YAML, MessagePack and Protobuf bodies
+func TestNegotiationSuite(t *testing.T) {
+	suite.Run(t, new(negotiationSuite))
+}
+
+type negotiationSuite struct {
+	suite.Suite
+	Router *gin.Engine
+	Ctx    context.Context
+}
+
+func (s *negotiationSuite) SetupSuite() {
+	s.Router = gin.Default()
+	NewTaskHandler(s.Router.Group("", middleware.LimitBody(maxTestBodySize)))
+}
+
+func (s *negotiationSuite) SetupTest() {
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: _taskRepo.NewInMemoryTaskRepo(),
+	})
+	s.Ctx = context.Background()
+}
+
+func (s *negotiationSuite) request(method, url, contentType, accept string, body []byte) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	s.NoError(err)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	s.Router.ServeHTTP(w, req)
+	return w
+}
+
+type taskResp struct {
+	Code int         `json:"code"`
+	Data domain.Task `json:"data"`
+}
+
+func (s *negotiationSuite) TestYAML() {
+	w := s.request("POST", "/v1/tasks", "application/x-yaml", media.MIMEYAML,
+		[]byte("name: yaml task\nstatus: 1\ndue_at: 2030-01-01T09:00:00Z\n"))
+	s.Equal(http.StatusOK, w.Code, w.Body.String())
+	s.Equal("application/yaml; charset=utf-8", w.Header().Get("Content-Type"))
+	s.Equal("Accept", w.Header().Get("Vary"))
+
+	resp := taskResp{}
+	s.NoError(media.Unmarshal(media.MIMEYAML, w.Body.Bytes(), &resp))
+	s.Equal("yaml task", resp.Data.Name)
+	s.Equal(domain.TaskStatusCompleted, resp.Data.Status)
+	s.Contains(w.Body.String(), "due_at: \"2030-01-01T09:00:00Z\"")
+
+	// the validation of the json body applies
+	w = s.request("POST", "/v1/tasks", media.MIMEYAML, media.MIMEYAML, []byte("name: missing status\n"))
+	s.Equal(http.StatusBadRequest, w.Code)
+	errResp := response.ErrorResp{}
+	s.NoError(media.Unmarshal(media.MIMEYAML, w.Body.Bytes(), &errResp))
+	s.Equal(code.ParamIncorrect, errResp.Code)
+}
+
+func (s *negotiationSuite) TestMsgPack() {
+	body, err := media.Marshal(media.MIMEMsgPack, map[string]interface{}{"name": "msgpack task", "status": 0})
+	s.NoError(err)
+	w := s.request("POST", "/v1/tasks", media.MIMEMsgPack, "application/x-msgpack", body)
+	s.Equal(http.StatusOK, w.Code, w.Body.String())
+	s.Equal(media.MIMEMsgPack, w.Header().Get("Content-Type"))
+
+	resp := taskResp{}
+	s.NoError(media.Unmarshal(media.MIMEMsgPack, w.Body.Bytes(), &resp))
+	s.Equal(1, resp.Data.ID)
+	s.Equal("msgpack task", resp.Data.Name)
+}
+
+func (s *negotiationSuite) TestProtobuf() {
+	body, err := proto.Marshal(&pb.Task{Name: "proto task", Status: proto.Int32(0)})
+	s.NoError(err)
+	w := s.request("POST", "/v1/tasks", media.MIMEProtobuf, media.MIMEProtobuf, body)
+	s.Equal(http.StatusOK, w.Code, w.Body.String())
+	s.Equal(media.MIMEProtobuf, w.Header().Get("Content-Type"))
+
+	resp := &pb.OKResponse{}
+	s.NoError(proto.Unmarshal(w.Body.Bytes(), resp))
+	s.Equal(int32(1), resp.GetTask().GetId())
+	s.Equal("proto task", resp.GetTask().GetName())
+	s.Equal(int32(0), resp.GetTask().GetStatus())
+
+	// the status is required, 0 included
+	body, err = proto.Marshal(&pb.Task{Name: "no status"})
+	s.NoError(err)
+	w = s.request("POST", "/v1/tasks", media.MIMEProtobuf, media.MIMEProtobuf, body)
+	s.Equal(http.StatusBadRequest, w.Code)
+	errResp := &pb.ErrorResponse{}
+	s.NoError(proto.Unmarshal(w.Body.Bytes(), errResp))
+	s.Equal(int32(code.ParamIncorrect), errResp.Code)
+	s.Equal(int32(http.StatusBadRequest), errResp.Status)
+
+	w = s.request("GET", "/v1/tasks", "", "application/protobuf", nil)
+	s.Equal(http.StatusOK, w.Code)
+	resp = &pb.OKResponse{}
+	s.NoError(proto.Unmarshal(w.Body.Bytes(), resp))
+	s.Len(resp.GetTasks().GetTasks(), 1)
+}
+
+func (s *negotiationSuite) TestUnsupported() {
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "task"}))
+
+	w := s.request("GET", "/v1/tasks", "", "text/html", nil)
+	s.Equal(http.StatusNotAcceptable, w.Code)
+	errResp := response.ErrorResp{}
+	s.NoError(json.Unmarshal(w.Body.Bytes(), &errResp))
+	s.Equal(code.NotAcceptable, errResp.Code)
+
+	// only the tasks have a protobuf message
+	w = s.request("GET", "/v1/audit", "", media.MIMEProtobuf, nil)
+	s.Equal(http.StatusNotAcceptable, w.Code)
+	pbErrResp := &pb.ErrorResponse{}
+	s.NoError(proto.Unmarshal(w.Body.Bytes(), pbErrResp))
+	s.Equal(int32(code.NotAcceptable), pbErrResp.Code)
+
+	w = s.request("POST", "/v1/tasks", "text/plain", "", []byte(`{"name": "task", "status": 0}`))
+	s.Equal(http.StatusUnsupportedMediaType, w.Code)
+	s.NoError(json.Unmarshal(w.Body.Bytes(), &errResp))
+	s.Equal(code.UnsupportedMediaType, errResp.Code)
+
+	w = s.request("POST", "/v1/tasks/1/dependencies", media.MIMEProtobuf, "", []byte{})
+	s.Equal(http.StatusUnsupportedMediaType, w.Code)
+
+	// q values are followed
+	w = s.request("GET", "/v1/tasks", "", "application/json;q=0.5, application/yaml", nil)
+	s.Equal("application/yaml; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func (s *negotiationSuite) TestTooLarge() {
+	name := strings.Repeat("a", maxTestBodySize)
+	for contentType, body := range map[string]string{
+		media.MIMEJSON: `{"name": "` + name + `", "status": 0}`,
+		media.MIMEYAML: "name: " + name + "\nstatus: 0\n",
+	} {
+		req := httptest.NewRequest("POST", "/v1/tasks", strings.NewReader(body))
+		req.ContentLength = -1
+		w := testutil.Serve(s.Router, req, "Content-Type", contentType)
+		s.Equal(http.StatusRequestEntityTooLarge, w.Code, contentType)
+		s.Equal(code.RequestTooLarge, testutil.ResponseCode(s.T(), w), contentType)
+	}
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sse"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/openapi"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+)
+
+// the path parameters, for the documentation
+type taskIDParams struct {
+	ID int `uri:"id"`
+}
+
+type dependencyIDParams struct {
+	taskIDParams
+	BlockerID int `uri:"blocker_id"`
+}
+
+type taskHistoryParams struct {
+	taskIDParams
+	paginationParams
+}
+
+type occurrencesParams struct {
+	taskIDParams
+	getOccurrencesParams
+}
+
+// importFile is the multipart form of ImportTasks
+type importFile struct {
+	File string `json:"file" binding:"required" format:"binary"`
+}
+
+type taskHistoryPage struct {
+	pageResp
+	Items []*domain.TaskHistory `json:"items"`
+}
+
+// OpenAPIRoutes documents the routes of NewTaskHandler
+func OpenAPIRoutes() []*openapi.Route {
+	return []*openapi.Route{
+		{Method: http.MethodGet, Path: "/v1/tasks", Summary: "List the tasks",
+			Params: getTasksParams{}, Response: []*domain.Task{}, Raw: map[string]interface{}{util.MIMENDJSON: domain.Task{}},
+			Statuses: []int{http.StatusNotModified}},
+		{Method: http.MethodPost, Path: "/v1/tasks", Summary: "Create a task", Body: &createTaskParams{}, Response: &domain.Task{}},
+		{Method: http.MethodGet, Path: "/v1/tasks/export.csv", Summary: "Export the tasks as a csv file",
+			Params: exportTasksParams{}, Raw: map[string]interface{}{"text/csv": nil}},
+		{Method: http.MethodPost, Path: "/v1/tasks/import", Summary: "Import the tasks of a csv or json file",
+			Params: importTasksParams{}, Body: importFile{}, BodyTypes: []string{"multipart/form-data"}, Response: importTasksResp{}},
+		{Method: http.MethodGet, Path: "/v1/tasks/events", Summary: "Stream the changes of the tasks as server-sent events",
+			Params: streamTaskEventsParams{}, Raw: map[string]interface{}{sse.ContentType: nil}},
+		{Method: http.MethodGet, Path: "/v1/ws", Summary: "Upgrade to a WebSocket taking task commands",
+			Status: http.StatusSwitchingProtocols},
+		{Method: http.MethodPut, Path: "/v1/tasks/:id", Summary: "Replace a task",
+			Params: taskIDParams{}, Body: &createTaskParams{}, Response: &domain.Task{}},
+		{Method: http.MethodPatch, Path: "/v1/tasks/:id", Summary: "Patch a task with a merge patch or a json patch",
+			Params: taskIDParams{}, BodyTypes: []string{MIMEMergePatch, MIMEJSONPatch}, Response: &domain.Task{}},
+		{Method: http.MethodDelete, Path: "/v1/tasks/:id", Summary: "Move a task to the trash", Params: taskIDParams{}},
+
+		{Method: http.MethodGet, Path: "/v1/tasks/:id/dependencies", Summary: "List the tasks blocking a task",
+			Params: taskIDParams{}, Response: []*domain.Task{}},
+		{Method: http.MethodPost, Path: "/v1/tasks/:id/dependencies", Summary: "Block a task by another task",
+			Params: taskIDParams{}, Body: addDependencyParams{}, Response: addDependencyParams{}},
+		{Method: http.MethodDelete, Path: "/v1/tasks/:id/dependencies/:blocker_id", Summary: "Unblock a task",
+			Params: dependencyIDParams{}},
+
+		{Method: http.MethodGet, Path: "/v1/tasks/:id/occurrences", Summary: "List the next occurrences of a recurring task",
+			Params: occurrencesParams{}, Response: []time.Time{}},
+
+		{Method: http.MethodPost, Path: "/v1/tasks/:id/restore", Summary: "Restore a task from the trash", Params: taskIDParams{}},
+		{Method: http.MethodGet, Path: "/v1/trash", Summary: "List the tasks in the trash", Response: []*domain.Task{}},
+		{Method: http.MethodDelete, Path: "/v1/trash/:id", Summary: "Purge a task from the trash", Params: taskIDParams{}},
+
+		{Method: http.MethodGet, Path: "/v1/tasks/:id/history", Summary: "List the changes of a task",
+			Params: taskHistoryParams{}, Response: taskHistoryPage{}},
+		{Method: http.MethodGet, Path: "/v1/audit", Summary: "List the changes of all tasks",
+			Params: getAuditLogsParams{}, Response: taskHistoryPage{}},
+
+		{Method: http.MethodGet, Path: "/v1/tasks/:id/versions", Summary: "List the versions of a task",
+			Params: taskIDParams{}, Response: []*domain.TaskVersion{}},
+		{Method: http.MethodPost, Path: "/v1/tasks/:id/revert", Summary: "Revert a task to a version",
+			Params: taskIDParams{}, Body: revertTaskParams{}, Response: []*domain.TaskFieldDiff{}},
+	}
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/Yu-Qi/restful_api/usecases/task/usecase"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+)
+
+// media types of the patch documents
+const (
+	MIMEMergePatch = "application/merge-patch+json"
+	MIMEJSONPatch  = "application/json-patch+json"
+)
+
+// PatchTask apply a JSON Merge Patch (RFC 7386) or a JSON Patch (RFC 6902) to the JSON of a task
+func (t *TaskHandler) PatchTask(ctx *gin.Context) {
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		customErr := code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		response.CustomError(ctx, customErr)
+		return
+	}
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		response.CustomError(ctx, util.BodyError(err))
+		return
+	}
+
+	var apply func(doc []byte) ([]byte, error)
+	mediaType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+	switch mediaType {
+	case MIMEMergePatch:
+		if !json.Valid(body) {
+			customErr := code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, fmt.Errorf("merge patch is not valid json"))
+			response.CustomError(ctx, customErr)
+			return
+		}
+		apply = func(doc []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(doc, body)
+		}
+	case MIMEJSONPatch:
+		patch, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			customErr := code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+			response.CustomError(ctx, customErr)
+			return
+		}
+		apply = patch.Apply
+	default:
+		customErr := code.NewCustomError(code.UnsupportedMediaType, http.StatusUnsupportedMediaType,
+			fmt.Errorf("content type must be %s or %s", MIMEMergePatch, MIMEJSONPatch))
+		ctx.Header("Accept-Patch", MIMEMergePatch+", "+MIMEJSONPatch)
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	patched, customErr := usecase.PatchTask(ctx, taskID, func(current *domain.Task) (*domain.Task, *code.CustomError) {
+		return patchTask(current, apply)
+	})
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, patched)
+}
+
+// patchTask applies a patch to the JSON of a task and validates the result
+func patchTask(current *domain.Task, apply func(doc []byte) ([]byte, error)) (*domain.Task, *code.CustomError) {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return nil, code.NewCustomError(code.InternalUnknownError, http.StatusInternalServerError, err)
+	}
+	doc, err = apply(doc)
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		return nil, code.NewCustomError(code.PatchFailed, http.StatusConflict, err)
+	}
+	if err != nil {
+		return nil, code.NewCustomError(code.PatchFailed, http.StatusUnprocessableEntity, err)
+	}
+
+	patched := &domain.Task{}
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(patched); err != nil {
+		return nil, code.NewCustomError(code.PatchFailed, http.StatusUnprocessableEntity, err)
+	}
+
+	readOnly := map[string][2]interface{}{
+		"id":          {current.ID, patched.ID},
+		"version":     {current.Version, patched.Version},
+		"owner_id":    {current.OwnerID, patched.OwnerID},
+		"external_id": {current.ExternalID, patched.ExternalID},
+		"deleted_at":  {current.DeletedAt, patched.DeletedAt},
+	}
+	for field, values := range readOnly {
+		if !reflect.DeepEqual(values[0], values[1]) {
+			return nil, code.NewCustomError(code.PatchFailed, http.StatusUnprocessableEntity, fmt.Errorf("%s is read-only", field))
+		}
+	}
+
+	if customErr := usecase.ValidateTask(patched); customErr != nil {
+		return nil, code.NewCustomError(code.PatchFailed, http.StatusUnprocessableEntity, customErr.Error)
+	}
+
+	return patched, nil
+}
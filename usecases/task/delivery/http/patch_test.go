@@ -0,0 +1,145 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// PATCH /v1/tasks/:id and PUT as a full replacement
+func TestPatchTaskSuite(t *testing.T) {
+	suite.Run(t, new(patchTaskSuite))
+}
+
+type patchTaskSuite struct {
+	suite.Suite
+	Router *gin.Engine
+	Ctx    context.Context
+	DueAt  time.Time
+}
+
+func (s *patchTaskSuite) SetupSuite() {
+	s.Router = gin.Default()
+	NewTaskHandler(s.Router.Group("", middleware.LimitBody(maxTestBodySize)))
+}
+
+func (s *patchTaskSuite) SetupTest() {
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: _taskRepo.NewInMemoryTaskRepo(),
+	})
+	s.Ctx = context.Background()
+	s.DueAt = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "task", DueAt: util.Ptr(s.DueAt)}))
+}
+
+func (s *patchTaskSuite) send(method, contentType, body string) *httptest.ResponseRecorder {
+	return testutil.Serve(s.Router, httptest.NewRequest(method, "/v1/tasks/1", strings.NewReader(body)), "Content-Type", contentType)
+}
+
+func (s *patchTaskSuite) getTask() *domain.Task {
+	task, customErr := _taskUsecase.GetTask(s.Ctx, 1)
+	s.Nil(customErr)
+	return task
+}
+
+func (s *patchTaskSuite) TestMergePatch() {
+	w := s.send("PATCH", MIMEMergePatch, `{"name": "renamed"}`)
+	s.Equal(http.StatusOK, w.Code)
+	var response struct {
+		Data *domain.Task `json:"data"`
+	}
+	s.Nil(json.Unmarshal(w.Body.Bytes(), &response))
+	s.Equal("renamed", response.Data.Name)
+	s.Equal(2, response.Data.Version)
+	s.True(s.DueAt.Equal(*response.Data.DueAt))
+
+	// null removes a field
+	s.Equal(http.StatusOK, s.send("PATCH", MIMEMergePatch+"; charset=utf-8", `{"due_at": null, "status": 1}`).Code)
+	task := s.getTask()
+	s.Equal("renamed", task.Name)
+	s.Equal(domain.TaskStatusCompleted, task.Status)
+	s.Nil(task.DueAt)
+
+	s.Equal(http.StatusBadRequest, s.send("PATCH", MIMEMergePatch, `{"name": `).Code)
+}
+
+func (s *patchTaskSuite) TestJSONPatch() {
+	w := s.send("PATCH", MIMEJSONPatch, `[
+		{"op": "test", "path": "/name", "value": "task"},
+		{"op": "replace", "path": "/name", "value": "renamed"},
+		{"op": "remove", "path": "/due_at"}
+	]`)
+	s.Equal(http.StatusOK, w.Code)
+	task := s.getTask()
+	s.Equal("renamed", task.Name)
+	s.Nil(task.DueAt)
+
+	// a failed test rejects the whole patch
+	w = s.send("PATCH", MIMEJSONPatch, `[
+		{"op": "replace", "path": "/status", "value": 1},
+		{"op": "test", "path": "/name", "value": "task"}
+	]`)
+	s.Equal(http.StatusConflict, w.Code)
+	s.Equal(code.PatchFailed, testutil.ResponseCode(s.T(), w))
+	s.Equal(domain.TaskStatusIncomplete, s.getTask().Status)
+	s.Equal(2, s.getTask().Version)
+
+	s.Equal(http.StatusBadRequest, s.send("PATCH", MIMEJSONPatch, `{"op": "remove"}`).Code)
+	s.Equal(http.StatusUnprocessableEntity, s.send("PATCH", MIMEJSONPatch, `[{"op": "remove", "path": "/unknown"}]`).Code)
+}
+
+func (s *patchTaskSuite) TestInvalidPatch() {
+	for _, body := range []string{
+		`{"id": 2}`,
+		`{"version": 5}`,
+		`{"owner_id": "mallory"}`,
+		`{"name": null}`,
+		`{"status": 2}`,
+		`{"priority": 1}`,
+		`{"recurrence": {"frequency": "hourly", "start_at": "2030-01-01T00:00:00Z"}}`,
+	} {
+		w := s.send("PATCH", MIMEMergePatch, body)
+		s.Equal(http.StatusUnprocessableEntity, w.Code, body)
+		s.Equal(code.PatchFailed, testutil.ResponseCode(s.T(), w), body)
+	}
+	s.Equal(1, s.getTask().Version)
+
+	w := s.send("PATCH", "application/json", `{"name": "renamed"}`)
+	s.Equal(http.StatusUnsupportedMediaType, w.Code)
+	s.Equal(code.UnsupportedMediaType, testutil.ResponseCode(s.T(), w))
+	s.Contains(w.Header().Get("Accept-Patch"), MIMEMergePatch)
+}
+
+func (s *patchTaskSuite) TestPatchTooLarge() {
+	req := httptest.NewRequest("PATCH", "/v1/tasks/1", strings.NewReader(`{"name": "`+strings.Repeat("a", maxTestBodySize)+`"}`))
+	req.ContentLength = -1
+	w := testutil.Serve(s.Router, req, "Content-Type", MIMEMergePatch)
+	s.Equal(http.StatusRequestEntityTooLarge, w.Code)
+	s.Equal(code.RequestTooLarge, testutil.ResponseCode(s.T(), w))
+	s.Equal("task", s.getTask().Name)
+}
+
+func (s *patchTaskSuite) TestPutReplacesTask() {
+	s.Equal(http.StatusBadRequest, s.send("PUT", "application/json", `{"name": "renamed"}`).Code)
+
+	s.Equal(http.StatusOK, s.send("PUT", "application/json", `{"name": "renamed", "status": 1}`).Code)
+	task := s.getTask()
+	s.Equal("renamed", task.Name)
+	s.Equal(domain.TaskStatusCompleted, task.Status)
+	s.Nil(task.DueAt)
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/ratelimit"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+func TestRateLimitSuite(t *testing.T) {
+	suite.Run(t, new(rateLimitSuite))
+}
+
+type rateLimitSuite struct {
+	suite.Suite
+	Router *gin.Engine
+}
+
+func (s *rateLimitSuite) SetupTest() {
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: _taskRepo.NewInMemoryTaskRepo(),
+	})
+
+	rules, err := ratelimit.ParseRules("POST /v1/tasks=2/1h")
+	s.NoError(err)
+	s.Router = gin.Default()
+	NewTaskHandler(s.Router.Group("", middleware.RateLimit(ratelimit.NewInMemoryStore(), rules)))
+}
+
+func (s *rateLimitSuite) serve(method, url, clientIP string) *httptest.ResponseRecorder {
+	req := testutil.NewJSONRequest(s.T(), method, url, map[string]interface{}{"name": "task", "status": 0})
+	req.RemoteAddr = clientIP + ":1234"
+	return testutil.Serve(s.Router, req)
+}
+
+func (s *rateLimitSuite) TestRateLimit() {
+	w := s.serve("POST", "/v1/tasks", "10.0.0.1")
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal("2", w.Header().Get("RateLimit-Limit"))
+	s.Equal("1", w.Header().Get("RateLimit-Remaining"))
+	s.Equal(strconv.Itoa(30*60), w.Header().Get("RateLimit-Reset"))
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks", "10.0.0.1").Code)
+
+	w = s.serve("POST", "/v1/tasks", "10.0.0.1")
+	s.Equal(http.StatusTooManyRequests, w.Code)
+	s.Equal("0", w.Header().Get("RateLimit-Remaining"))
+	s.Equal(strconv.Itoa(30*60), w.Header().Get("Retry-After"))
+	var response struct {
+		Code int `json:"code"`
+	}
+	s.Nil(json.Unmarshal(w.Body.Bytes(), &response))
+	s.Equal(code.TooManyRequests, response.Code)
+
+	// other clients and routes without a rule are not affected
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks", "10.0.0.2").Code)
+	w = s.serve("GET", "/v1/tasks", "10.0.0.1")
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal("", w.Header().Get("RateLimit-Limit"))
+
+	tasks, customErr := _taskUsecase.GetTasks(context.Background())
+	s.Nil(customErr)
+	s.Equal(3, len(tasks))
+}
+
+func (s *rateLimitSuite) TestFailedAuthLimit() {
+	scheme := middleware.AuthScheme{
+		Name: "Bearer",
+		Verify: func(ctx context.Context, token string) (*auth.Principal, *code.CustomError) {
+			if token != "good" {
+				return nil, code.NewCustomError(code.Unauthorized, http.StatusUnauthorized, fmt.Errorf("invalid token"))
+			}
+			return &auth.Principal{Subject: "alice"}, nil
+		},
+	}
+	router := gin.Default()
+	router.ContextWithFallback = true
+	NewTaskHandler(router.Group("",
+		middleware.LimitFailedAuth(ratelimit.NewInMemoryStore(), ratelimit.Limit{Requests: 2, Period: time.Hour}),
+		middleware.Authenticate(scheme),
+	))
+	serve := func(token, clientIP string) *httptest.ResponseRecorder {
+		req := testutil.NewJSONRequest(s.T(), "GET", "/v1/tasks", nil)
+		req.RemoteAddr = clientIP + ":1234"
+		return testutil.Serve(router, req, "Authorization", testutil.Bearer(token))
+	}
+
+	s.Equal(http.StatusOK, serve("good", "10.0.0.1").Code)
+	s.Equal(http.StatusUnauthorized, serve("guess-1", "10.0.0.1").Code)
+	s.Equal(http.StatusUnauthorized, serve("", "10.0.0.1").Code)
+
+	// the ip is locked out, even with valid credentials
+	w := serve("guess-3", "10.0.0.1")
+	s.Equal(http.StatusTooManyRequests, w.Code)
+	s.Equal(code.TooManyRequests, testutil.ResponseCode(s.T(), w))
+	s.Equal(strconv.Itoa(30*60), w.Header().Get("Retry-After"))
+	s.Equal(http.StatusTooManyRequests, serve("good", "10.0.0.1").Code)
+
+	// the successful authentications are not counted
+	for i := 0; i < 3; i++ {
+		s.Equal(http.StatusOK, serve("good", "10.0.0.2").Code)
+	}
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/Yu-Qi/restful_api/usecases/task/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+type getOccurrencesParams struct {
+	N int `form:"n" binding:"omitempty,min=1,max=100"`
+}
+
+// GetOccurrences preview the next occurrences of a recurring task
+func (t *TaskHandler) GetOccurrences(ctx *gin.Context) {
+	params := getOccurrencesParams{}
+	customErr := util.ToGinContextExt(ctx).BindQuery(&params)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	if params.N == 0 {
+		params.N = 5
+	}
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		customErr = code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	occurrences, customErr := usecase.GetNextOccurrences(ctx, taskID, params.N)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, occurrences)
+}
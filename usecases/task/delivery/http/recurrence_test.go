@@ -0,0 +1,176 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// recurring tasks
+func TestRecurrenceSuite(t *testing.T) {
+	suite.Run(t, new(recurrenceSuite))
+}
+
+type recurrenceSuite struct {
+	suite.Suite
+	Router *gin.Engine
+	Ctx    context.Context
+}
+
+func (s *recurrenceSuite) SetupSuite() {
+	s.Router = gin.Default()
+	NewTaskHandler(s.Router.Group(""))
+}
+
+func (s *recurrenceSuite) SetupTest() {
+	taskRepo := _taskRepo.NewInMemoryTaskRepo()
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: taskRepo,
+	})
+
+	s.Ctx = context.Background()
+}
+
+func (s *recurrenceSuite) do(method, url string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Buffer
+	if body != nil {
+		jsonStr, err := json.Marshal(body)
+		s.NoError(err)
+		reader = bytes.NewBuffer(jsonStr)
+	} else {
+		reader = bytes.NewBuffer(nil)
+	}
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(method, url, reader)
+	s.NoError(err)
+	if method == http.MethodPatch {
+		req.Header.Set("Content-Type", MIMEMergePatch)
+	}
+	s.Router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *recurrenceSuite) TestCompleteCreatesNextOccurrence() {
+	w := s.do("POST", "/v1/tasks", map[string]interface{}{
+		"name":   "water plants",
+		"status": 0,
+		"recurrence": map[string]interface{}{
+			"frequency":  "weekly",
+			"by_weekday": []string{"MO", "TH"},
+			"start_at":   "2024-01-01T09:00:00Z", // monday
+			"count":      3,
+		},
+	})
+	s.Equal(http.StatusOK, w.Code)
+
+	var created struct {
+		Data struct {
+			ID    int       `json:"id"`
+			DueAt time.Time `json:"due_at"`
+		} `json:"data"`
+	}
+	s.Nil(json.Unmarshal(w.Body.Bytes(), &created))
+	s.Equal("2024-01-01T09:00:00Z", created.Data.DueAt.Format(time.RFC3339))
+
+	w = s.do("GET", "/v1/tasks/1/occurrences?n=5", nil)
+	s.Equal(http.StatusOK, w.Code)
+	var preview struct {
+		Data []time.Time `json:"data"`
+	}
+	s.Nil(json.Unmarshal(w.Body.Bytes(), &preview))
+	s.Equal(2, len(preview.Data)) // count is 3, including the first one
+	s.Equal("2024-01-04T09:00:00Z", preview.Data[0].Format(time.RFC3339))
+	s.Equal("2024-01-08T09:00:00Z", preview.Data[1].Format(time.RFC3339))
+
+	s.Equal(http.StatusOK, s.do("PATCH", "/v1/tasks/1", map[string]interface{}{"status": 1}).Code)
+	tasks, customErr := _taskUsecase.GetTasks(s.Ctx)
+	s.Nil(customErr)
+	s.Equal(2, len(tasks))
+
+	next, customErr := _taskUsecase.GetTask(s.Ctx, 2)
+	s.Nil(customErr)
+	s.Equal("water plants", next.Name)
+	s.Equal(0, int(next.Status))
+	s.Equal("2024-01-04T09:00:00Z", next.DueAt.Format(time.RFC3339))
+
+	// completing an already completed task does not generate another occurrence
+	s.Equal(http.StatusOK, s.do("PATCH", "/v1/tasks/1", map[string]interface{}{"status": 1}).Code)
+	tasks, customErr = _taskUsecase.GetTasks(s.Ctx)
+	s.Nil(customErr)
+	s.Equal(2, len(tasks))
+
+	// the series ends after the third occurrence
+	s.Equal(http.StatusOK, s.do("PATCH", "/v1/tasks/2", map[string]interface{}{"status": 1}).Code)
+	s.Equal(http.StatusOK, s.do("PATCH", "/v1/tasks/3", map[string]interface{}{"status": 1}).Code)
+	tasks, customErr = _taskUsecase.GetTasks(s.Ctx)
+	s.Nil(customErr)
+	s.Equal(3, len(tasks))
+}
+
+func (s *recurrenceSuite) TestConcurrentCompletion() {
+	s.Equal(http.StatusOK, s.do("POST", "/v1/tasks", map[string]interface{}{
+		"name":       "water plants",
+		"status":     0,
+		"recurrence": map[string]interface{}{"frequency": "daily", "start_at": "2024-01-01T09:00:00Z"},
+	}).Code)
+
+	// the task is completed once, so a single next occurrence is created
+	var workers sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			<-start
+			s.Nil(_taskUsecase.UpdateTask(s.Ctx, &domain.UpdateTaskParams{ID: 1, Status: util.Ptr(domain.TaskStatusCompleted)}))
+		}()
+	}
+	close(start)
+	workers.Wait()
+
+	tasks, customErr := _taskUsecase.GetTasks(s.Ctx)
+	s.Nil(customErr)
+	s.Equal(2, len(tasks))
+}
+
+func (s *recurrenceSuite) TestInvalidRecurrence() {
+	w := s.do("POST", "/v1/tasks", map[string]interface{}{
+		"name":   "test",
+		"status": 0,
+		"recurrence": map[string]interface{}{
+			"frequency": "yearly",
+			"start_at":  "2024-01-01T09:00:00Z",
+		},
+	})
+	s.Equal(http.StatusBadRequest, w.Code)
+
+	w = s.do("POST", "/v1/tasks", map[string]interface{}{
+		"name":   "test",
+		"status": 0,
+		"recurrence": map[string]interface{}{
+			"frequency": "daily",
+			"start_at":  "2024-01-01T09:00:00Z",
+			"until":     "2023-01-01T09:00:00Z",
+		},
+	})
+	s.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (s *recurrenceSuite) TestNotRecurring() {
+	s.Equal(http.StatusOK, s.do("POST", "/v1/tasks", map[string]interface{}{"name": "test", "status": 0}).Code)
+	s.Equal(http.StatusBadRequest, s.do("GET", "/v1/tasks/1/occurrences", nil).Code)
+}
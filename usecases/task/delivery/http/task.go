@@ -2,15 +2,22 @@ package http
 
 import (
 	"fmt"
+	"hash/fnv"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/media"
+	"github.com/Yu-Qi/restful_api/pkg/api/pb"
 	"github.com/Yu-Qi/restful_api/pkg/api/response"
 	"github.com/Yu-Qi/restful_api/pkg/code"
+	customlog "github.com/Yu-Qi/restful_api/pkg/custom_log"
 	"github.com/Yu-Qi/restful_api/pkg/util"
 	"github.com/Yu-Qi/restful_api/usecases/task/usecase"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/protobuf/proto"
 )
 
 // TaskHandler represent the http handler for tasks
@@ -23,13 +30,62 @@ func NewTaskHandler(r *gin.RouterGroup) {
 	handler := &TaskHandler{}
 	v1.GET("/tasks", handler.GetTasks)
 	v1.POST("/tasks", handler.CreateTask)
+	v1.GET("/tasks/export.csv", handler.ExportTasks)
+	v1.POST("/tasks/import", handler.ImportTasks)
+	v1.GET("/tasks/events", handler.StreamTaskEvents)
+	v1.GET("/ws", handler.ServeWebSocket)
 	v1.PUT("/tasks/:id", handler.UpdateTask)
+	v1.PATCH("/tasks/:id", handler.PatchTask)
 	v1.DELETE("/tasks/:id", handler.DeleteTask)
+
+	v1.GET("/tasks/:id/dependencies", handler.GetDependencies)
+	v1.POST("/tasks/:id/dependencies", handler.AddDependency)
+	v1.DELETE("/tasks/:id/dependencies/:blocker_id", handler.RemoveDependency)
+
+	v1.GET("/tasks/:id/occurrences", handler.GetOccurrences)
+
+	v1.POST("/tasks/:id/restore", handler.RestoreTask)
+	v1.GET("/trash", handler.GetTrash)
+	v1.DELETE("/trash/:id", handler.PurgeTask)
+
+	v1.GET("/tasks/:id/history", handler.GetTaskHistory)
+	v1.GET("/audit", handler.GetAuditLogs)
+
+	v1.GET("/tasks/:id/versions", handler.GetTaskVersions)
+	v1.POST("/tasks/:id/revert", handler.RevertTask)
+}
+
+type getTasksParams struct {
+	Blocked *bool `form:"blocked"`
 }
 
-// TaskHandler get all tasks
+// TaskHandler get all tasks, as newline delimited json when it is accepted.
+// The list carries the revision of the tasks as validators and is not sent again to a client holding it.
 func (t *TaskHandler) GetTasks(ctx *gin.Context) {
-	tasks, customErr := usecase.GetTasks(ctx)
+	params := getTasksParams{}
+	customErr := util.ToGinContextExt(ctx).BindQuery(&params)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	revision, customErr := usecase.GetTaskRevision(ctx)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	mediaType := media.Negotiate(ctx.GetHeader("Accept"), media.MIMEJSON, media.MIMEYAML, media.MIMEMsgPack, media.MIMEProtobuf, util.MIMENDJSON)
+	if util.NotModified(ctx, tasksETag(ctx, revision, &params, mediaType), revision.ModifiedAt) {
+		ctx.Writer.Header().Add("Vary", "Accept")
+		return
+	}
+
+	if mediaType == util.MIMENDJSON {
+		streamTasks(ctx, &params)
+		return
+	}
+
+	tasks, customErr := getTasks(ctx, &params)
 	if customErr != nil {
 		response.CustomError(ctx, customErr)
 		return
@@ -38,9 +94,95 @@ func (t *TaskHandler) GetTasks(ctx *gin.Context) {
 	response.OK(ctx, tasks)
 }
 
+// tasksETag is a weak validator of a list of the tasks, it changes with the revision of the tasks, the filters,
+// the tasks the caller can access and the media type of the list
+func tasksETag(ctx *gin.Context, revision *domain.TaskRevision, params *getTasksParams, mediaType string) string {
+	hash := fnv.New64a()
+	blocked := ""
+	if params.Blocked != nil {
+		blocked = strconv.FormatBool(*params.Blocked)
+	}
+	_, _ = fmt.Fprintf(hash, "blocked=%s\nscope=%s\nmedia=%s", blocked, usecase.AccessScope(ctx), mediaType)
+	return fmt.Sprintf(`W/"%x-%d-%x"`, revision.Epoch, revision.Revision, hash.Sum64())
+}
+
+// streamTasks write the tasks one per line as they are read, the blocked filter needs the whole graph
+// so its tasks are collected first
+func streamTasks(ctx *gin.Context, params *getTasksParams) {
+	writer := util.NewNDJSONWriter(ctx)
+	var writeErr error
+	var customErr *code.CustomError
+	if params.Blocked != nil {
+		var tasks []*domain.Task
+		tasks, customErr = usecase.GetTasksByBlocked(ctx, *params.Blocked)
+		for _, task := range tasks {
+			if writeErr = writer.Write(task); writeErr != nil {
+				break
+			}
+		}
+	} else {
+		customErr = usecase.RangeTasks(ctx, func(task *domain.Task) bool {
+			writeErr = writer.Write(task)
+			return writeErr == nil
+		})
+	}
+
+	switch {
+	case customErr != nil && !writer.Started():
+		response.CustomError(ctx, customErr)
+	case customErr != nil:
+		customlog.ErrorfCtx(ctx, "stream tasks: %v", customErr.Error)
+	case writeErr != nil:
+		customlog.ErrorfCtx(ctx, "stream tasks: %v", writeErr)
+	default:
+		writer.Close()
+	}
+}
+
+// getTasks get the tasks matching the filters of the list endpoint
+func getTasks(ctx *gin.Context, params *getTasksParams) ([]*domain.Task, *code.CustomError) {
+	if params.Blocked != nil {
+		return usecase.GetTasksByBlocked(ctx, *params.Blocked)
+	}
+	return usecase.GetTasks(ctx)
+}
+
 type createTaskParams struct {
-	Name   string             `json:"name" binding:"required"`
-	Status *domain.TaskStatus `json:"status" binding:"required"`
+	Name       string             `json:"name" binding:"required"`
+	Status     *domain.TaskStatus `json:"status" binding:"required"`
+	DueAt      *time.Time         `json:"due_at"`
+	Recurrence *domain.Recurrence `json:"recurrence"`
+}
+
+// AfterValidate checks the task like the other transports do
+func (p *createTaskParams) AfterValidate(binding.StructValidator) error {
+	if customErr := usecase.ValidateTask(p.toTask()); customErr != nil {
+		return customErr.Error
+	}
+	return nil
+}
+
+// toTask converts the validated params, the other fields are set by the server
+func (p *createTaskParams) toTask() *domain.Task {
+	return &domain.Task{
+		Name:       p.Name,
+		Status:     *p.Status,
+		DueAt:      p.DueAt,
+		Recurrence: p.Recurrence,
+	}
+}
+
+// NewProtoMessage lets the params be sent as a protobuf Task
+func (p *createTaskParams) NewProtoMessage() proto.Message {
+	return &pb.Task{}
+}
+
+// validate checks the params decoded without BindJson the same way as CreateTask
+func (p *createTaskParams) validate() error {
+	if err := binding.Validator.ValidateStruct(p); err != nil {
+		return err
+	}
+	return p.AfterValidate(binding.Validator)
 }
 
 // CreateTask create a task
@@ -52,31 +194,18 @@ func (t *TaskHandler) CreateTask(ctx *gin.Context) {
 		return
 	}
 
-	if !task.Status.IsValid() {
-		customErr = code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, fmt.Errorf("status is invalid"))
-		response.CustomError(ctx, customErr)
-		return
-	}
-
-	customErr = usecase.CreateTask(ctx, &domain.Task{
-		Name:   task.Name,
-		Status: *task.Status,
-	})
+	newTask := task.toTask()
+	customErr = usecase.CreateTask(ctx, newTask)
 	if customErr != nil {
 		response.CustomError(ctx, customErr)
 		return
 	}
-	response.OK(ctx, task)
+	response.OK(ctx, newTask)
 }
 
-type updateTaskParams struct {
-	Name   *string            `json:"name"`
-	Status *domain.TaskStatus `json:"status"`
-}
-
-// UpdateTask update a task
+// UpdateTask replace a task, the omitted due date and recurrence are cleared
 func (t *TaskHandler) UpdateTask(ctx *gin.Context) {
-	task := updateTaskParams{}
+	task := createTaskParams{}
 	customErr := util.ToGinContextExt(ctx).BindJson(&task)
 	if customErr != nil {
 		response.CustomError(ctx, customErr)
@@ -89,22 +218,14 @@ func (t *TaskHandler) UpdateTask(ctx *gin.Context) {
 		return
 	}
 
-	if !task.Status.IsValid() {
-		customErr = code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, fmt.Errorf("status is invalid"))
-		response.CustomError(ctx, customErr)
-		return
-	}
-
-	customErr = usecase.UpdateTask(ctx, &domain.UpdateTaskParams{
-		ID:     taskID,
-		Name:   task.Name,
-		Status: task.Status,
-	})
+	newTask := task.toTask()
+	newTask.ID = taskID
+	replaced, customErr := usecase.ReplaceTask(ctx, newTask)
 	if customErr != nil {
 		response.CustomError(ctx, customErr)
 		return
 	}
-	response.OK(ctx, task)
+	response.OK(ctx, replaced)
 }
 
 // DeleteTask delete a task
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/Yu-Qi/restful_api/domain"
 	"github.com/Yu-Qi/restful_api/domain/seed"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/suite"
@@ -81,6 +83,97 @@ func (s *getTaskSuite) TestSuccess() {
 	}
 }
 
+func (s *getTaskSuite) TestNDJSON() {
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", s.Url, nil)
+	s.NoError(err)
+	req.Header.Set("Accept", "application/x-ndjson")
+	s.Router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal("application/x-ndjson", w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	s.Len(lines, len(seed.Tasks()))
+	for _, line := range lines {
+		actualTask := domain.Task{}
+		s.NoError(json.Unmarshal([]byte(line), &actualTask))
+		s.Equal(seed.Tasks()[actualTask.ID-1].Name, actualTask.Name)
+	}
+
+	// the blocked filter applies too
+	s.Nil(_taskUsecase.AddDependency(s.Ctx, 1, 3))
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest("GET", s.Url+"?blocked=true", nil)
+	s.NoError(err)
+	req.Header.Set("Accept", "application/x-ndjson")
+	s.Router.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+	actualTask := domain.Task{}
+	s.NoError(json.Unmarshal(w.Body.Bytes(), &actualTask))
+	s.Equal(1, actualTask.ID)
+	s.Equal(1, strings.Count(w.Body.String(), "\n"))
+}
+
+func (s *getTaskSuite) TestNDJSONEmpty() {
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: _taskRepo.NewInMemoryTaskRepo(),
+	})
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", s.Url, nil)
+	s.NoError(err)
+	req.Header.Set("Accept", "application/x-ndjson")
+	s.Router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal("application/x-ndjson", w.Header().Get("Content-Type"))
+	s.Empty(w.Body.String())
+}
+
+func (s *getTaskSuite) TestConditional() {
+	get := func(query string, header map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", s.Url+query, nil)
+		s.NoError(err)
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		s.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("", nil)
+	s.Equal(http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	lastModified := w.Header().Get("Last-Modified")
+	s.True(strings.HasPrefix(etag, `W/"`), etag)
+	s.NotEmpty(lastModified)
+
+	w = get("", map[string]string{"If-None-Match": `"other", ` + etag})
+	s.Equal(http.StatusNotModified, w.Code)
+	s.Empty(w.Body.String())
+	s.Equal(etag, w.Header().Get("ETag"))
+	s.Equal(http.StatusNotModified, get("", map[string]string{"If-Modified-Since": lastModified}).Code)
+	// If-None-Match wins
+	s.Equal(http.StatusOK, get("", map[string]string{"If-None-Match": `W/"other"`, "If-Modified-Since": lastModified}).Code)
+
+	// the filters and the media type have their own validators
+	blocked := get("?blocked=true", nil).Header().Get("ETag")
+	s.NotEqual(etag, blocked)
+	s.NotEqual(blocked, get("?blocked=false", nil).Header().Get("ETag"))
+	s.Equal(http.StatusOK, get("?blocked=true", map[string]string{"If-None-Match": etag}).Code)
+	s.Equal(http.StatusNotModified, get("?blocked=true", map[string]string{"If-None-Match": blocked}).Code)
+	s.Equal(http.StatusOK, get("", map[string]string{"If-None-Match": etag, "Accept": "application/x-ndjson"}).Code)
+
+	// a change makes the validators stale
+	s.Nil(_taskUsecase.AddDependency(s.Ctx, 1, 3))
+	w = get("", map[string]string{"If-None-Match": etag})
+	s.Equal(http.StatusOK, w.Code)
+	s.NotEqual(etag, w.Header().Get("ETag"))
+	s.Equal(http.StatusOK, get("?blocked=true", map[string]string{"If-None-Match": blocked}).Code)
+	s.Equal(http.StatusOK, get("", map[string]string{"If-Modified-Since": "Mon, 02 Jan 2006 15:04:05 GMT"}).Code)
+}
+
 // Post /v1/tasks
 func TestCreateTaskSuite(t *testing.T) {
 	suite.Run(t, new(createTaskSuite))
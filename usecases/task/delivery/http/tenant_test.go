@@ -0,0 +1,116 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// tenants resolved from the header and the token claim
+func TestTenantSuite(t *testing.T) {
+	suite.Run(t, new(tenantSuite))
+}
+
+type tenantSuite struct {
+	suite.Suite
+	Router   *gin.Engine
+	Ctx      context.Context
+	HS256Key []byte
+}
+
+func (s *tenantSuite) SetupSuite() {
+	s.HS256Key = []byte("secret")
+	verifier, err := auth.NewJWTVerifier(&auth.JWTConfig{HS256Secret: s.HS256Key})
+	s.NoError(err)
+
+	s.Router = gin.Default()
+	s.Router.ContextWithFallback = true
+	s.Router.Use(requestid.New(), middleware.RequestContext)
+	NewTaskHandler(s.Router.Group("", middleware.Authenticate(middleware.BearerScheme(verifier))))
+}
+
+func (s *tenantSuite) SetupTest() {
+	taskRepo := _taskRepo.NewInMemoryTaskRepo()
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: taskRepo,
+	})
+
+	s.Ctx = context.Background()
+}
+
+func (s *tenantSuite) token(subject, tenantID string) string {
+	claims := jwt.MapClaims{
+		"sub": subject,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	if tenantID != "" {
+		claims["tenant"] = tenantID
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.HS256Key)
+	s.NoError(err)
+	return token
+}
+
+func (s *tenantSuite) serve(method, url, token, tenantID string, body interface{}) *httptest.ResponseRecorder {
+	return testutil.Serve(s.Router, testutil.NewJSONRequest(s.T(), method, url, body),
+		"Authorization", testutil.Bearer(token), middleware.HeaderTenantID, tenantID)
+}
+
+func (s *tenantSuite) taskNames(w *httptest.ResponseRecorder) []string {
+	s.Equal(http.StatusOK, w.Code)
+	var response struct {
+		Data []struct {
+			Name string `json:"name"`
+		} `json:"data"`
+	}
+	s.Nil(json.Unmarshal(w.Body.Bytes(), &response))
+	names := []string{}
+	for _, task := range response.Data {
+		names = append(names, task.Name)
+	}
+	return names
+}
+
+func (s *tenantSuite) TestHeaderTenant() {
+	// a token without a tenant is bound to the default tenant, the header cannot switch it
+	alice := s.token("alice", "")
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks", alice, "", map[string]interface{}{"name": "default task", "status": 0}).Code)
+	s.Equal(http.StatusForbidden, s.serve("POST", "/v1/tasks", alice, "acme", map[string]interface{}{"name": "acme task", "status": 0}).Code)
+	s.Equal(http.StatusForbidden, s.serve("GET", "/v1/tasks", alice, "acme", nil).Code)
+
+	s.Equal([]string{"default task"}, s.taskNames(s.serve("GET", "/v1/tasks", alice, "", nil)))
+	s.Equal([]string{"default task"}, s.taskNames(s.serve("GET", "/v1/tasks", alice, requestctx.DefaultTenantID, nil)))
+	s.Equal([]string{}, s.taskNames(s.serve("GET", "/v1/tasks", s.token("alice", "acme"), "acme", nil)))
+
+	s.Equal(http.StatusBadRequest, s.serve("GET", "/v1/tasks", alice, "not a tenant!", nil).Code)
+}
+
+func (s *tenantSuite) TestClaimedTenant() {
+	acme := s.token("alice", "acme")
+	globex := s.token("alice", "globex")
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks", acme, "", map[string]interface{}{"name": "acme task", "status": 0}).Code)
+
+	// the task 1 of acme is not visible to globex, even for the same owner
+	s.Equal(http.StatusNotFound, s.serve("PUT", "/v1/tasks/1", globex, "", map[string]interface{}{"name": "hijacked", "status": 0}).Code)
+	s.Equal(http.StatusNotFound, s.serve("DELETE", "/v1/tasks/1", globex, "", nil).Code)
+	s.Equal([]string{}, s.taskNames(s.serve("GET", "/v1/tasks", globex, "", nil)))
+
+	// the header cannot switch the claimed tenant
+	s.Equal(http.StatusForbidden, s.serve("GET", "/v1/tasks", globex, "acme", nil).Code)
+	s.Equal([]string{"acme task"}, s.taskNames(s.serve("GET", "/v1/tasks", acme, "acme", nil)))
+}
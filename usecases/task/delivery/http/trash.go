@@ -0,0 +1,56 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/usecases/task/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrash get all tasks in the trash
+func (t *TaskHandler) GetTrash(ctx *gin.Context) {
+	tasks, customErr := usecase.GetDeletedTasks(ctx)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	response.OK(ctx, tasks)
+}
+
+// RestoreTask move a task out of the trash
+func (t *TaskHandler) RestoreTask(ctx *gin.Context) {
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		customErr := code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	customErr := usecase.RestoreTask(ctx, taskID)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, nil)
+}
+
+// PurgeTask permanently delete a task in the trash
+func (t *TaskHandler) PurgeTask(ctx *gin.Context) {
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		customErr := code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	customErr := usecase.PurgeTask(ctx, taskID)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, nil)
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Yu-Qi/restful_api/domain/seed"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// /v1/trash
+func TestTrashSuite(t *testing.T) {
+	suite.Run(t, new(trashSuite))
+}
+
+type trashSuite struct {
+	suite.Suite
+	Router *gin.Engine
+	Ctx    context.Context
+}
+
+func (s *trashSuite) SetupSuite() {
+	s.Router = gin.Default()
+	NewTaskHandler(s.Router.Group(""))
+}
+
+func (s *trashSuite) SetupTest() {
+	taskRepo := _taskRepo.NewInMemoryTaskRepo()
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: taskRepo,
+	})
+
+	s.Ctx = context.Background()
+
+	for _, task := range seed.Tasks() {
+		customErr := _taskUsecase.CreateTask(s.Ctx, task)
+		s.Nil(customErr)
+	}
+	s.Nil(_taskUsecase.DeleteTask(s.Ctx, 1))
+}
+
+func (s *trashSuite) serve(method, url string) *httptest.ResponseRecorder {
+	return testutil.Serve(s.Router, testutil.NewJSONRequest(s.T(), method, url, nil))
+}
+
+func (s *trashSuite) TestGetTrash() {
+	w := s.serve("GET", "/v1/trash")
+	s.Equal(http.StatusOK, w.Code)
+
+	var response struct {
+		Code int `json:"code"`
+		Data []struct {
+			ID        int     `json:"id"`
+			DeletedAt *string `json:"deleted_at"`
+		} `json:"data"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	s.Nil(err)
+	s.Equal(1, len(response.Data))
+	s.Equal(1, response.Data[0].ID)
+	s.NotNil(response.Data[0].DeletedAt)
+}
+
+func (s *trashSuite) TestRestore() {
+	s.Equal(http.StatusOK, s.serve("POST", fmt.Sprintf("/v1/tasks/%d/restore", 1)).Code)
+
+	tasks, customErr := _taskUsecase.GetTasks(s.Ctx)
+	s.Nil(customErr)
+	s.Equal(len(seed.Tasks()), len(tasks))
+
+	s.Equal(http.StatusNotFound, s.serve("POST", fmt.Sprintf("/v1/tasks/%d/restore", 1)).Code)
+}
+
+func (s *trashSuite) TestPurge() {
+	s.Equal(http.StatusNotFound, s.serve("DELETE", fmt.Sprintf("/v1/trash/%d", 2)).Code)
+	s.Equal(http.StatusOK, s.serve("DELETE", fmt.Sprintf("/v1/trash/%d", 1)).Code)
+
+	tasks, customErr := _taskUsecase.GetDeletedTasks(s.Ctx)
+	s.Nil(customErr)
+	s.Empty(tasks)
+
+	s.Equal(http.StatusNotFound, s.serve("POST", fmt.Sprintf("/v1/tasks/%d/restore", 1)).Code)
+}
+
+func (s *trashSuite) TestPurgeTrash() {
+	purged, customErr := _taskUsecase.PurgeTrash(s.Ctx, 0)
+	s.Nil(customErr)
+	s.Equal(1, purged)
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/Yu-Qi/restful_api/usecases/task/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// GetTaskVersions get every version of a task
+func (t *TaskHandler) GetTaskVersions(ctx *gin.Context) {
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		customErr := code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	versions, customErr := usecase.GetTaskVersions(ctx, taskID)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, versions)
+}
+
+type revertTaskParams struct {
+	Version int `json:"version" binding:"required,min=1"`
+}
+
+// RevertTask restore a task to a previous version
+func (t *TaskHandler) RevertTask(ctx *gin.Context) {
+	params := revertTaskParams{}
+	customErr := util.ToGinContextExt(ctx).BindJson(&params)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		customErr = code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	diffs, customErr := usecase.RevertTask(ctx, taskID, params.Version)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, diffs)
+}
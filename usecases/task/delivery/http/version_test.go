@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// GET /v1/tasks/:id/versions and POST /v1/tasks/:id/revert
+func TestVersionSuite(t *testing.T) {
+	suite.Run(t, new(versionSuite))
+}
+
+type versionSuite struct {
+	suite.Suite
+	Router *gin.Engine
+	Ctx    context.Context
+}
+
+func (s *versionSuite) SetupSuite() {
+	s.Router = gin.Default()
+	NewTaskHandler(s.Router.Group(""))
+}
+
+func (s *versionSuite) SetupTest() {
+	taskRepo := _taskRepo.NewInMemoryTaskRepo()
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: taskRepo,
+	})
+
+	s.Ctx = context.Background()
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "v1"}))
+	s.Nil(_taskUsecase.UpdateTask(s.Ctx, &domain.UpdateTaskParams{ID: 1, Name: util.Ptr("v2")}))
+}
+
+func (s *versionSuite) serve(method, url string, body interface{}) *httptest.ResponseRecorder {
+	return testutil.Serve(s.Router, testutil.NewJSONRequest(s.T(), method, url, body))
+}
+
+func (s *versionSuite) TestGetVersions() {
+	w := s.serve("GET", "/v1/tasks/1/versions", nil)
+	s.Equal(http.StatusOK, w.Code)
+
+	var response struct {
+		Data []struct {
+			Version int  `json:"version"`
+			Task    task `json:"task"`
+		} `json:"data"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	s.Nil(err)
+	s.Equal(2, len(response.Data))
+	s.Equal(1, response.Data[0].Version)
+	s.Equal("v1", response.Data[0].Task.Name)
+	s.Equal("v2", response.Data[1].Task.Name)
+
+	s.Equal(http.StatusNotFound, s.serve("GET", "/v1/tasks/2/versions", nil).Code)
+}
+
+func (s *versionSuite) TestRevert() {
+	w := s.serve("POST", "/v1/tasks/1/revert", map[string]interface{}{"version": 1})
+	s.Equal(http.StatusOK, w.Code)
+
+	var response struct {
+		Data []struct {
+			Field string      `json:"field"`
+			From  interface{} `json:"from"`
+			To    interface{} `json:"to"`
+		} `json:"data"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	s.Nil(err)
+	s.Equal(1, len(response.Data))
+	s.Equal("name", response.Data[0].Field)
+	s.Equal("v2", response.Data[0].From)
+	s.Equal("v1", response.Data[0].To)
+
+	task, customErr := _taskUsecase.GetTask(s.Ctx, 1)
+	s.Nil(customErr)
+	s.Equal("v1", task.Name)
+	s.Equal(3, task.Version)
+
+	s.Equal(http.StatusNotFound, s.serve("POST", "/v1/tasks/1/revert", map[string]interface{}{"version": 9}).Code)
+	s.Equal(http.StatusBadRequest, s.serve("POST", "/v1/tasks/1/revert", map[string]interface{}{"version": 0}).Code)
+}
+
+func (s *versionSuite) TestRevertToCompletedContinuesSeries() {
+	recurrence := &domain.Recurrence{Frequency: domain.RecurrenceFrequencyDaily, StartAt: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "daily", Recurrence: recurrence}))
+	s.Nil(_taskUsecase.UpdateTask(s.Ctx, &domain.UpdateTaskParams{ID: 2, Status: util.Ptr(domain.TaskStatusCompleted)}))
+	s.Nil(_taskUsecase.DeleteTask(s.Ctx, 3))
+
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks/2/revert", map[string]interface{}{"version": 1}).Code)
+	s.Equal(http.StatusOK, s.serve("POST", "/v1/tasks/2/revert", map[string]interface{}{"version": 2}).Code)
+
+	next, customErr := _taskUsecase.GetTask(s.Ctx, 4)
+	s.Require().Nil(customErr)
+	s.Equal("daily", next.Name)
+	s.Equal(time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC), next.DueAt.UTC())
+}
@@ -0,0 +1,336 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+	"github.com/Yu-Qi/restful_api/usecases/task/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// types of the messages sent by the clients
+const (
+	wsSubscribe   = "subscribe"
+	wsUnsubscribe = "unsubscribe"
+	wsCreate      = "create"
+	wsUpdate      = "update"
+	wsDelete      = "delete"
+)
+
+// types of the messages sent by the server
+const (
+	wsAck   = "ack"
+	wsEvent = "event"
+)
+
+// wsMaxMessageSize is the largest message accepted from a client
+const wsMaxMessageSize = 64 << 10
+
+var (
+	// wsSendBuffer is the number of messages queued for a connection, a subscriber falling further behind
+	// is disconnected with websocket.CloseTryAgainLater and resumes with last_event_id
+	wsSendBuffer   = 64
+	wsWriteTimeout = 10 * time.Second
+	wsPingInterval = 30 * time.Second
+)
+
+var upgrader = websocket.Upgrader{}
+
+// wsRequest is a command of a client, ID is echoed in the ack
+type wsRequest struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	TaskID int             `json:"task_id"`
+	Task   json.RawMessage `json:"task"`
+
+	// subscribe
+	Status      []domain.TaskStatus `json:"status"`
+	TaskIDs     []int               `json:"task_ids"`
+	LastEventID uint64              `json:"last_event_id"`
+}
+
+// wsAckMessage is the result of a command, Code is code.OK on success
+type wsAckMessage struct {
+	Type    string      `json:"type"`
+	ID      string      `json:"id"`
+	Code    int         `json:"code"`
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// wsEventMessage is a change of a subscribed task
+type wsEventMessage struct {
+	Type    string            `json:"type"`
+	EventID uint64            `json:"event_id"`
+	Data    *domain.TaskEvent `json:"data"`
+}
+
+// wsConn is a websocket connection, only writeLoop writes data messages to conn
+type wsConn struct {
+	conn         *websocket.Conn
+	ctx          context.Context
+	send         chan interface{}
+	writeTimeout time.Duration
+	// done is closed when the connection is closing
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// subscription is only accessed by the read loop
+	subscription *wsSubscription
+}
+
+type wsSubscription struct {
+	*usecase.TaskEventSubscription
+	// stopped is closed when the client unsubscribes
+	stopped chan struct{}
+}
+
+// ServeWebSocket upgrade to a websocket to subscribe the changes of the tasks and to create, update and delete tasks
+func (t *TaskHandler) ServeWebSocket(ctx *gin.Context) {
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// the upgrader has replied the error
+		return
+	}
+
+	c := &wsConn{
+		conn:         conn,
+		ctx:          ctx.Request.Context(),
+		send:         make(chan interface{}, wsSendBuffer),
+		writeTimeout: wsWriteTimeout,
+		done:         make(chan struct{}),
+	}
+	go c.writeLoop()
+	c.readLoop()
+}
+
+func (c *wsConn) readLoop() {
+	defer func() {
+		c.unsubscribe()
+		c.close()
+	}()
+
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.extendReadDeadline()
+	c.conn.SetPongHandler(func(string) error {
+		c.extendReadDeadline()
+		return nil
+	})
+	for {
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.extendReadDeadline()
+
+		req := &wsRequest{}
+		if err = json.Unmarshal(message, req); err != nil {
+			c.reply(req, nil, code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err))
+			continue
+		}
+		c.handle(req)
+	}
+}
+
+func (c *wsConn) extendReadDeadline() {
+	_ = c.conn.SetReadDeadline(time.Now().Add(2 * wsPingInterval))
+}
+
+func (c *wsConn) writeLoop() {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case message := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+			if err := c.conn.WriteJSON(message); err != nil {
+				c.close()
+				return
+			}
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.writeTimeout)); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+// close closes the connection, the close frame is sent when closeCode is given
+func (c *wsConn) close(closeCode ...int) {
+	c.closeOnce.Do(func() {
+		if len(closeCode) > 0 {
+			message := websocket.FormatCloseMessage(closeCode[0], "")
+			_ = c.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(c.writeTimeout))
+		}
+		close(c.done)
+		_ = c.conn.Close()
+	})
+}
+
+// enqueue waits for room in the send buffer, so a client not reading its acks stops being read as well
+func (c *wsConn) enqueue(message interface{}) {
+	select {
+	case c.send <- message:
+	case <-c.done:
+	}
+}
+
+func (c *wsConn) reply(req *wsRequest, data interface{}, customErr *code.CustomError) {
+	ack := &wsAckMessage{Type: wsAck, ID: req.ID, Code: code.OK, Data: data}
+	if customErr != nil {
+		ack.Code = customErr.Code
+		ack.Message = customErr.Error.Error()
+		ack.Data = nil
+	}
+	c.enqueue(ack)
+}
+
+func (c *wsConn) handle(req *wsRequest) {
+	ctx := c.ctx
+	if req.ID != "" {
+		ctx = requestctx.WithRequestID(ctx, requestctx.GetRequestID(ctx)+"/"+req.ID)
+	}
+
+	switch req.Type {
+	case wsSubscribe:
+		c.subscribe(ctx, req)
+	case wsUnsubscribe:
+		c.unsubscribe()
+		c.reply(req, nil, nil)
+	case wsCreate, wsUpdate, wsDelete:
+		if customErr := requireWriteScope(ctx); customErr != nil {
+			c.reply(req, nil, customErr)
+			return
+		}
+		data, customErr := runTaskCommand(ctx, req)
+		c.reply(req, data, customErr)
+	default:
+		customErr := code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, fmt.Errorf("unknown message type %q", req.Type))
+		c.reply(req, nil, customErr)
+	}
+}
+
+// subscribe replaces the subscription of the connection, the ack is sent before the replayed events
+func (c *wsConn) subscribe(ctx context.Context, req *wsRequest) {
+	for _, status := range req.Status {
+		if !status.IsValid() {
+			c.reply(req, nil, code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, fmt.Errorf("status is invalid")))
+			return
+		}
+	}
+
+	subscription, customErr := usecase.SubscribeTaskEvents(ctx, req.LastEventID, &usecase.TaskEventFilter{
+		Statuses: req.Status,
+		TaskIDs:  req.TaskIDs,
+	})
+	if customErr != nil {
+		c.reply(req, nil, customErr)
+		return
+	}
+	c.unsubscribe()
+	c.subscription = &wsSubscription{TaskEventSubscription: subscription, stopped: make(chan struct{})}
+
+	c.reply(req, nil, nil)
+	for _, event := range subscription.Replay {
+		c.enqueue(&wsEventMessage{Type: wsEvent, EventID: event.ID, Data: event.Data})
+	}
+	go c.forward(c.subscription)
+}
+
+func (c *wsConn) unsubscribe() {
+	if c.subscription == nil {
+		return
+	}
+	close(c.subscription.stopped)
+	c.subscription.Cancel()
+	c.subscription = nil
+}
+
+// forward queues the events of a subscription, the connection is closed once the client falls behind
+func (c *wsConn) forward(subscription *wsSubscription) {
+	for {
+		event, ok := <-subscription.Events
+		if !ok {
+			select {
+			case <-subscription.stopped:
+			default:
+				// dropped by the event bus
+				c.close(websocket.CloseTryAgainLater)
+			}
+			return
+		}
+
+		select {
+		case c.send <- &wsEventMessage{Type: wsEvent, EventID: event.ID, Data: event.Data}:
+		case <-c.done:
+			return
+		default:
+			c.close(websocket.CloseTryAgainLater)
+			return
+		}
+	}
+}
+
+// requireWriteScope is the per message counterpart of the scope middleware, the upgrade only needs the read scope
+func requireWriteScope(ctx context.Context) *code.CustomError {
+	principal := auth.GetPrincipal(ctx)
+	if principal != nil && !principal.HasScope(auth.ScopeTasksWrite) {
+		return code.NewCustomError(code.Forbidden, http.StatusForbidden, fmt.Errorf("scope %s is required", auth.ScopeTasksWrite))
+	}
+	return nil
+}
+
+// runTaskCommand runs a create, update or delete command, update replaces the task like PUT /v1/tasks/:id
+func runTaskCommand(ctx context.Context, req *wsRequest) (interface{}, *code.CustomError) {
+	if req.Type == wsDelete {
+		return nil, usecase.DeleteTask(ctx, req.TaskID)
+	}
+
+	params, customErr := bindTaskParams(req.Task)
+	if customErr != nil {
+		return nil, customErr
+	}
+	task := &domain.Task{
+		ID:         req.TaskID,
+		Name:       params.Name,
+		Status:     *params.Status,
+		DueAt:      params.DueAt,
+		Recurrence: params.Recurrence,
+	}
+	if req.Type == wsUpdate {
+		return usecase.ReplaceTask(ctx, task)
+	}
+	task.ID = 0
+	if customErr = usecase.CreateTask(ctx, task); customErr != nil {
+		return nil, customErr
+	}
+	return task, nil
+}
+
+// bindTaskParams decodes and validates the task of a command like BindJson does for a request body
+func bindTaskParams(raw json.RawMessage) (*createTaskParams, *code.CustomError) {
+	if len(raw) == 0 {
+		return nil, code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, fmt.Errorf("task is required"))
+	}
+	params := &createTaskParams{}
+	err := json.Unmarshal(raw, params)
+	if err == nil {
+		err = params.validate()
+	}
+	if err != nil {
+		return nil, code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+	}
+	return params, nil
+}
@@ -0,0 +1,226 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/eventbus"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+)
+
+// GET /v1/ws
+func TestWebSocketSuite(t *testing.T) {
+	suite.Run(t, new(webSocketSuite))
+}
+
+type webSocketSuite struct {
+	suite.Suite
+	Server     *httptest.Server
+	TaskEvents *eventbus.Bus[*domain.TaskEvent]
+}
+
+// wsMessage is a message of the server, either an ack or an event
+type wsMessage struct {
+	Type    string            `json:"type"`
+	ID      string            `json:"id"`
+	Code    int               `json:"code"`
+	Message string            `json:"message"`
+	Data    json.RawMessage   `json:"data"`
+	EventID uint64            `json:"event_id"`
+	Event   *domain.TaskEvent `json:"-"`
+}
+
+func (s *webSocketSuite) SetupSuite() {
+	// the token is the subject, readers only get the read scope
+	scheme := middleware.AuthScheme{
+		Name: "Test",
+		Verify: func(ctx context.Context, token string) (*auth.Principal, *code.CustomError) {
+			principal := &auth.Principal{Subject: token, Role: auth.RoleEditor}
+			if strings.HasPrefix(token, "reader") {
+				principal.Scopes = []string{auth.ScopeTasksRead}
+			}
+			return principal, nil
+		},
+	}
+
+	router := gin.Default()
+	router.ContextWithFallback = true
+	NewTaskHandler(router.Group("",
+		middleware.Authenticate(scheme),
+		middleware.RequireScope(auth.ScopeTasksRead, auth.ScopeTasksWrite),
+	))
+	s.Server = httptest.NewServer(router)
+}
+
+func (s *webSocketSuite) TearDownSuite() {
+	s.Server.Close()
+}
+
+func (s *webSocketSuite) SetupTest() {
+	s.TaskEvents = eventbus.New[*domain.TaskEvent](100, 10)
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo:   _taskRepo.NewInMemoryTaskRepo(_taskRepo.WithEventPublisher(s.TaskEvents.Publish)),
+		TaskEvents: s.TaskEvents,
+	})
+}
+
+func (s *webSocketSuite) dial(token string) *websocket.Conn {
+	header := http.Header{"Authorization": {"Test " + token}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.Server.URL, "http")+"/v1/ws", header)
+	s.Require().NoError(err)
+	return conn
+}
+
+func (s *webSocketSuite) send(conn *websocket.Conn, message string) {
+	s.Require().NoError(conn.WriteMessage(websocket.TextMessage, []byte(message)))
+}
+
+func (s *webSocketSuite) read(conn *websocket.Conn) *wsMessage {
+	s.Require().NoError(conn.SetReadDeadline(time.Now().Add(time.Second)))
+	message := &wsMessage{}
+	s.Require().NoError(conn.ReadJSON(message))
+	if message.Type == wsEvent {
+		message.Event = &domain.TaskEvent{}
+		s.Require().NoError(json.Unmarshal(message.Data, message.Event))
+	}
+	return message
+}
+
+// request sends a command and returns its ack
+func (s *webSocketSuite) request(conn *websocket.Conn, message string) *wsMessage {
+	s.send(conn, message)
+	ack := s.read(conn)
+	s.Equal(wsAck, ack.Type)
+	return ack
+}
+
+func (s *webSocketSuite) TestCommands() {
+	conn := s.dial("alice")
+	defer conn.Close()
+
+	ack := s.request(conn, `{"id": "1", "type": "create", "task": {"name": "task", "status": 0}}`)
+	s.Equal("1", ack.ID)
+	s.Equal(code.OK, ack.Code)
+	task := &domain.Task{}
+	s.Nil(json.Unmarshal(ack.Data, task))
+	s.Equal(1, task.ID)
+	s.Equal("alice", task.OwnerID)
+
+	ack = s.request(conn, `{"id": "2", "type": "update", "task_id": 1, "task": {"name": "renamed", "status": 1}}`)
+	s.Equal(code.OK, ack.Code)
+	s.Nil(json.Unmarshal(ack.Data, task))
+	s.Equal("renamed", task.Name)
+	s.Equal(domain.TaskStatusCompleted, task.Status)
+
+	ack = s.request(conn, `{"id": "3", "type": "delete", "task_id": 1}`)
+	s.Equal(code.OK, ack.Code)
+	_, customErr := _taskUsecase.GetTask(auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "alice"}), 1)
+	s.Equal(code.NotFound, customErr.Code)
+
+	ack = s.request(conn, `{"id": "4", "type": "delete", "task_id": 1}`)
+	s.Equal("4", ack.ID)
+	s.Equal(code.NotFound, ack.Code)
+	s.NotEmpty(ack.Message)
+
+	// the connection survives the invalid messages
+	s.Equal(code.ParamIncorrect, s.request(conn, `{"id": "5", "type": "create", "task": {"name": "task"}}`).Code)
+	s.Equal(code.ParamIncorrect, s.request(conn, `{"id": "6", "type": "create"}`).Code)
+	s.Equal(code.ParamIncorrect, s.request(conn, `{"id": "7", "type": "rename"}`).Code)
+	s.Equal(code.ParamIncorrect, s.request(conn, `not json`).Code)
+	s.Equal(code.OK, s.request(conn, `{"id": "8", "type": "create", "task": {"name": "task", "status": 0}}`).Code)
+}
+
+func (s *webSocketSuite) TestOwnershipAndScope() {
+	alice := s.dial("alice")
+	defer alice.Close()
+	s.Equal(code.OK, s.request(alice, `{"id": "1", "type": "create", "task": {"name": "task", "status": 0}}`).Code)
+
+	bob := s.dial("bob")
+	defer bob.Close()
+	s.Equal(code.NotFound, s.request(bob, `{"id": "1", "type": "delete", "task_id": 1}`).Code)
+
+	reader := s.dial("reader")
+	defer reader.Close()
+	s.Equal(code.OK, s.request(reader, `{"id": "1", "type": "subscribe"}`).Code)
+	s.Equal(code.Forbidden, s.request(reader, `{"id": "2", "type": "create", "task": {"name": "task", "status": 0}}`).Code)
+}
+
+func (s *webSocketSuite) TestSubscribe() {
+	conn := s.dial("alice")
+	defer conn.Close()
+	s.Equal(code.OK, s.request(conn, `{"id": "1", "type": "create", "task": {"name": "first", "status": 0}}`).Code)
+	s.Equal(code.OK, s.request(conn, `{"id": "2", "type": "create", "task": {"name": "second", "status": 0}}`).Code)
+
+	// the ack comes before the replayed events
+	s.Equal(code.OK, s.request(conn, `{"id": "3", "type": "subscribe", "task_ids": [2], "last_event_id": 0}`).Code)
+	event := s.read(conn)
+	s.Equal(wsEvent, event.Type)
+	s.Equal(uint64(2), event.EventID)
+	s.Equal(domain.TaskActionCreate, event.Event.Action)
+	s.Equal("second", event.Event.Task.Name)
+
+	// the change made by another connection is pushed, the acks and the events share the connection
+	other := s.dial("alice")
+	defer other.Close()
+	s.Equal(code.OK, s.request(other, `{"id": "1", "type": "update", "task_id": 1, "task": {"name": "first", "status": 1}}`).Code)
+	s.Equal(code.OK, s.request(other, `{"id": "2", "type": "update", "task_id": 2, "task": {"name": "second", "status": 1}}`).Code)
+	event = s.read(conn)
+	s.Equal(uint64(4), event.EventID)
+	s.Equal(domain.TaskActionUpdate, event.Event.Action)
+	s.Equal(2, event.Event.Task.ID)
+
+	// another tenant is not visible
+	s.Nil(_taskUsecase.CreateTask(requestctx.WithTenantID(context.Background(), "acme"), &domain.Task{Name: "another tenant"}))
+
+	ack := s.request(conn, `{"id": "4", "type": "unsubscribe"}`)
+	s.Equal("4", ack.ID)
+	s.Equal(code.OK, ack.Code)
+	s.Equal(code.OK, s.request(other, `{"id": "3", "type": "delete", "task_id": 2}`).Code)
+	s.Equal(code.OK, s.request(conn, `{"id": "5", "type": "delete", "task_id": 1}`).Code)
+
+	s.Equal(code.ParamIncorrect, s.request(conn, `{"id": "6", "type": "subscribe", "status": [2]}`).Code)
+}
+
+func (s *webSocketSuite) TestSlowConsumer() {
+	wsSendBuffer, wsWriteTimeout = 1, 100*time.Millisecond
+	defer func() {
+		wsSendBuffer, wsWriteTimeout = 64, 10*time.Second
+	}()
+
+	conn := s.dial("alice")
+	defer conn.Close()
+	s.Equal(code.OK, s.request(conn, `{"id": "1", "type": "subscribe"}`).Code)
+
+	// the events outgrow the socket buffers while the client is not reading
+	task := &domain.Task{ID: 1, Name: strings.Repeat("x", 1<<20), OwnerID: "alice"}
+	published := 64
+	for i := 0; i < published; i++ {
+		s.TaskEvents.Publish(&domain.TaskEvent{Action: domain.TaskActionUpdate, Task: task, TenantID: requestctx.DefaultTenantID})
+	}
+
+	received := 0
+	for {
+		s.Require().NoError(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+		if _, _, err := conn.ReadMessage(); err != nil {
+			s.False(websocket.IsCloseError(err, websocket.CloseNormalClosure))
+			break
+		}
+		received++
+	}
+	s.Less(received, published)
+}
@@ -0,0 +1,129 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// AddDependency will record that taskID is blocked by blockerID
+func (i *inMemoryTaskRepo) AddDependency(ctx context.Context, taskID, blockerID int) *code.CustomError {
+	if taskID == blockerID {
+		return code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, fmt.Errorf("task can not block itself"))
+	}
+	for _, id := range []int{taskID, blockerID} {
+		if _, ok := i.loadTask(id); !ok {
+			return code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task %d not found", id))
+		}
+	}
+
+	i.DependencyLock.Lock()
+	defer i.DependencyLock.Unlock()
+
+	if i.reachable(blockerID, taskID) {
+		return code.NewCustomError(code.DependencyCycle, http.StatusConflict, fmt.Errorf("dependency would introduce a cycle"))
+	}
+
+	if _, ok := i.Dependencies[taskID]; !ok {
+		i.Dependencies[taskID] = map[int]struct{}{}
+	}
+	i.Dependencies[taskID][blockerID] = struct{}{}
+	i.bumpRevision()
+	return nil
+}
+
+// RemoveDependency will remove the blocked-by edge between taskID and blockerID
+func (i *inMemoryTaskRepo) RemoveDependency(ctx context.Context, taskID, blockerID int) *code.CustomError {
+	i.DependencyLock.Lock()
+	defer i.DependencyLock.Unlock()
+
+	if _, ok := i.Dependencies[taskID][blockerID]; !ok {
+		return code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("dependency not found"))
+	}
+
+	delete(i.Dependencies[taskID], blockerID)
+	if len(i.Dependencies[taskID]) == 0 {
+		delete(i.Dependencies, taskID)
+	}
+	i.bumpRevision()
+	return nil
+}
+
+// GetBlockers will get the ids of the tasks blocking taskID
+func (i *inMemoryTaskRepo) GetBlockers(ctx context.Context, taskID int) ([]int, *code.CustomError) {
+	if _, ok := i.loadTask(taskID); !ok {
+		return nil, code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task not found"))
+	}
+
+	i.DependencyLock.RLock()
+	defer i.DependencyLock.RUnlock()
+
+	// blockers in the trash no longer block
+	blockers := make([]int, 0, len(i.Dependencies[taskID]))
+	for _, blockerID := range sortedKeys(i.Dependencies[taskID]) {
+		if _, ok := i.loadTask(blockerID); ok {
+			blockers = append(blockers, blockerID)
+		}
+	}
+	return blockers, nil
+}
+
+// GetDependencies will get the whole blocked-by graph
+func (i *inMemoryTaskRepo) GetDependencies(ctx context.Context) (map[int][]int, *code.CustomError) {
+	i.DependencyLock.RLock()
+	defer i.DependencyLock.RUnlock()
+
+	graph := make(map[int][]int, len(i.Dependencies))
+	for taskID, blockers := range i.Dependencies {
+		graph[taskID] = sortedKeys(blockers)
+	}
+	return graph, nil
+}
+
+// reachable reports whether to can be reached from from by following blocked-by edges.
+// The caller must hold DependencyLock.
+func (i *inMemoryTaskRepo) reachable(from, to int) bool {
+	visited := map[int]bool{}
+	stack := []int{from}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == to {
+			return true
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		for next := range i.Dependencies[current] {
+			stack = append(stack, next)
+		}
+	}
+	return false
+}
+
+// removeDependenciesOf drops every edge that points from or to id
+func (i *inMemoryTaskRepo) removeDependenciesOf(id int) {
+	i.DependencyLock.Lock()
+	defer i.DependencyLock.Unlock()
+
+	delete(i.Dependencies, id)
+	for taskID, blockers := range i.Dependencies {
+		delete(blockers, id)
+		if len(blockers) == 0 {
+			delete(i.Dependencies, taskID)
+		}
+	}
+}
+
+func sortedKeys(m map[int]struct{}) []int {
+	keys := make([]int, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
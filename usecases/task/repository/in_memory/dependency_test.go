@@ -0,0 +1,106 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/domain/seed"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/stretchr/testify/suite"
+)
+
+type dependencySuite struct {
+	suite.Suite
+	taskRepo domain.TaskRepository
+}
+
+func TestDependencySuite(t *testing.T) {
+	suite.Run(t, new(dependencySuite))
+}
+
+func (s *dependencySuite) SetupTest() {
+	s.taskRepo = NewInMemoryTaskRepo()
+
+	// setup data
+	for _, task := range seed.Tasks() {
+		s.taskRepo.CreateTask(context.Background(), task)
+	}
+}
+
+func (s *dependencySuite) TestAddDependency() {
+	ctx := context.Background()
+	s.Nil(s.taskRepo.AddDependency(ctx, 2, 1))
+	s.Nil(s.taskRepo.AddDependency(ctx, 3, 1))
+	s.Nil(s.taskRepo.AddDependency(ctx, 3, 2))
+
+	blockers, customErr := s.taskRepo.GetBlockers(ctx, 3)
+	s.Nil(customErr)
+	s.Equal([]int{1, 2}, blockers)
+
+	graph, customErr := s.taskRepo.GetDependencies(ctx)
+	s.Nil(customErr)
+	s.Equal(map[int][]int{2: {1}, 3: {1, 2}}, graph)
+}
+
+func (s *dependencySuite) TestCycle() {
+	ctx := context.Background()
+	s.Nil(s.taskRepo.AddDependency(ctx, 2, 1))
+	s.Nil(s.taskRepo.AddDependency(ctx, 3, 2))
+
+	customErr := s.taskRepo.AddDependency(ctx, 1, 3)
+	s.NotNil(customErr)
+	s.Equal(code.DependencyCycle, customErr.Code)
+
+	customErr = s.taskRepo.AddDependency(ctx, 1, 1)
+	s.NotNil(customErr)
+	s.Equal(code.ParamIncorrect, customErr.Code)
+}
+
+func (s *dependencySuite) TestTaskNotFound() {
+	customErr := s.taskRepo.AddDependency(context.Background(), 1, 6)
+	s.NotNil(customErr)
+	s.Equal(code.NotFound, customErr.Code)
+}
+
+func (s *dependencySuite) TestRemoveDependency() {
+	ctx := context.Background()
+	s.Nil(s.taskRepo.AddDependency(ctx, 2, 1))
+	s.Nil(s.taskRepo.RemoveDependency(ctx, 2, 1))
+
+	blockers, customErr := s.taskRepo.GetBlockers(ctx, 2)
+	s.Nil(customErr)
+	s.Empty(blockers)
+
+	customErr = s.taskRepo.RemoveDependency(ctx, 2, 1)
+	s.NotNil(customErr)
+	s.Equal(code.NotFound, customErr.Code)
+}
+
+func (s *dependencySuite) TestDeletedBlockerDoesNotBlock() {
+	ctx := context.Background()
+	s.Nil(s.taskRepo.AddDependency(ctx, 2, 1))
+	s.Nil(s.taskRepo.DeleteTask(ctx, 1))
+
+	blockers, customErr := s.taskRepo.GetBlockers(ctx, 2)
+	s.Nil(customErr)
+	s.Empty(blockers)
+
+	// restoring the blocker brings the dependency back
+	s.Nil(s.taskRepo.RestoreTask(ctx, 1))
+	blockers, customErr = s.taskRepo.GetBlockers(ctx, 2)
+	s.Nil(customErr)
+	s.Equal([]int{1}, blockers)
+}
+
+func (s *dependencySuite) TestPurgeTaskDropsDependencies() {
+	ctx := context.Background()
+	s.Nil(s.taskRepo.AddDependency(ctx, 2, 1))
+	s.Nil(s.taskRepo.AddDependency(ctx, 1, 3))
+	s.Nil(s.taskRepo.DeleteTask(ctx, 1))
+	s.Nil(s.taskRepo.PurgeTask(ctx, 1))
+
+	graph, customErr := s.taskRepo.GetDependencies(ctx)
+	s.Nil(customErr)
+	s.Empty(graph)
+}
@@ -1,6 +1,11 @@
 package inmemory
 
-import "sync"
+import (
+	"sync"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/domain/model"
+)
 
 func getLen(m *sync.Map) int {
 	len := 0
@@ -10,3 +15,36 @@ func getLen(m *sync.Map) int {
 	})
 	return len
 }
+
+func toDomainTask(modelTask *model.Task) *domain.Task {
+	return &domain.Task{
+		ID:         modelTask.Id,
+		Name:       modelTask.Name,
+		Status:     modelTask.Status,
+		DueAt:      modelTask.DueAt,
+		Recurrence: modelTask.Recurrence,
+		DeletedAt:  modelTask.DeletedAt,
+		Version:    modelTask.Version,
+		OwnerID:    modelTask.OwnerId,
+		ExternalID: modelTask.ExternalId,
+	}
+}
+
+func toDomainTaskHistory(modelHistory *model.TaskHistory) *domain.TaskHistory {
+	history := &domain.TaskHistory{
+		ID:            modelHistory.Id,
+		TaskID:        modelHistory.TaskId,
+		Action:        modelHistory.Action,
+		Actor:         modelHistory.Actor,
+		ActorVerified: modelHistory.ActorVerified,
+		RequestID:     modelHistory.RequestId,
+		CreatedAt:     modelHistory.CreatedAt,
+	}
+	if modelHistory.Before != nil {
+		history.Before = toDomainTask(modelHistory.Before)
+	}
+	if modelHistory.After != nil {
+		history.After = toDomainTask(modelHistory.After)
+	}
+	return history
+}
@@ -0,0 +1,111 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/domain/model"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+)
+
+// GetTaskHistory will get a page of the history of a task, newest first
+func (i *inMemoryTaskRepo) GetTaskHistory(ctx context.Context, taskID int, pagination domain.Pagination) ([]*domain.TaskHistory, int, *code.CustomError) {
+	i.HistoryLock.RLock()
+	defer i.HistoryLock.RUnlock()
+
+	indexes, ok := i.TaskHistoryIndex[taskID]
+	if !ok {
+		return nil, 0, code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task not found"))
+	}
+
+	start, end := pagination.Bounds(len(indexes))
+	histories := make([]*domain.TaskHistory, 0, end-start)
+	for n := start; n < end; n++ {
+		histories = append(histories, toDomainTaskHistory(i.History[indexes[len(indexes)-1-n]]))
+	}
+	return histories, len(indexes), nil
+}
+
+// GetAuditLogs will get a page of the history of all tasks, newest first
+func (i *inMemoryTaskRepo) GetAuditLogs(ctx context.Context, params *domain.GetAuditLogsParams) ([]*domain.TaskHistory, int, *code.CustomError) {
+	i.HistoryLock.RLock()
+	defer i.HistoryLock.RUnlock()
+
+	var matched []*model.TaskHistory
+	for n := len(i.History) - 1; n >= 0; n-- {
+		history := i.History[n]
+		if params.Actor != nil && history.Actor != *params.Actor {
+			continue
+		}
+		if params.From != nil && history.CreatedAt.Before(*params.From) {
+			continue
+		}
+		if params.To != nil && history.CreatedAt.After(*params.To) {
+			continue
+		}
+		if params.OwnerID != nil && historyOwner(history) != *params.OwnerID {
+			continue
+		}
+		matched = append(matched, history)
+	}
+
+	start, end := params.Pagination.Bounds(len(matched))
+	histories := make([]*domain.TaskHistory, 0, end-start)
+	for _, history := range matched[start:end] {
+		histories = append(histories, toDomainTaskHistory(history))
+	}
+	return histories, len(matched), nil
+}
+
+// recordHistory appends a history entry of a task and counts the change in the revision.
+// before and after must not be modified afterwards, which holds as the stored tasks are copied on write.
+func (i *inMemoryTaskRepo) recordHistory(ctx context.Context, action domain.TaskAction, taskID int, before, after *model.Task) {
+	i.bumpRevision()
+
+	i.HistoryLock.Lock()
+	defer i.HistoryLock.Unlock()
+
+	history := &model.TaskHistory{
+		Id:            len(i.History) + 1,
+		TaskId:        taskID,
+		Action:        action,
+		Before:        before,
+		After:         after,
+		Actor:         requestctx.GetActor(ctx),
+		ActorVerified: requestctx.IsActorVerified(ctx),
+		RequestId:     requestctx.GetRequestID(ctx),
+		CreatedAt:     time.Now(),
+	}
+	i.History = append(i.History, history)
+	i.TaskHistoryIndex[taskID] = append(i.TaskHistoryIndex[taskID], len(i.History)-1)
+
+	// published under the lock, so the events keep the order of the history
+	if i.Publish != nil {
+		event := &domain.TaskEvent{
+			Action:     action,
+			Actor:      history.Actor,
+			OccurredAt: history.CreatedAt,
+			TenantID:   requestctx.GetTenantID(ctx),
+		}
+		if before != nil {
+			event.Before = toDomainTask(before)
+		}
+		if after != nil {
+			event.Task = toDomainTask(after)
+		} else {
+			event.Task = event.Before
+		}
+		i.Publish(event)
+	}
+}
+
+func historyOwner(history *model.TaskHistory) string {
+	if history.After != nil {
+		return history.After.OwnerId
+	}
+	return history.Before.OwnerId
+}
@@ -0,0 +1,115 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/stretchr/testify/suite"
+)
+
+type historySuite struct {
+	suite.Suite
+	taskRepo domain.TaskRepository
+}
+
+func TestHistorySuite(t *testing.T) {
+	suite.Run(t, new(historySuite))
+}
+
+func (s *historySuite) SetupTest() {
+	s.taskRepo = NewInMemoryTaskRepo()
+}
+
+func (s *historySuite) TestTaskHistory() {
+	ctx := requestctx.WithActor(context.Background(), "alice")
+	ctx = requestctx.WithRequestID(ctx, "request-1")
+
+	s.Nil(s.taskRepo.CreateTask(ctx, &domain.Task{Name: "task1"}))
+	s.Nil(s.taskRepo.UpdateTask(ctx, &domain.UpdateTaskParams{ID: 1, Name: util.Ptr("task1_new")}))
+	s.Nil(s.taskRepo.DeleteTask(ctx, 1))
+	s.Nil(s.taskRepo.RestoreTask(ctx, 1))
+
+	histories, total, customErr := s.taskRepo.GetTaskHistory(ctx, 1, domain.Pagination{Page: 1, PageSize: 10})
+	s.Nil(customErr)
+	s.Equal(4, total)
+	s.Equal(domain.TaskActionRestore, histories[0].Action)
+	s.Equal(domain.TaskActionDelete, histories[1].Action)
+	s.Equal(domain.TaskActionUpdate, histories[2].Action)
+	s.Equal(domain.TaskActionCreate, histories[3].Action)
+
+	update := histories[2]
+	s.Equal("task1", update.Before.Name)
+	s.Equal("task1_new", update.After.Name)
+	s.Equal("alice", update.Actor)
+	s.True(update.ActorVerified)
+	s.Equal("request-1", update.RequestID)
+
+	create := histories[3]
+	s.Nil(create.Before)
+	s.Equal("task1", create.After.Name)
+
+	// pagination
+	histories, total, customErr = s.taskRepo.GetTaskHistory(ctx, 1, domain.Pagination{Page: 2, PageSize: 3})
+	s.Nil(customErr)
+	s.Equal(4, total)
+	s.Equal(1, len(histories))
+	s.Equal(domain.TaskActionCreate, histories[0].Action)
+
+	_, _, customErr = s.taskRepo.GetTaskHistory(ctx, 2, domain.Pagination{Page: 1, PageSize: 10})
+	s.Equal(code.NotFound, customErr.Code)
+}
+
+func (s *historySuite) TestHistoryIsImmutable() {
+	ctx := context.Background()
+	s.Nil(s.taskRepo.CreateTask(ctx, &domain.Task{Name: "task1"}))
+
+	histories, _, customErr := s.taskRepo.GetTaskHistory(ctx, 1, domain.Pagination{Page: 1, PageSize: 10})
+	s.Nil(customErr)
+	histories[0].After.Name = "changed"
+
+	histories, _, customErr = s.taskRepo.GetTaskHistory(ctx, 1, domain.Pagination{Page: 1, PageSize: 10})
+	s.Nil(customErr)
+	s.Equal("task1", histories[0].After.Name)
+}
+
+func (s *historySuite) TestAuditLogs() {
+	alice := requestctx.WithActor(context.Background(), "alice")
+	bob := requestctx.WithActor(context.Background(), "bob")
+
+	s.Nil(s.taskRepo.CreateTask(alice, &domain.Task{Name: "task1"}))
+	s.Nil(s.taskRepo.CreateTask(bob, &domain.Task{Name: "task2"}))
+	checkpoint := time.Now()
+	s.Nil(s.taskRepo.DeleteTask(alice, 2))
+
+	histories, total, customErr := s.taskRepo.GetAuditLogs(alice, &domain.GetAuditLogsParams{
+		Actor:      util.Ptr("alice"),
+		Pagination: domain.Pagination{Page: 1, PageSize: 10},
+	})
+	s.Nil(customErr)
+	s.Equal(2, total)
+	s.Equal(domain.TaskActionDelete, histories[0].Action)
+	s.Equal(2, histories[0].TaskID)
+	s.Equal(1, histories[1].TaskID)
+
+	histories, total, customErr = s.taskRepo.GetAuditLogs(alice, &domain.GetAuditLogsParams{
+		From:       &checkpoint,
+		Pagination: domain.Pagination{Page: 1, PageSize: 10},
+	})
+	s.Nil(customErr)
+	s.Equal(1, total)
+	s.Equal(domain.TaskActionDelete, histories[0].Action)
+
+	histories, total, customErr = s.taskRepo.GetAuditLogs(alice, &domain.GetAuditLogsParams{
+		To:         &checkpoint,
+		Pagination: domain.Pagination{Page: 1, PageSize: 1},
+	})
+	s.Nil(customErr)
+	s.Equal(2, total)
+	s.Equal(1, len(histories))
+	s.Equal("bob", histories[0].Actor)
+}
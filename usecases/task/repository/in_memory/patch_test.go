@@ -0,0 +1,84 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/stretchr/testify/suite"
+)
+
+type patchSuite struct {
+	suite.Suite
+	taskRepo domain.TaskRepository
+}
+
+func TestPatchSuite(t *testing.T) {
+	suite.Run(t, new(patchSuite))
+}
+
+func (s *patchSuite) SetupTest() {
+	s.taskRepo = NewInMemoryTaskRepo()
+	s.Nil(s.taskRepo.CreateTask(context.Background(), &domain.Task{Name: "0"}))
+}
+
+func (s *patchSuite) TestPatchTaskIsAtomic() {
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, customErr := s.taskRepo.PatchTask(ctx, 1, func(current *domain.Task) (*domain.Task, *code.CustomError) {
+				n, err := strconv.Atoi(current.Name)
+				if err != nil {
+					return nil, code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+				}
+				current.Name = strconv.Itoa(n + 1)
+				return current, nil
+			})
+			s.Nil(customErr)
+		}()
+	}
+	wg.Wait()
+
+	task, customErr := s.taskRepo.GetTask(ctx, 1)
+	s.Nil(customErr)
+	s.Equal("20", task.Name)
+	s.Equal(21, task.Version)
+}
+
+func (s *patchSuite) TestPatchTaskKeepsReadOnlyFields() {
+	patched, customErr := s.taskRepo.PatchTask(context.Background(), 1, func(current *domain.Task) (*domain.Task, *code.CustomError) {
+		return &domain.Task{ID: 100, Name: "renamed", Status: domain.TaskStatusCompleted, Version: 100, OwnerID: "mallory"}, nil
+	})
+	s.Nil(customErr)
+	s.Equal(1, patched.ID)
+	s.Equal(2, patched.Version)
+	s.Equal("", patched.OwnerID)
+	s.Equal("renamed", patched.Name)
+	s.Equal(domain.TaskStatusCompleted, patched.Status)
+}
+
+func (s *patchSuite) TestPatchTaskFailed() {
+	_, customErr := s.taskRepo.PatchTask(context.Background(), 1, func(current *domain.Task) (*domain.Task, *code.CustomError) {
+		return nil, code.NewCustomError(code.PatchFailed, http.StatusConflict, fmt.Errorf("test failed"))
+	})
+	s.NotNil(customErr)
+	s.Equal(code.PatchFailed, customErr.Code)
+
+	task, customErr := s.taskRepo.GetTask(context.Background(), 1)
+	s.Nil(customErr)
+	s.Equal(1, task.Version)
+
+	_, customErr = s.taskRepo.PatchTask(context.Background(), 100, func(current *domain.Task) (*domain.Task, *code.CustomError) {
+		return current, nil
+	})
+	s.NotNil(customErr)
+	s.Equal(http.StatusNotFound, customErr.HttpStatus)
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/Yu-Qi/restful_api/domain"
 	"github.com/Yu-Qi/restful_api/domain/model"
 	"github.com/Yu-Qi/restful_api/pkg/code"
 	"github.com/Yu-Qi/restful_api/pkg/lock"
+	"github.com/Yu-Qi/restful_api/pkg/util"
 )
 
 var (
@@ -22,37 +24,137 @@ type inMemoryTaskRepo struct {
 	// WriteRowLock key: task id, value: sync.Mutex
 	WriteRowLock *lock.LockMap
 	TaskID       int
+	// ExternalIDs key: external id, value: task id, guarded by CreateLock
+	ExternalIDs map[string]int
+
+	// Dependencies key: task id, value: set of blocker task ids
+	Dependencies   map[int]map[int]struct{}
+	DependencyLock sync.RWMutex
+
+	// History is append only, TaskHistoryIndex key: task id, value: indexes in History
+	History          []*model.TaskHistory
+	TaskHistoryIndex map[int][]int
+	HistoryLock      sync.RWMutex
+
+	// Versions key: task id, value: snapshots of the task, the index is version - 1
+	Versions    map[int][]*model.TaskVersion
+	VersionLock sync.RWMutex
+
+	// Publish is called for every change recorded in History, nil if nobody subscribes
+	Publish domain.TaskEventPublisher
+
+	// Revision counts the changes of the tasks and the dependencies since CreatedAt, ModifiedAt is the time of
+	// the last one
+	Revision     int64
+	ModifiedAt   time.Time
+	CreatedAt    time.Time
+	RevisionLock sync.RWMutex
 }
 
-// NewInMemoryTaskRepo will create an object that represent the task.Repository interface
-func NewInMemoryTaskRepo() domain.TaskRepository {
+// newInMemoryTaskRepo will create the storage of a tenant
+func newInMemoryTaskRepo(publish domain.TaskEventPublisher) *inMemoryTaskRepo {
+	now := time.Now()
 	return &inMemoryTaskRepo{
 		StorageMap:   sync.Map{},
 		CreateLock:   sync.Mutex{},
 		WriteRowLock: lock.NewLockMap(lockWaitSecond),
 		TaskID:       0,
+		ExternalIDs:  map[string]int{},
+		Dependencies: map[int]map[int]struct{}{},
+
+		TaskHistoryIndex: map[int][]int{},
+		Versions:         map[int][]*model.TaskVersion{},
+		Publish:          publish,
+
+		ModifiedAt: now,
+		CreatedAt:  now,
 	}
 }
 
 // GetTasks will get all tasks
 func (i *inMemoryTaskRepo) GetTasks(ctx context.Context) ([]*domain.Task, *code.CustomError) {
 	var tasks []*domain.Task
+	customErr := i.RangeTasks(ctx, func(task *domain.Task) bool {
+		tasks = append(tasks, task)
+		return true
+	})
+	if customErr != nil {
+		return nil, customErr
+	}
 
-	i.StorageMap.Range(func(key, value interface{}) bool {
-		modelTask, ok := value.(*model.Task)
+	return tasks, nil
+}
+
+// RangeTasks will call fn for each task in id order until fn returns false,
+// the tasks created meanwhile are left out
+func (i *inMemoryTaskRepo) RangeTasks(ctx context.Context, fn func(task *domain.Task) bool) *code.CustomError {
+	i.CreateLock.Lock()
+	lastID := i.TaskID
+	i.CreateLock.Unlock()
+
+	for id := 1; id <= lastID; id++ {
+		value, ok := i.StorageMap.Load(id)
 		if !ok {
+			// purged
+			continue
+		}
+		modelTask, ok := value.(*model.Task)
+		if !ok || modelTask.DeletedAt != nil {
 			// skip
-			return true
+			continue
 		}
-		tasks = append(tasks, &domain.Task{
-			ID:     modelTask.Id,
-			Name:   modelTask.Name,
-			Status: modelTask.Status,
-		})
-		return true
-	})
+		if !fn(toDomainTask(modelTask)) {
+			break
+		}
+	}
 
-	return tasks, nil
+	return nil
+}
+
+// GetTaskRevision will get the revision of the tasks
+func (i *inMemoryTaskRepo) GetTaskRevision(ctx context.Context) (*domain.TaskRevision, *code.CustomError) {
+	i.RevisionLock.RLock()
+	defer i.RevisionLock.RUnlock()
+
+	return &domain.TaskRevision{
+		Epoch:      i.CreatedAt.UnixNano(),
+		Revision:   i.Revision,
+		ModifiedAt: i.ModifiedAt,
+	}, nil
+}
+
+// bumpRevision counts a change, it is called after the change is stored
+func (i *inMemoryTaskRepo) bumpRevision() {
+	i.RevisionLock.Lock()
+	defer i.RevisionLock.Unlock()
+
+	i.Revision++
+	i.ModifiedAt = time.Now()
+}
+
+// GetTask will get a task by id
+func (i *inMemoryTaskRepo) GetTask(ctx context.Context, id int) (*domain.Task, *code.CustomError) {
+	modelTask, ok := i.loadTask(id)
+	if !ok {
+		return nil, code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task not found"))
+	}
+
+	return toDomainTask(modelTask), nil
+}
+
+// GetTaskByExternalID will get a task by external id
+func (i *inMemoryTaskRepo) GetTaskByExternalID(ctx context.Context, externalID string) (*domain.Task, *code.CustomError) {
+	i.CreateLock.Lock()
+	id, ok := i.ExternalIDs[externalID]
+	i.CreateLock.Unlock()
+	if !ok {
+		return nil, code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task not found"))
+	}
+	if _, ok = i.loadDeletedTask(id); ok {
+		return nil, code.NewCustomError(code.Conflict, http.StatusConflict, fmt.Errorf("external id %s is used by a task in the trash", externalID))
+	}
+
+	return i.GetTask(ctx, id)
 }
 
 // CreateTask will create a task
@@ -60,12 +162,30 @@ func (i *inMemoryTaskRepo) CreateTask(ctx context.Context, task *domain.Task) *c
 	i.CreateLock.Lock()
 	defer i.CreateLock.Unlock()
 
+	if task.ExternalID != "" {
+		if _, ok := i.ExternalIDs[task.ExternalID]; ok {
+			return code.NewCustomError(code.Conflict, http.StatusConflict, fmt.Errorf("external id %s is used by another task", task.ExternalID))
+		}
+	}
+
 	i.TaskID++
-	i.StorageMap.Store(i.TaskID, &model.Task{
-		Id:     i.TaskID,
-		Name:   task.Name,
-		Status: task.Status,
-	})
+	task.ID = i.TaskID
+	modelTask := &model.Task{
+		Id:         i.TaskID,
+		Name:       task.Name,
+		Status:     task.Status,
+		DueAt:      task.DueAt,
+		Recurrence: task.Recurrence,
+		Version:    1,
+		OwnerId:    task.OwnerID,
+		ExternalId: task.ExternalID,
+	}
+	if task.ExternalID != "" {
+		i.ExternalIDs[task.ExternalID] = i.TaskID
+	}
+	i.StorageMap.Store(i.TaskID, modelTask)
+	i.recordVersion(ctx, modelTask)
+	i.recordHistory(ctx, domain.TaskActionCreate, i.TaskID, nil, modelTask)
 	return nil
 }
 
@@ -81,23 +201,65 @@ func (i *inMemoryTaskRepo) UpdateTask(ctx context.Context, params *domain.Update
 	}
 	defer i.WriteRowLock.Unlock(params.ID)
 
-	modelTask, ok := i.StorageMap.Load(params.ID)
+	modelTask, ok := i.loadTask(params.ID)
 	if !ok {
 		return code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task not found"))
 	}
 
+	// copy on write, readers may still hold the stored one
+	updated := *modelTask
 	if params.Name != nil {
-		modelTask.(*model.Task).Name = *params.Name
+		updated.Name = *params.Name
 	}
 	if params.Status != nil {
-		modelTask.(*model.Task).Status = *params.Status
+		updated.Status = *params.Status
+	}
+	if params.DueAt != nil {
+		updated.DueAt = params.DueAt
 	}
+	if params.Recurrence != nil {
+		updated.Recurrence = params.Recurrence
+	}
+	updated.Version++
 
-	i.StorageMap.Store(params.ID, modelTask)
+	i.StorageMap.Store(params.ID, &updated)
+	i.recordVersion(ctx, &updated)
+	i.recordHistory(ctx, domain.TaskActionUpdate, params.ID, modelTask, &updated)
 	return nil
 }
 
-// DeleteTask will delete a task
+// PatchTask will replace the mutable fields of a task by the ones computed by patch
+func (i *inMemoryTaskRepo) PatchTask(ctx context.Context, id int, patch domain.TaskPatchFunc) (*domain.Task, *code.CustomError) {
+	err := i.WriteRowLock.Lock(id)
+	if err != nil {
+		return nil, code.NewCustomError(code.Timeout, http.StatusInternalServerError, err)
+	}
+	defer i.WriteRowLock.Unlock(id)
+
+	modelTask, ok := i.loadTask(id)
+	if !ok {
+		return nil, code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task not found"))
+	}
+
+	patched, customErr := patch(toDomainTask(modelTask))
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	updated := *modelTask
+	updated.Name = patched.Name
+	updated.Status = patched.Status
+	updated.DueAt = patched.DueAt
+	updated.Recurrence = patched.Recurrence
+	updated.Version++
+
+	i.StorageMap.Store(id, &updated)
+	i.recordVersion(ctx, &updated)
+	i.recordHistory(ctx, domain.TaskActionUpdate, id, modelTask, &updated)
+	return toDomainTask(&updated), nil
+}
+
+// DeleteTask will move a task to the trash
 func (i *inMemoryTaskRepo) DeleteTask(ctx context.Context, id int) *code.CustomError {
 	err := i.WriteRowLock.Lock(id)
 	if err != nil {
@@ -105,10 +267,28 @@ func (i *inMemoryTaskRepo) DeleteTask(ctx context.Context, id int) *code.CustomE
 	}
 	defer i.WriteRowLock.Unlock(id)
 
-	_, existing := i.StorageMap.LoadAndDelete(id)
-	if !existing {
+	modelTask, ok := i.loadTask(id)
+	if !ok {
 		return code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task not found"))
 	}
 
+	deleted := *modelTask
+	deleted.DeletedAt = util.Ptr(time.Now())
+	i.StorageMap.Store(id, &deleted)
+	i.recordHistory(ctx, domain.TaskActionDelete, id, modelTask, &deleted)
 	return nil
 }
+
+// loadTask loads a task which is not in the trash
+func (i *inMemoryTaskRepo) loadTask(id int) (*model.Task, bool) {
+	value, ok := i.StorageMap.Load(id)
+	if !ok {
+		return nil, false
+	}
+
+	modelTask := value.(*model.Task)
+	if modelTask.DeletedAt != nil {
+		return nil, false
+	}
+	return modelTask, true
+}
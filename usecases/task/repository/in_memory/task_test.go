@@ -2,6 +2,7 @@ package inmemory
 
 import (
 	"context"
+	"net/http"
 	"sync"
 	"testing"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/Yu-Qi/restful_api/domain"
 	"github.com/Yu-Qi/restful_api/domain/seed"
 	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
 	"github.com/Yu-Qi/restful_api/pkg/util"
 	"github.com/stretchr/testify/suite"
 )
@@ -41,6 +43,54 @@ func (s *getTaskSuite) TestGetTasks() {
 	s.Equal(5, len(tasks))
 }
 
+func (s *getTaskSuite) TestRangeTasks() {
+	ctx := context.Background()
+	s.Nil(s.taskRepo.DeleteTask(ctx, 1))
+
+	var ids []int
+	customErr := s.taskRepo.RangeTasks(ctx, func(task *domain.Task) bool {
+		ids = append(ids, task.ID)
+		return true
+	})
+	s.Nil(customErr)
+	s.ElementsMatch([]int{2, 3, 4, 5}, ids)
+
+	// stops once fn returns false
+	visited := 0
+	customErr = s.taskRepo.RangeTasks(ctx, func(task *domain.Task) bool {
+		visited++
+		return visited < 2
+	})
+	s.Nil(customErr)
+	s.Equal(2, visited)
+}
+
+func (s *getTaskSuite) TestGetTaskRevision() {
+	ctx := context.Background()
+	revision, customErr := s.taskRepo.GetTaskRevision(ctx)
+	s.Nil(customErr)
+	s.Equal(int64(5), revision.Revision)
+
+	// every change counts, the failed ones do not
+	s.Nil(s.taskRepo.UpdateTask(ctx, &domain.UpdateTaskParams{ID: 1, Name: util.Ptr("renamed")}))
+	s.Nil(s.taskRepo.AddDependency(ctx, 1, 3))
+	s.Nil(s.taskRepo.RemoveDependency(ctx, 1, 3))
+	s.Nil(s.taskRepo.DeleteTask(ctx, 1))
+	s.NotNil(s.taskRepo.DeleteTask(ctx, 1))
+	s.Nil(s.taskRepo.RestoreTask(ctx, 1))
+	actual, customErr := s.taskRepo.GetTaskRevision(ctx)
+	s.Nil(customErr)
+	s.Equal(revision.Epoch, actual.Epoch)
+	s.Equal(int64(10), actual.Revision)
+	s.False(actual.ModifiedAt.Before(revision.ModifiedAt))
+
+	// the tenants count apart
+	other, customErr := s.taskRepo.GetTaskRevision(requestctx.WithTenantID(ctx, "other"))
+	s.Nil(customErr)
+	s.Equal(int64(0), other.Revision)
+	s.NotEqual(revision.Epoch, other.Epoch)
+}
+
 type createTaskSuite struct {
 	suite.Suite
 	taskRepo domain.TaskRepository
@@ -68,6 +118,33 @@ func (s *createTaskSuite) TestCreateTask() {
 	s.Equal(len(seed.Tasks()), len(actualTasks))
 }
 
+func (s *createTaskSuite) TestExternalID() {
+	ctx := context.Background()
+	s.Nil(s.taskRepo.CreateTask(ctx, &domain.Task{Name: "imported", ExternalID: "ext-1"}))
+
+	task, customErr := s.taskRepo.GetTaskByExternalID(ctx, "ext-1")
+	s.Nil(customErr)
+	s.Equal(1, task.ID)
+	s.Equal("ext-1", task.ExternalID)
+
+	customErr = s.taskRepo.CreateTask(ctx, &domain.Task{Name: "duplicate", ExternalID: "ext-1"})
+	s.NotNil(customErr)
+	s.Equal(http.StatusConflict, customErr.HttpStatus)
+	s.Equal(code.Conflict, customErr.Code)
+
+	// a trashed task keeps its external id until it is purged, the lookup reports the conflict of CreateTask
+	s.Nil(s.taskRepo.DeleteTask(ctx, 1))
+	_, customErr = s.taskRepo.GetTaskByExternalID(ctx, "ext-1")
+	s.Equal(code.Conflict, customErr.Code)
+	s.NotNil(s.taskRepo.CreateTask(ctx, &domain.Task{Name: "duplicate", ExternalID: "ext-1"}))
+
+	s.Nil(s.taskRepo.PurgeTask(ctx, 1))
+	s.Nil(s.taskRepo.CreateTask(ctx, &domain.Task{Name: "again", ExternalID: "ext-1"}))
+	task, customErr = s.taskRepo.GetTaskByExternalID(ctx, "ext-1")
+	s.Nil(customErr)
+	s.Equal("again", task.Name)
+}
+
 type updateTaskSuite struct {
 	suite.Suite
 	taskRepo domain.TaskRepository
@@ -0,0 +1,161 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+)
+
+// tenantTaskRepo partitions the tasks by tenant, every tenant has its own storage and id sequence
+type tenantTaskRepo struct {
+	// Tenants key: tenant id, value: *inMemoryTaskRepo
+	Tenants sync.Map
+	Publish domain.TaskEventPublisher
+	// CreatedAt is the epoch of the tenants without a storage
+	CreatedAt time.Time
+}
+
+// Option configures the repository created by NewInMemoryTaskRepo
+type Option func(*tenantTaskRepo)
+
+// WithEventPublisher publishes an event for every change of the tasks
+func WithEventPublisher(publish domain.TaskEventPublisher) Option {
+	return func(t *tenantTaskRepo) {
+		t.Publish = publish
+	}
+}
+
+// NewInMemoryTaskRepo will create an object that represent the task.Repository interface
+func NewInMemoryTaskRepo(options ...Option) domain.TaskRepository {
+	repo := &tenantTaskRepo{CreatedAt: time.Now()}
+	for _, option := range options {
+		option(repo)
+	}
+	return repo
+}
+
+// tenant returns the storage of the tenant carried by ctx. A tenant without a storage reads as an empty one
+// which is not kept, so that naming a tenant in a request does not allocate a storage.
+func (t *tenantTaskRepo) tenant(ctx context.Context) *inMemoryTaskRepo {
+	if repo, ok := t.Tenants.Load(requestctx.GetTenantID(ctx)); ok {
+		return repo.(*inMemoryTaskRepo)
+	}
+	empty := newInMemoryTaskRepo(nil)
+	empty.CreatedAt = t.CreatedAt
+	empty.ModifiedAt = t.CreatedAt
+	return empty
+}
+
+// provision returns the storage of the tenant carried by ctx, it is created with the first task of the tenant
+func (t *tenantTaskRepo) provision(ctx context.Context) *inMemoryTaskRepo {
+	tenantID := requestctx.GetTenantID(ctx)
+	if repo, ok := t.Tenants.Load(tenantID); ok {
+		return repo.(*inMemoryTaskRepo)
+	}
+	repo, _ := t.Tenants.LoadOrStore(tenantID, newInMemoryTaskRepo(t.Publish))
+	return repo.(*inMemoryTaskRepo)
+}
+
+// GetTenantIDs will get the tenants having a storage, ordered by id
+func (t *tenantTaskRepo) GetTenantIDs(ctx context.Context) ([]string, *code.CustomError) {
+	tenantIDs := []string{}
+	t.Tenants.Range(func(key, _ interface{}) bool {
+		tenantIDs = append(tenantIDs, key.(string))
+		return true
+	})
+	sort.Strings(tenantIDs)
+	return tenantIDs, nil
+}
+
+func (t *tenantTaskRepo) GetTasks(ctx context.Context) ([]*domain.Task, *code.CustomError) {
+	return t.tenant(ctx).GetTasks(ctx)
+}
+
+func (t *tenantTaskRepo) RangeTasks(ctx context.Context, fn func(task *domain.Task) bool) *code.CustomError {
+	return t.tenant(ctx).RangeTasks(ctx, fn)
+}
+
+func (t *tenantTaskRepo) GetTaskRevision(ctx context.Context) (*domain.TaskRevision, *code.CustomError) {
+	return t.tenant(ctx).GetTaskRevision(ctx)
+}
+
+func (t *tenantTaskRepo) GetTask(ctx context.Context, id int) (*domain.Task, *code.CustomError) {
+	return t.tenant(ctx).GetTask(ctx, id)
+}
+
+func (t *tenantTaskRepo) GetTaskByExternalID(ctx context.Context, externalID string) (*domain.Task, *code.CustomError) {
+	return t.tenant(ctx).GetTaskByExternalID(ctx, externalID)
+}
+
+func (t *tenantTaskRepo) CreateTask(ctx context.Context, task *domain.Task) *code.CustomError {
+	return t.provision(ctx).CreateTask(ctx, task)
+}
+
+func (t *tenantTaskRepo) UpdateTask(ctx context.Context, params *domain.UpdateTaskParams) *code.CustomError {
+	return t.tenant(ctx).UpdateTask(ctx, params)
+}
+
+func (t *tenantTaskRepo) PatchTask(ctx context.Context, id int, patch domain.TaskPatchFunc) (*domain.Task, *code.CustomError) {
+	return t.tenant(ctx).PatchTask(ctx, id, patch)
+}
+
+func (t *tenantTaskRepo) DeleteTask(ctx context.Context, id int) *code.CustomError {
+	return t.tenant(ctx).DeleteTask(ctx, id)
+}
+
+func (t *tenantTaskRepo) GetDeletedTasks(ctx context.Context) ([]*domain.Task, *code.CustomError) {
+	return t.tenant(ctx).GetDeletedTasks(ctx)
+}
+
+func (t *tenantTaskRepo) GetDeletedTask(ctx context.Context, id int) (*domain.Task, *code.CustomError) {
+	return t.tenant(ctx).GetDeletedTask(ctx, id)
+}
+
+func (t *tenantTaskRepo) RestoreTask(ctx context.Context, id int) *code.CustomError {
+	return t.tenant(ctx).RestoreTask(ctx, id)
+}
+
+func (t *tenantTaskRepo) PurgeTask(ctx context.Context, id int) *code.CustomError {
+	return t.tenant(ctx).PurgeTask(ctx, id)
+}
+
+func (t *tenantTaskRepo) PurgeDeletedTasks(ctx context.Context, before time.Time) (int, *code.CustomError) {
+	return t.tenant(ctx).PurgeDeletedTasks(ctx, before)
+}
+
+func (t *tenantTaskRepo) GetTaskVersions(ctx context.Context, id int) ([]*domain.TaskVersion, *code.CustomError) {
+	return t.tenant(ctx).GetTaskVersions(ctx, id)
+}
+
+func (t *tenantTaskRepo) RevertTask(ctx context.Context, id, version int) ([]*domain.TaskFieldDiff, *code.CustomError) {
+	return t.tenant(ctx).RevertTask(ctx, id, version)
+}
+
+func (t *tenantTaskRepo) GetTaskHistory(ctx context.Context, taskID int, pagination domain.Pagination) ([]*domain.TaskHistory, int, *code.CustomError) {
+	return t.tenant(ctx).GetTaskHistory(ctx, taskID, pagination)
+}
+
+func (t *tenantTaskRepo) GetAuditLogs(ctx context.Context, params *domain.GetAuditLogsParams) ([]*domain.TaskHistory, int, *code.CustomError) {
+	return t.tenant(ctx).GetAuditLogs(ctx, params)
+}
+
+func (t *tenantTaskRepo) AddDependency(ctx context.Context, taskID, blockerID int) *code.CustomError {
+	return t.tenant(ctx).AddDependency(ctx, taskID, blockerID)
+}
+
+func (t *tenantTaskRepo) RemoveDependency(ctx context.Context, taskID, blockerID int) *code.CustomError {
+	return t.tenant(ctx).RemoveDependency(ctx, taskID, blockerID)
+}
+
+func (t *tenantTaskRepo) GetBlockers(ctx context.Context, taskID int) ([]int, *code.CustomError) {
+	return t.tenant(ctx).GetBlockers(ctx, taskID)
+}
+
+func (t *tenantTaskRepo) GetDependencies(ctx context.Context) (map[int][]int, *code.CustomError) {
+	return t.tenant(ctx).GetDependencies(ctx)
+}
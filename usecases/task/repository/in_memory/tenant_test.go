@@ -0,0 +1,114 @@
+package inmemory
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/stretchr/testify/suite"
+)
+
+type tenantSuite struct {
+	suite.Suite
+	taskRepo domain.TaskRepository
+	acme     context.Context
+	globex   context.Context
+}
+
+func TestTenantSuite(t *testing.T) {
+	suite.Run(t, new(tenantSuite))
+}
+
+func (s *tenantSuite) SetupTest() {
+	s.taskRepo = NewInMemoryTaskRepo()
+	s.acme = requestctx.WithTenantID(context.Background(), "acme")
+	s.globex = requestctx.WithTenantID(context.Background(), "globex")
+
+	s.Nil(s.taskRepo.CreateTask(s.acme, &domain.Task{Name: "acme 1"}))
+	s.Nil(s.taskRepo.CreateTask(s.acme, &domain.Task{Name: "acme 2"}))
+	s.Nil(s.taskRepo.CreateTask(s.globex, &domain.Task{Name: "globex 1"}))
+}
+
+func (s *tenantSuite) TestIDSequencePerTenant() {
+	task := &domain.Task{Name: "globex 2"}
+	s.Nil(s.taskRepo.CreateTask(s.globex, task))
+	s.Equal(2, task.ID)
+
+	task = &domain.Task{Name: "default 1"}
+	s.Nil(s.taskRepo.CreateTask(context.Background(), task))
+	s.Equal(1, task.ID)
+
+	tenantIDs, customErr := s.taskRepo.GetTenantIDs(context.Background())
+	s.Nil(customErr)
+	s.Equal([]string{"acme", requestctx.DefaultTenantID, "globex"}, tenantIDs)
+}
+
+func (s *tenantSuite) TestCannotReadAnotherTenant() {
+	tasks, customErr := s.taskRepo.GetTasks(s.globex)
+	s.Nil(customErr)
+	s.Equal(1, len(tasks))
+	s.Equal("globex 1", tasks[0].Name)
+
+	_, customErr = s.taskRepo.GetTask(s.globex, 2)
+	s.NotNil(customErr)
+	s.Equal(http.StatusNotFound, customErr.HttpStatus)
+
+	task, customErr := s.taskRepo.GetTask(s.globex, 1)
+	s.Nil(customErr)
+	s.Equal("globex 1", task.Name)
+
+	histories, total, customErr := s.taskRepo.GetAuditLogs(s.globex, &domain.GetAuditLogsParams{Pagination: domain.Pagination{Page: 1, PageSize: 10}})
+	s.Nil(customErr)
+	s.Equal(1, total)
+	s.Equal("globex 1", histories[0].After.Name)
+}
+
+func (s *tenantSuite) TestCannotUpdateOrDeleteAnotherTenant() {
+	customErr := s.taskRepo.UpdateTask(s.globex, &domain.UpdateTaskParams{ID: 2, Name: util.Ptr("hijacked")})
+	s.NotNil(customErr)
+	s.Equal(http.StatusNotFound, customErr.HttpStatus)
+	customErr = s.taskRepo.DeleteTask(s.globex, 2)
+	s.NotNil(customErr)
+	s.Equal(http.StatusNotFound, customErr.HttpStatus)
+
+	// the same id in another tenant is another task
+	s.Nil(s.taskRepo.UpdateTask(s.globex, &domain.UpdateTaskParams{ID: 1, Name: util.Ptr("renamed")}))
+	s.Nil(s.taskRepo.DeleteTask(s.globex, 1))
+	purged, customErr := s.taskRepo.PurgeDeletedTasks(s.acme, time.Now().Add(time.Second))
+	s.Nil(customErr)
+	s.Equal(0, purged)
+
+	task, customErr := s.taskRepo.GetTask(s.acme, 1)
+	s.Nil(customErr)
+	s.Equal("acme 1", task.Name)
+	tasks, customErr := s.taskRepo.GetDeletedTasks(s.acme)
+	s.Nil(customErr)
+	s.Equal(0, len(tasks))
+}
+
+func (s *tenantSuite) TestReadsDoNotProvision() {
+	initech := requestctx.WithTenantID(context.Background(), "initech")
+	tasks, customErr := s.taskRepo.GetTasks(initech)
+	s.Nil(customErr)
+	s.Empty(tasks)
+	_, customErr = s.taskRepo.GetTask(initech, 1)
+	s.Equal(http.StatusNotFound, customErr.HttpStatus)
+	first, customErr := s.taskRepo.GetTaskRevision(initech)
+	s.Nil(customErr)
+	second, customErr := s.taskRepo.GetTaskRevision(initech)
+	s.Nil(customErr)
+	s.Equal(first, second)
+
+	tenantIDs, customErr := s.taskRepo.GetTenantIDs(context.Background())
+	s.Nil(customErr)
+	s.Equal([]string{"acme", "globex"}, tenantIDs)
+
+	s.Nil(s.taskRepo.CreateTask(initech, &domain.Task{Name: "initech 1"}))
+	tenantIDs, customErr = s.taskRepo.GetTenantIDs(context.Background())
+	s.Nil(customErr)
+	s.Equal([]string{"acme", "globex", "initech"}, tenantIDs)
+}
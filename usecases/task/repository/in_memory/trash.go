@@ -0,0 +1,134 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/domain/model"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// GetDeletedTasks will get all tasks in the trash in id order
+func (i *inMemoryTaskRepo) GetDeletedTasks(ctx context.Context) ([]*domain.Task, *code.CustomError) {
+	var tasks []*domain.Task
+
+	i.StorageMap.Range(func(key, value interface{}) bool {
+		modelTask, ok := value.(*model.Task)
+		if !ok || modelTask.DeletedAt == nil {
+			// skip
+			return true
+		}
+		tasks = append(tasks, toDomainTask(modelTask))
+		return true
+	})
+	// the map is ranged in no order, the pages of the trash need a stable one
+	sort.Slice(tasks, func(a, b int) bool { return tasks[a].ID < tasks[b].ID })
+
+	return tasks, nil
+}
+
+// GetDeletedTask will get a task in the trash by id
+func (i *inMemoryTaskRepo) GetDeletedTask(ctx context.Context, id int) (*domain.Task, *code.CustomError) {
+	modelTask, ok := i.loadDeletedTask(id)
+	if !ok {
+		return nil, code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task not found in trash"))
+	}
+
+	return toDomainTask(modelTask), nil
+}
+
+// RestoreTask will move a task out of the trash
+func (i *inMemoryTaskRepo) RestoreTask(ctx context.Context, id int) *code.CustomError {
+	err := i.WriteRowLock.Lock(id)
+	if err != nil {
+		return code.NewCustomError(code.Timeout, http.StatusInternalServerError, err)
+	}
+	defer i.WriteRowLock.Unlock(id)
+
+	modelTask, ok := i.loadDeletedTask(id)
+	if !ok {
+		return code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task not found in trash"))
+	}
+
+	restored := *modelTask
+	restored.DeletedAt = nil
+	i.StorageMap.Store(id, &restored)
+	i.recordHistory(ctx, domain.TaskActionRestore, id, modelTask, &restored)
+	return nil
+}
+
+// PurgeTask will permanently remove a task from the trash
+func (i *inMemoryTaskRepo) PurgeTask(ctx context.Context, id int) *code.CustomError {
+	return i.purgeTask(ctx, id, time.Time{})
+}
+
+// PurgeDeletedTasks will permanently remove the tasks trashed before the given time
+func (i *inMemoryTaskRepo) PurgeDeletedTasks(ctx context.Context, before time.Time) (int, *code.CustomError) {
+	var expiredIDs []int
+	i.StorageMap.Range(func(key, value interface{}) bool {
+		modelTask, ok := value.(*model.Task)
+		if ok && modelTask.DeletedAt != nil && modelTask.DeletedAt.Before(before) {
+			expiredIDs = append(expiredIDs, modelTask.Id)
+		}
+		return true
+	})
+
+	purged := 0
+	for _, id := range expiredIDs {
+		customErr := i.purgeTask(ctx, id, before)
+		if customErr != nil {
+			if customErr.Code == code.NotFound {
+				// restored, purged or trashed again in the meantime
+				continue
+			}
+			return purged, customErr
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purgeTask removes a task in the trash, a non-zero before only purges it when trashed before that time
+func (i *inMemoryTaskRepo) purgeTask(ctx context.Context, id int, before time.Time) *code.CustomError {
+	err := i.WriteRowLock.Lock(id)
+	if err != nil {
+		return code.NewCustomError(code.Timeout, http.StatusInternalServerError, err)
+	}
+	defer i.WriteRowLock.Unlock(id)
+
+	modelTask, ok := i.loadDeletedTask(id)
+	if !ok || (!before.IsZero() && !modelTask.DeletedAt.Before(before)) {
+		return code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task not found in trash"))
+	}
+
+	i.StorageMap.Delete(id)
+	if modelTask.ExternalId != "" {
+		i.CreateLock.Lock()
+		delete(i.ExternalIDs, modelTask.ExternalId)
+		i.CreateLock.Unlock()
+	}
+	i.removeDependenciesOf(id)
+	i.VersionLock.Lock()
+	delete(i.Versions, id)
+	i.VersionLock.Unlock()
+	i.recordHistory(ctx, domain.TaskActionPurge, id, modelTask, nil)
+	return nil
+}
+
+// loadDeletedTask loads a task which is in the trash
+func (i *inMemoryTaskRepo) loadDeletedTask(id int) (*model.Task, bool) {
+	value, ok := i.StorageMap.Load(id)
+	if !ok {
+		return nil, false
+	}
+
+	modelTask := value.(*model.Task)
+	if modelTask.DeletedAt == nil {
+		return nil, false
+	}
+	return modelTask, true
+}
@@ -0,0 +1,123 @@
+package inmemory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/domain/seed"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/stretchr/testify/suite"
+)
+
+type trashSuite struct {
+	suite.Suite
+	taskRepo domain.TaskRepository
+}
+
+func TestTrashSuite(t *testing.T) {
+	suite.Run(t, new(trashSuite))
+}
+
+func (s *trashSuite) SetupTest() {
+	s.taskRepo = NewInMemoryTaskRepo()
+
+	// setup data
+	for _, task := range seed.Tasks() {
+		s.taskRepo.CreateTask(context.Background(), task)
+	}
+}
+
+func (s *trashSuite) TestDeletedTaskIsHidden() {
+	ctx := context.Background()
+	s.Nil(s.taskRepo.DeleteTask(ctx, 1))
+
+	tasks, customErr := s.taskRepo.GetTasks(ctx)
+	s.Nil(customErr)
+	s.Equal(len(seed.Tasks())-1, len(tasks))
+
+	_, customErr = s.taskRepo.GetTask(ctx, 1)
+	s.Equal(code.NotFound, customErr.Code)
+
+	customErr = s.taskRepo.UpdateTask(ctx, &domain.UpdateTaskParams{ID: 1, Name: util.Ptr("test")})
+	s.Equal(code.NotFound, customErr.Code)
+
+	customErr = s.taskRepo.DeleteTask(ctx, 1)
+	s.Equal(code.NotFound, customErr.Code)
+
+	deletedTasks, customErr := s.taskRepo.GetDeletedTasks(ctx)
+	s.Nil(customErr)
+	s.Equal(1, len(deletedTasks))
+	s.Equal(1, deletedTasks[0].ID)
+	s.NotNil(deletedTasks[0].DeletedAt)
+}
+
+func (s *trashSuite) TestDeletedTasksOrder() {
+	ctx := context.Background()
+	var ids []int
+	for i := 0; i < 20; i++ {
+		task := &domain.Task{Name: "task"}
+		s.Nil(s.taskRepo.CreateTask(ctx, task))
+		s.Nil(s.taskRepo.DeleteTask(ctx, task.ID))
+		ids = append(ids, task.ID)
+	}
+
+	deletedTasks, customErr := s.taskRepo.GetDeletedTasks(ctx)
+	s.Nil(customErr)
+	s.Require().Equal(len(ids), len(deletedTasks))
+	for i, task := range deletedTasks {
+		s.Equal(ids[i], task.ID)
+	}
+}
+
+func (s *trashSuite) TestRestoreTask() {
+	ctx := context.Background()
+	s.Nil(s.taskRepo.DeleteTask(ctx, 1))
+	s.Nil(s.taskRepo.RestoreTask(ctx, 1))
+
+	task, customErr := s.taskRepo.GetTask(ctx, 1)
+	s.Nil(customErr)
+	s.Nil(task.DeletedAt)
+
+	// only tasks in the trash can be restored
+	customErr = s.taskRepo.RestoreTask(ctx, 1)
+	s.Equal(code.NotFound, customErr.Code)
+}
+
+func (s *trashSuite) TestPurgeTask() {
+	ctx := context.Background()
+
+	// only tasks in the trash can be purged
+	customErr := s.taskRepo.PurgeTask(ctx, 1)
+	s.Equal(code.NotFound, customErr.Code)
+
+	s.Nil(s.taskRepo.DeleteTask(ctx, 1))
+	s.Nil(s.taskRepo.PurgeTask(ctx, 1))
+
+	deletedTasks, customErr := s.taskRepo.GetDeletedTasks(ctx)
+	s.Nil(customErr)
+	s.Empty(deletedTasks)
+
+	customErr = s.taskRepo.RestoreTask(ctx, 1)
+	s.Equal(code.NotFound, customErr.Code)
+}
+
+func (s *trashSuite) TestPurgeDeletedTasks() {
+	ctx := context.Background()
+	s.Nil(s.taskRepo.DeleteTask(ctx, 1))
+	s.Nil(s.taskRepo.DeleteTask(ctx, 2))
+
+	purged, customErr := s.taskRepo.PurgeDeletedTasks(ctx, time.Now().Add(-time.Hour))
+	s.Nil(customErr)
+	s.Equal(0, purged)
+
+	purged, customErr = s.taskRepo.PurgeDeletedTasks(ctx, time.Now().Add(time.Second))
+	s.Nil(customErr)
+	s.Equal(2, purged)
+
+	deletedTasks, customErr := s.taskRepo.GetDeletedTasks(ctx)
+	s.Nil(customErr)
+	s.Empty(deletedTasks)
+}
@@ -0,0 +1,109 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/domain/model"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+)
+
+// GetTaskVersions will get every version of a task, oldest first
+func (i *inMemoryTaskRepo) GetTaskVersions(ctx context.Context, id int) ([]*domain.TaskVersion, *code.CustomError) {
+	if _, ok := i.loadTask(id); !ok {
+		return nil, code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task not found"))
+	}
+
+	i.VersionLock.RLock()
+	defer i.VersionLock.RUnlock()
+
+	versions := make([]*domain.TaskVersion, 0, len(i.Versions[id]))
+	for _, modelVersion := range i.Versions[id] {
+		versions = append(versions, &domain.TaskVersion{
+			Version:       modelVersion.Task.Version,
+			Task:          toDomainTask(modelVersion.Task),
+			Actor:         modelVersion.Actor,
+			ActorVerified: modelVersion.ActorVerified,
+			CreatedAt:     modelVersion.CreatedAt,
+		})
+	}
+	return versions, nil
+}
+
+// RevertTask will restore the fields of a task to the given version as a new version
+func (i *inMemoryTaskRepo) RevertTask(ctx context.Context, id, version int) ([]*domain.TaskFieldDiff, *code.CustomError) {
+	err := i.WriteRowLock.Lock(id)
+	if err != nil {
+		return nil, code.NewCustomError(code.Timeout, http.StatusInternalServerError, err)
+	}
+	defer i.WriteRowLock.Unlock(id)
+
+	modelTask, ok := i.loadTask(id)
+	if !ok {
+		return nil, code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task not found"))
+	}
+
+	i.VersionLock.RLock()
+	versions := i.Versions[id]
+	i.VersionLock.RUnlock()
+	if version < 1 || version > len(versions) {
+		return nil, code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("version %d not found", version))
+	}
+	target := versions[version-1].Task
+
+	reverted := *modelTask
+	reverted.Name = target.Name
+	reverted.Status = target.Status
+	reverted.DueAt = target.DueAt
+	reverted.Recurrence = target.Recurrence
+	reverted.Version++
+
+	i.StorageMap.Store(id, &reverted)
+	i.recordVersion(ctx, &reverted)
+	i.recordHistory(ctx, domain.TaskActionRevert, id, modelTask, &reverted)
+	return diffTask(modelTask, &reverted), nil
+}
+
+// recordVersion appends a snapshot of a task, the snapshot must not be modified afterwards
+func (i *inMemoryTaskRepo) recordVersion(ctx context.Context, modelTask *model.Task) {
+	i.VersionLock.Lock()
+	defer i.VersionLock.Unlock()
+
+	i.Versions[modelTask.Id] = append(i.Versions[modelTask.Id], &model.TaskVersion{
+		Task:          modelTask,
+		Actor:         requestctx.GetActor(ctx),
+		ActorVerified: requestctx.IsActorVerified(ctx),
+		CreatedAt:     time.Now(),
+	})
+}
+
+// diffTask lists the user editable fields changed from before to after
+func diffTask(before, after *model.Task) []*domain.TaskFieldDiff {
+	fields := []struct {
+		name     string
+		from, to interface{}
+	}{
+		{"name", before.Name, after.Name},
+		{"status", before.Status, after.Status},
+		{"due_at", before.DueAt, after.DueAt},
+		{"recurrence", before.Recurrence, after.Recurrence},
+	}
+
+	diffs := []*domain.TaskFieldDiff{}
+	for _, field := range fields {
+		if reflect.DeepEqual(field.from, field.to) {
+			continue
+		}
+		diffs = append(diffs, &domain.TaskFieldDiff{
+			Field: field.name,
+			From:  field.from,
+			To:    field.to,
+		})
+	}
+	return diffs
+}
@@ -0,0 +1,89 @@
+package inmemory
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/stretchr/testify/suite"
+)
+
+type versionSuite struct {
+	suite.Suite
+	taskRepo domain.TaskRepository
+}
+
+func TestVersionSuite(t *testing.T) {
+	suite.Run(t, new(versionSuite))
+}
+
+func (s *versionSuite) SetupTest() {
+	s.taskRepo = NewInMemoryTaskRepo()
+
+	ctx := context.Background()
+	s.Nil(s.taskRepo.CreateTask(ctx, &domain.Task{Name: "v1"}))
+	s.Nil(s.taskRepo.UpdateTask(ctx, &domain.UpdateTaskParams{ID: 1, Name: util.Ptr("v2")}))
+	s.Nil(s.taskRepo.UpdateTask(ctx, &domain.UpdateTaskParams{ID: 1, Status: util.Ptr(domain.TaskStatusCompleted)}))
+}
+
+func (s *versionSuite) TestGetTaskVersions() {
+	versions, customErr := s.taskRepo.GetTaskVersions(context.Background(), 1)
+	s.Nil(customErr)
+	s.Equal(3, len(versions))
+	for i, version := range versions {
+		s.Equal(i+1, version.Version)
+		s.Equal(i+1, version.Task.Version)
+	}
+	s.Equal("v1", versions[0].Task.Name)
+	s.Equal("v2", versions[1].Task.Name)
+	s.Equal(domain.TaskStatusCompleted, versions[2].Task.Status)
+}
+
+func (s *versionSuite) TestRevertTask() {
+	ctx := context.Background()
+	diffs, customErr := s.taskRepo.RevertTask(ctx, 1, 1)
+	s.Nil(customErr)
+	s.Equal([]*domain.TaskFieldDiff{
+		{Field: "name", From: "v2", To: "v1"},
+		{Field: "status", From: domain.TaskStatusCompleted, To: domain.TaskStatusIncomplete},
+	}, diffs)
+
+	task, customErr := s.taskRepo.GetTask(ctx, 1)
+	s.Nil(customErr)
+	s.Equal("v1", task.Name)
+	s.Equal(domain.TaskStatusIncomplete, task.Status)
+	s.Equal(4, task.Version)
+
+	// the revert itself is a new version and a history entry
+	versions, customErr := s.taskRepo.GetTaskVersions(ctx, 1)
+	s.Nil(customErr)
+	s.Equal(4, len(versions))
+
+	histories, _, customErr := s.taskRepo.GetTaskHistory(ctx, 1, domain.Pagination{Page: 1, PageSize: 1})
+	s.Nil(customErr)
+	s.Equal(domain.TaskActionRevert, histories[0].Action)
+
+	_, customErr = s.taskRepo.RevertTask(ctx, 1, 5)
+	s.Equal(code.NotFound, customErr.Code)
+}
+
+func (s *versionSuite) TestConcurrentRevert() {
+	ctx := context.Background()
+	var workers sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		workers.Add(1)
+		go func(version int) {
+			defer workers.Done()
+			_, customErr := s.taskRepo.RevertTask(ctx, 1, version)
+			s.Nil(customErr)
+		}(i%3 + 1)
+	}
+	workers.Wait()
+
+	task, customErr := s.taskRepo.GetTask(ctx, 1)
+	s.Nil(customErr)
+	s.Equal(13, task.Version)
+}
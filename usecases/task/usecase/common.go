@@ -2,18 +2,32 @@ package usecase
 
 import (
 	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/eventbus"
+	"github.com/Yu-Qi/restful_api/pkg/ratelimit"
 )
 
 var (
-	taskRepo domain.TaskRepository
+	taskRepo   domain.TaskRepository
+	taskEvents *eventbus.Bus[*domain.TaskEvent]
+
+	createLimiter ratelimit.Store
+	createLimit   ratelimit.Limit
 )
 
 // InitParam defines the parameters for initializing the service.
 type InitParam struct {
 	TaskRepo domain.TaskRepository
+	// TaskEvents is the bus the TaskRepo publishes to, nil disables the subscriptions
+	TaskEvents *eventbus.Bus[*domain.TaskEvent]
+	// CreateLimiter keeps the tasks created by each client to CreateLimit, nil does not limit them
+	CreateLimiter ratelimit.Store
+	CreateLimit   ratelimit.Limit
 }
 
 // Init injects implementations into the service.
 func Init(param InitParam) {
 	taskRepo = param.TaskRepo
+	taskEvents = param.TaskEvents
+	createLimiter = param.CreateLimiter
+	createLimit = param.CreateLimit
 }
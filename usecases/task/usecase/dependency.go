@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// AddDependency make taskID blocked by blockerID
+func AddDependency(ctx context.Context, taskID, blockerID int) *code.CustomError {
+	if customErr := authorize(ctx, permissionWrite); customErr != nil {
+		return customErr
+	}
+
+	for _, id := range []int{taskID, blockerID} {
+		_, customErr := getOwnedTask(ctx, id)
+		if customErr != nil {
+			return customErr
+		}
+	}
+
+	customErr := taskRepo.AddDependency(ctx, taskID, blockerID)
+	if customErr != nil {
+		return customErr
+	}
+
+	return nil
+}
+
+// RemoveDependency remove the blocked-by relation between taskID and blockerID
+func RemoveDependency(ctx context.Context, taskID, blockerID int) *code.CustomError {
+	if customErr := authorize(ctx, permissionWrite); customErr != nil {
+		return customErr
+	}
+
+	_, customErr := getOwnedTask(ctx, taskID)
+	if customErr != nil {
+		return customErr
+	}
+
+	customErr = taskRepo.RemoveDependency(ctx, taskID, blockerID)
+	if customErr != nil {
+		return customErr
+	}
+
+	return nil
+}
+
+// GetBlockers get the tasks blocking taskID
+func GetBlockers(ctx context.Context, taskID int) ([]*domain.Task, *code.CustomError) {
+	if customErr := authorize(ctx, permissionRead); customErr != nil {
+		return nil, customErr
+	}
+
+	_, customErr := getOwnedTask(ctx, taskID)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	blockerIDs, customErr := taskRepo.GetBlockers(ctx, taskID)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	blockers := make([]*domain.Task, 0, len(blockerIDs))
+	for _, blockerID := range blockerIDs {
+		blocker, customErr := taskRepo.GetTask(ctx, blockerID)
+		if customErr != nil {
+			return nil, customErr
+		}
+		blockers = append(blockers, blocker)
+	}
+	return blockers, nil
+}
+
+// GetTasksByBlocked get the tasks filtered by whether they are blocked, in topological order.
+// A task is blocked when at least one of its blockers is incomplete, so the unblocked
+// tasks are the incomplete ones ready to work on.
+func GetTasksByBlocked(ctx context.Context, blocked bool) ([]*domain.Task, *code.CustomError) {
+	tasks, customErr := GetTasks(ctx)
+	if customErr != nil {
+		return nil, customErr
+	}
+	graph, customErr := taskRepo.GetDependencies(ctx)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	taskMap := make(map[int]*domain.Task, len(tasks))
+	for _, task := range tasks {
+		taskMap[task.ID] = task
+	}
+
+	result := make([]*domain.Task, 0, len(tasks))
+	for _, task := range topologicalSort(tasks, graph) {
+		if isBlocked(task, taskMap, graph) != blocked {
+			continue
+		}
+		if !blocked && task.Status == domain.TaskStatusCompleted {
+			continue
+		}
+		result = append(result, task)
+	}
+	return result, nil
+}
+
+func checkBlockersCompleted(ctx context.Context, taskID int) *code.CustomError {
+	blockers, customErr := GetBlockers(ctx, taskID)
+	if customErr != nil {
+		return customErr
+	}
+
+	for _, blocker := range blockers {
+		if blocker.Status != domain.TaskStatusCompleted {
+			return code.NewCustomError(code.BlockedByIncomplete, http.StatusConflict,
+				fmt.Errorf("task is blocked by incomplete task %d", blocker.ID))
+		}
+	}
+	return nil
+}
+
+func isBlocked(task *domain.Task, taskMap map[int]*domain.Task, graph map[int][]int) bool {
+	for _, blockerID := range graph[task.ID] {
+		blocker, ok := taskMap[blockerID]
+		if ok && blocker.Status != domain.TaskStatusCompleted {
+			return true
+		}
+	}
+	return false
+}
+
+// topologicalSort orders tasks so that every blocker comes before the tasks it blocks,
+// ties are broken by task id to keep the order stable.
+func topologicalSort(tasks []*domain.Task, graph map[int][]int) []*domain.Task {
+	taskMap := make(map[int]*domain.Task, len(tasks))
+	for _, task := range tasks {
+		taskMap[task.ID] = task
+	}
+
+	inDegree := make(map[int]int, len(tasks))
+	blocking := map[int][]int{} // key: blocker id, value: ids of the tasks it blocks
+	for taskID, blockerIDs := range graph {
+		if _, ok := taskMap[taskID]; !ok {
+			continue
+		}
+		for _, blockerID := range blockerIDs {
+			if _, ok := taskMap[blockerID]; !ok {
+				continue
+			}
+			inDegree[taskID]++
+			blocking[blockerID] = append(blocking[blockerID], taskID)
+		}
+	}
+
+	ready := make([]int, 0, len(tasks))
+	for id := range taskMap {
+		if inDegree[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	sorted := make([]*domain.Task, 0, len(tasks))
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		id := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, taskMap[id])
+		for _, blockedID := range blocking[id] {
+			inDegree[blockedID]--
+			if inDegree[blockedID] == 0 {
+				ready = append(ready, blockedID)
+			}
+		}
+	}
+	return sorted
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/eventbus"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+	"github.com/samber/lo"
+)
+
+// TaskEvent is a change of a task with the id to resume the subscription from
+type TaskEvent = eventbus.Event[*domain.TaskEvent]
+
+// TaskEventSubscription delivers the changes of the tasks the subscriber can access
+type TaskEventSubscription struct {
+	// Replay are the kept events published after the last event id of the subscriber
+	Replay []TaskEvent
+	// Events is closed when the subscription is canceled or falls behind
+	Events <-chan TaskEvent
+	Cancel func()
+}
+
+// TaskEventFilter selects the tasks of a subscription, an empty field matches every task
+type TaskEventFilter struct {
+	Statuses []domain.TaskStatus
+	TaskIDs  []int
+}
+
+func (f *TaskEventFilter) match(task *domain.Task) bool {
+	if len(f.TaskIDs) > 0 && !lo.Contains(f.TaskIDs, task.ID) {
+		return false
+	}
+	return len(f.Statuses) == 0 || lo.Contains(f.Statuses, task.Status)
+}
+
+// SubscribeTaskEvents subscribe the changes of the tasks matching filter after lastEventID
+func SubscribeTaskEvents(ctx context.Context, lastEventID uint64, filter *TaskEventFilter) (*TaskEventSubscription, *code.CustomError) {
+	if customErr := authorize(ctx, permissionRead); customErr != nil {
+		return nil, customErr
+	}
+	if taskEvents == nil {
+		return nil, code.NewCustomError(code.InternalUnknownError, http.StatusServiceUnavailable, fmt.Errorf("task events are disabled"))
+	}
+
+	tenantID := requestctx.GetTenantID(ctx)
+	visible := func(event TaskEvent) bool {
+		return event.Data.TenantID == tenantID && canAccess(ctx, event.Data.Task) && filter.match(event.Data.Task)
+	}
+
+	replay, events, cancel := taskEvents.Subscribe(lastEventID)
+	subscription := &TaskEventSubscription{}
+	for _, event := range replay {
+		if visible(event) {
+			subscription.Replay = append(subscription.Replay, event)
+		}
+	}
+
+	filtered := make(chan TaskEvent)
+	done := make(chan struct{})
+	go func() {
+		defer close(filtered)
+		for event := range events {
+			if !visible(event) {
+				continue
+			}
+			select {
+			case filtered <- event:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	subscription.Events = filtered
+	subscription.Cancel = func() {
+		once.Do(func() {
+			close(done)
+			cancel()
+		})
+	}
+	return subscription, nil
+}
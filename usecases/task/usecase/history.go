@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// GetTaskHistory get a page of the change history of a task
+func GetTaskHistory(ctx context.Context, taskID int, pagination domain.Pagination) ([]*domain.TaskHistory, int, *code.CustomError) {
+	if customErr := authorize(ctx, permissionRead); customErr != nil {
+		return nil, 0, customErr
+	}
+
+	// the task may be purged already, so the owner is checked against its latest history
+	latest, _, customErr := taskRepo.GetTaskHistory(ctx, taskID, domain.Pagination{Page: 1, PageSize: 1})
+	if customErr != nil {
+		return nil, 0, customErr
+	}
+	task := latest[0].After
+	if task == nil {
+		task = latest[0].Before
+	}
+	if !canAccess(ctx, task) {
+		return nil, 0, newNotFoundError()
+	}
+
+	histories, total, customErr := taskRepo.GetTaskHistory(ctx, taskID, pagination)
+	if customErr != nil {
+		return nil, 0, customErr
+	}
+
+	return histories, total, nil
+}
+
+// GetAuditLogs get a page of the change history of all tasks
+func GetAuditLogs(ctx context.Context, params *domain.GetAuditLogsParams) ([]*domain.TaskHistory, int, *code.CustomError) {
+	if customErr := authorize(ctx, permissionRead); customErr != nil {
+		return nil, 0, customErr
+	}
+
+	if subject, scoped := ownerScope(ctx); scoped {
+		params.OwnerID = &subject
+	}
+
+	histories, total, customErr := taskRepo.GetAuditLogs(ctx, params)
+	if customErr != nil {
+		return nil, 0, customErr
+	}
+
+	return histories, total, nil
+}
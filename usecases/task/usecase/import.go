@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// ImportResult is the outcome of importing a task, Err is nil when it is imported
+type ImportResult struct {
+	Created bool
+	Err     *code.CustomError
+}
+
+// ImportTask is a task of an import, Row is its position in the imported file
+type ImportTask struct {
+	Task *domain.Task
+	Row  int
+}
+
+// ImportTasks upsert the tasks by external id one by one, a task replaces the one having its external id like
+// ReplaceTask and is created otherwise. A dry run makes the same checks without writing.
+// The import counts as a single create against the create limit of the caller.
+// The tasks are given the id and the state they are stored with.
+func ImportTasks(ctx context.Context, tasks []*ImportTask, dryRun bool) ([]*ImportResult, *code.CustomError) {
+	if customErr := authorize(ctx, permissionWrite); customErr != nil {
+		return nil, customErr
+	}
+	if customErr := takeCreateToken(ctx, dryRun); customErr != nil {
+		return nil, customErr
+	}
+
+	results := make([]*ImportResult, len(tasks))
+	// seen key: external id, value: row of the task having it first
+	seen := map[string]int{}
+	for i, task := range tasks {
+		externalID := task.Task.ExternalID
+		if firstRow, ok := seen[externalID]; ok && externalID != "" {
+			results[i] = &ImportResult{Err: code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest,
+				fmt.Errorf("external id %s is repeated, see row %d of the import", externalID, firstRow))}
+			continue
+		}
+		seen[externalID] = task.Row
+
+		created, customErr := importTask(ctx, task.Task, dryRun)
+		results[i] = &ImportResult{Created: created, Err: customErr}
+	}
+	return results, nil
+}
+
+func importTask(ctx context.Context, task *domain.Task, dryRun bool) (bool, *code.CustomError) {
+	var existing *domain.Task
+	if task.ExternalID != "" {
+		var customErr *code.CustomError
+		existing, customErr = taskRepo.GetTaskByExternalID(ctx, task.ExternalID)
+		if customErr != nil && customErr.Code != code.NotFound {
+			return false, customErr
+		}
+	}
+
+	if existing == nil {
+		if dryRun {
+			return true, nil
+		}
+		return true, createTask(ctx, task)
+	}
+
+	task.ID = existing.ID
+	if dryRun {
+		if !canAccess(ctx, existing) {
+			return false, newNotFoundError()
+		}
+		if existing.Status != domain.TaskStatusCompleted && task.Status == domain.TaskStatusCompleted {
+			return false, checkBlockersCompleted(ctx, existing.ID)
+		}
+		return false, nil
+	}
+
+	replaced, customErr := ReplaceTask(ctx, task)
+	if customErr != nil {
+		return false, customErr
+	}
+	*task = *replaced
+	return false, nil
+}
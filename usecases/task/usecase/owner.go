@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// callerSubject returns the subject of the authenticated caller.
+// The requests without a principal, e.g. from background jobs, are not scoped to an owner.
+func callerSubject(ctx context.Context) (string, bool) {
+	principal := auth.GetPrincipal(ctx)
+	if principal == nil {
+		return "", false
+	}
+	return principal.Subject, true
+}
+
+// ownerScope returns the subject the caller is restricted to, the callers managing tasks are not restricted
+func ownerScope(ctx context.Context) (string, bool) {
+	subject, ok := callerSubject(ctx)
+	if !ok || isAllowed(ctx, permissionManage) {
+		return "", false
+	}
+	return subject, true
+}
+
+// AccessScope returns the owner whose tasks the caller is restricted to, "" when the caller sees every task
+func AccessScope(ctx context.Context) string {
+	subject, _ := ownerScope(ctx)
+	return subject
+}
+
+// canAccess reports whether the caller can access the task
+func canAccess(ctx context.Context, task *domain.Task) bool {
+	subject, scoped := ownerScope(ctx)
+	return !scoped || task.OwnerID == subject
+}
+
+// filterAccessible keeps the tasks the caller can access
+func filterAccessible(ctx context.Context, tasks []*domain.Task) []*domain.Task {
+	if _, scoped := ownerScope(ctx); !scoped {
+		return tasks
+	}
+
+	accessible := make([]*domain.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if canAccess(ctx, task) {
+			accessible = append(accessible, task)
+		}
+	}
+	return accessible
+}
+
+// getOwnedTask get a task and check the caller can access it
+func getOwnedTask(ctx context.Context, id int) (*domain.Task, *code.CustomError) {
+	task, customErr := taskRepo.GetTask(ctx, id)
+	if customErr != nil {
+		return nil, customErr
+	}
+	if !canAccess(ctx, task) {
+		return nil, newNotFoundError()
+	}
+
+	return task, nil
+}
+
+// getOwnedDeletedTask get a task in the trash and check the caller can access it
+func getOwnedDeletedTask(ctx context.Context, id int) (*domain.Task, *code.CustomError) {
+	task, customErr := taskRepo.GetDeletedTask(ctx, id)
+	if customErr != nil {
+		return nil, customErr
+	}
+	if !canAccess(ctx, task) {
+		return nil, code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task not found in trash"))
+	}
+
+	return task, nil
+}
+
+// newNotFoundError is the error for a task of another user, it is the same as for a missing task
+// so that the caller cannot tell which ids exist
+func newNotFoundError() *code.CustomError {
+	return code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("task not found"))
+}
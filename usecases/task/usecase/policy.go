@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// permission is a kind of operation on tasks
+type permission string
+
+const (
+	permissionRead  permission = "read"
+	permissionWrite permission = "write"
+	// permissionManage grants access to the tasks of every owner
+	permissionManage permission = "manage"
+)
+
+// rolePermissions is the policy deciding what each role can do
+var rolePermissions = map[auth.Role]map[permission]bool{
+	auth.RoleViewer: {permissionRead: true},
+	auth.RoleEditor: {permissionRead: true, permissionWrite: true},
+	auth.RoleAdmin:  {permissionRead: true, permissionWrite: true, permissionManage: true},
+}
+
+// isAllowed reports whether the caller is granted the permission.
+// The requests without a principal, e.g. from background jobs, are allowed to do anything.
+func isAllowed(ctx context.Context, p permission) bool {
+	principal := auth.GetPrincipal(ctx)
+	if principal == nil {
+		return true
+	}
+
+	role := principal.Role
+	if role == "" {
+		role = auth.DefaultRole
+	}
+	return rolePermissions[role][p]
+}
+
+// authorize checks the caller is granted the permission
+func authorize(ctx context.Context, p permission) *code.CustomError {
+	if isAllowed(ctx, p) {
+		return nil
+	}
+	return code.NewCustomError(code.Forbidden, http.StatusForbidden, fmt.Errorf("%s permission on tasks is required", p))
+}
@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	customlog "github.com/Yu-Qi/restful_api/pkg/custom_log"
+	"github.com/Yu-Qi/restful_api/pkg/ratelimit"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+)
+
+// takeCreateToken counts a create against the limit of its client, so the limit holds whether the tasks come one
+// per request, several per GraphQL operation, over the websocket or over gRPC. An import is one create whatever
+// its number of rows. A dry run only checks a token is left.
+func takeCreateToken(ctx context.Context, dryRun bool) *code.CustomError {
+	if createLimiter == nil {
+		return nil
+	}
+
+	take := createLimiter.Take
+	if dryRun {
+		take = createLimiter.Peek
+	}
+	key := "create task " + ratelimit.ClientKey(auth.GetPrincipal(ctx), requestctx.GetClientIP(ctx))
+	result, err := take(ctx, key, createLimit, time.Now())
+	if err != nil {
+		// the tasks can still be created when the store is down
+		customlog.ErrorfCtx(ctx, "rate limit store: %v", err)
+		return nil
+	}
+	if !result.Allowed {
+		return code.NewCustomError(code.TooManyRequests, http.StatusTooManyRequests,
+			fmt.Errorf("more than %d creates per %s, retry in %s", createLimit.Requests, createLimit.Period,
+				result.RetryAfter.Round(time.Second)))
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	customlog "github.com/Yu-Qi/restful_api/pkg/custom_log"
+)
+
+// GetNextOccurrences preview the next n occurrences of a recurring task
+func GetNextOccurrences(ctx context.Context, taskID, n int) ([]time.Time, *code.CustomError) {
+	if customErr := authorize(ctx, permissionRead); customErr != nil {
+		return nil, customErr
+	}
+
+	task, customErr := getOwnedTask(ctx, taskID)
+	if customErr != nil {
+		return nil, customErr
+	}
+	if task.Recurrence == nil {
+		return nil, code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, fmt.Errorf("task is not recurring"))
+	}
+
+	return task.Recurrence.Next(occurrenceAnchor(task), n), nil
+}
+
+// createNextOccurrence create the task of the next occurrence once a recurring task is completed.
+// The completion is already saved, so a failure is logged instead of failing the request.
+func createNextOccurrence(ctx context.Context, taskID int) {
+	task, customErr := taskRepo.GetTask(ctx, taskID)
+	if customErr != nil {
+		customlog.ErrorfCtx(ctx, "create next occurrence of task %d: %v", taskID, customErr.Error)
+		return
+	}
+	if task.Recurrence == nil {
+		return
+	}
+
+	next := task.Recurrence.Next(occurrenceAnchor(task), 1)
+	if len(next) == 0 {
+		// the series is over
+		return
+	}
+
+	customErr = taskRepo.CreateTask(ctx, &domain.Task{
+		Name:       task.Name,
+		Status:     domain.TaskStatusIncomplete,
+		DueAt:      &next[0],
+		Recurrence: task.Recurrence,
+		OwnerID:    task.OwnerID,
+	})
+	if customErr != nil {
+		customlog.ErrorfCtx(ctx, "create next occurrence of task %d: %v", taskID, customErr.Error)
+	}
+}
+
+// occurrenceAnchor is the time the next occurrence is counted from
+func occurrenceAnchor(task *domain.Task) time.Time {
+	if task.DueAt != nil {
+		return *task.DueAt
+	}
+	return time.Now()
+}
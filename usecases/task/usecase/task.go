@@ -2,6 +2,10 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin/binding"
 
 	"github.com/Yu-Qi/restful_api/domain"
 	"github.com/Yu-Qi/restful_api/pkg/code"
@@ -9,16 +13,99 @@ import (
 
 // GetTasks get all tasks
 func GetTasks(ctx context.Context) ([]*domain.Task, *code.CustomError) {
+	if customErr := authorize(ctx, permissionRead); customErr != nil {
+		return nil, customErr
+	}
+
 	tasks, customErr := taskRepo.GetTasks(ctx)
 	if customErr != nil {
 		return nil, customErr
 	}
 
-	return tasks, nil
+	return filterAccessible(ctx, tasks), nil
+}
+
+// RangeTasks call fn for each task the caller can access in id order until fn returns false,
+// the tasks are not collected
+func RangeTasks(ctx context.Context, fn func(task *domain.Task) bool) *code.CustomError {
+	if customErr := authorize(ctx, permissionRead); customErr != nil {
+		return customErr
+	}
+
+	return taskRepo.RangeTasks(ctx, func(task *domain.Task) bool {
+		if !canAccess(ctx, task) {
+			return true
+		}
+		return fn(task)
+	})
+}
+
+// GetTaskRevision get the revision of the tasks, a list read after it is at least as new
+func GetTaskRevision(ctx context.Context) (*domain.TaskRevision, *code.CustomError) {
+	if customErr := authorize(ctx, permissionRead); customErr != nil {
+		return nil, customErr
+	}
+
+	return taskRepo.GetTaskRevision(ctx)
+}
+
+// GetTask get a task
+func GetTask(ctx context.Context, id int) (*domain.Task, *code.CustomError) {
+	if customErr := authorize(ctx, permissionRead); customErr != nil {
+		return nil, customErr
+	}
+
+	task, customErr := getOwnedTask(ctx, id)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	return task, nil
+}
+
+// ValidateTask checks the name, status and recurrence of a task given by a client,
+// every transport checks the created and replaced tasks with it
+func ValidateTask(task *domain.Task) *code.CustomError {
+	var err error
+	switch {
+	case task.Name == "":
+		err = fmt.Errorf("name is required")
+	case !task.Status.IsValid():
+		err = fmt.Errorf("status is invalid")
+	case task.Recurrence != nil:
+		if err = binding.Validator.ValidateStruct(task.Recurrence); err == nil {
+			err = task.Recurrence.Validate()
+		}
+	}
+	if err != nil {
+		return code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+	}
+	return nil
 }
 
 // CreateTask create a task
 func CreateTask(ctx context.Context, task *domain.Task) *code.CustomError {
+	if customErr := authorize(ctx, permissionWrite); customErr != nil {
+		return customErr
+	}
+	if customErr := takeCreateToken(ctx, false); customErr != nil {
+		return customErr
+	}
+
+	return createTask(ctx, task)
+}
+
+// createTask create a task of the caller, the permission and the create limit are checked by the callers
+func createTask(ctx context.Context, task *domain.Task) *code.CustomError {
+	if subject, ok := callerSubject(ctx); ok {
+		task.OwnerID = subject
+	}
+	if task.Recurrence != nil && task.DueAt == nil {
+		if first, ok := task.Recurrence.First(); ok {
+			task.DueAt = &first
+		}
+	}
+
 	customErr := taskRepo.CreateTask(ctx, task)
 	if customErr != nil {
 		return customErr
@@ -27,19 +114,88 @@ func CreateTask(ctx context.Context, task *domain.Task) *code.CustomError {
 	return nil
 }
 
-// UpdateTask update a task
+// UpdateTask update the given fields of a task, the others are kept. It is applied by PatchTask, so that the
+// blockers and the completion are checked under the row lock.
 func UpdateTask(ctx context.Context, params *domain.UpdateTaskParams) *code.CustomError {
-	customErr := taskRepo.UpdateTask(ctx, params)
+	_, customErr := PatchTask(ctx, params.ID, func(current *domain.Task) (*domain.Task, *code.CustomError) {
+		// current is compared with the result to find the completion
+		updated := *current
+		if params.Name != nil {
+			updated.Name = *params.Name
+		}
+		if params.Status != nil {
+			updated.Status = *params.Status
+		}
+		if params.DueAt != nil {
+			updated.DueAt = params.DueAt
+		}
+		if params.Recurrence != nil {
+			updated.Recurrence = params.Recurrence
+		}
+		return &updated, nil
+	})
+	return customErr
+}
+
+// ReplaceTask replace the name, status, due date and recurrence of a task, the omitted ones are cleared
+func ReplaceTask(ctx context.Context, task *domain.Task) (*domain.Task, *code.CustomError) {
+	return PatchTask(ctx, task.ID, func(current *domain.Task) (*domain.Task, *code.CustomError) {
+		return task, nil
+	})
+}
+
+// PatchTask apply a patch to a task atomically
+func PatchTask(ctx context.Context, id int, patch domain.TaskPatchFunc) (*domain.Task, *code.CustomError) {
+	if customErr := authorize(ctx, permissionWrite); customErr != nil {
+		return nil, customErr
+	}
+
+	var completing bool
+	patched, customErr := taskRepo.PatchTask(ctx, id, func(current *domain.Task) (*domain.Task, *code.CustomError) {
+		if !canAccess(ctx, current) {
+			return nil, newNotFoundError()
+		}
+		next, customErr := patch(current)
+		if customErr != nil {
+			return nil, customErr
+		}
+
+		completing = current.Status != domain.TaskStatusCompleted && next.Status == domain.TaskStatusCompleted
+		if completing {
+			if customErr = checkBlockersCompleted(ctx, id); customErr != nil {
+				return nil, customErr
+			}
+		}
+		if next.Recurrence != nil && next.DueAt == nil {
+			if first, ok := next.Recurrence.First(); ok {
+				next.DueAt = &first
+			}
+		}
+		return next, nil
+	})
 	if customErr != nil {
-		return customErr
+		return nil, customErr
 	}
 
-	return nil
+	if completing {
+		createNextOccurrence(ctx, id)
+	}
+
+	return patched, nil
 }
 
 // DeleteTask delete a task
 func DeleteTask(ctx context.Context, id int) *code.CustomError {
-	customErr := taskRepo.DeleteTask(ctx, id)
+	if customErr := authorize(ctx, permissionWrite); customErr != nil {
+		return customErr
+	}
+
+	_, customErr := getOwnedTask(ctx, id)
+	if customErr != nil {
+		return customErr
+	}
+
+	customErr = taskRepo.DeleteTask(ctx, id)
 	if customErr != nil {
 		return customErr
 	}
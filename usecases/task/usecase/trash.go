@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	customlog "github.com/Yu-Qi/restful_api/pkg/custom_log"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+)
+
+// GetDeletedTasks get all tasks in the trash
+func GetDeletedTasks(ctx context.Context) ([]*domain.Task, *code.CustomError) {
+	if customErr := authorize(ctx, permissionRead); customErr != nil {
+		return nil, customErr
+	}
+
+	tasks, customErr := taskRepo.GetDeletedTasks(ctx)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	return filterAccessible(ctx, tasks), nil
+}
+
+// RestoreTask move a task out of the trash
+func RestoreTask(ctx context.Context, id int) *code.CustomError {
+	if customErr := authorize(ctx, permissionWrite); customErr != nil {
+		return customErr
+	}
+
+	_, customErr := getOwnedDeletedTask(ctx, id)
+	if customErr != nil {
+		return customErr
+	}
+
+	customErr = taskRepo.RestoreTask(ctx, id)
+	if customErr != nil {
+		return customErr
+	}
+
+	return nil
+}
+
+// PurgeTask permanently delete a task in the trash
+func PurgeTask(ctx context.Context, id int) *code.CustomError {
+	if customErr := authorize(ctx, permissionWrite); customErr != nil {
+		return customErr
+	}
+
+	_, customErr := getOwnedDeletedTask(ctx, id)
+	if customErr != nil {
+		return customErr
+	}
+
+	customErr = taskRepo.PurgeTask(ctx, id)
+	if customErr != nil {
+		return customErr
+	}
+
+	return nil
+}
+
+// PurgeTrash permanently delete the tasks of every tenant which stay in the trash longer than retention
+func PurgeTrash(ctx context.Context, retention time.Duration) (int, *code.CustomError) {
+	tenantIDs, customErr := taskRepo.GetTenantIDs(ctx)
+	if customErr != nil {
+		return 0, customErr
+	}
+
+	before := time.Now().Add(-retention)
+	total := 0
+	for _, tenantID := range tenantIDs {
+		purged, customErr := taskRepo.PurgeDeletedTasks(requestctx.WithTenantID(ctx, tenantID), before)
+		total += purged
+		if customErr != nil {
+			return total, customErr
+		}
+	}
+
+	return total, nil
+}
+
+// StartTrashPurger purge the trash every interval in the background until ctx is done
+func StartTrashPurger(ctx context.Context, retention, interval time.Duration) {
+	ctx = requestctx.WithActor(ctx, requestctx.ActorSystem)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purged, customErr := PurgeTrash(ctx, retention)
+				if customErr != nil {
+					customlog.ErrorfCtx(ctx, "purge trash failed: %v", customErr.Error)
+					continue
+				}
+				if purged > 0 {
+					customlog.InfofCtx(ctx, "purged %d tasks from trash", purged)
+				}
+			}
+		}
+	}()
+}
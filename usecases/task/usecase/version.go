@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+// GetTaskVersions get every version of a task
+func GetTaskVersions(ctx context.Context, id int) ([]*domain.TaskVersion, *code.CustomError) {
+	if customErr := authorize(ctx, permissionRead); customErr != nil {
+		return nil, customErr
+	}
+
+	_, customErr := getOwnedTask(ctx, id)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	versions, customErr := taskRepo.GetTaskVersions(ctx, id)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	return versions, nil
+}
+
+// RevertTask restore a task to a previous version and return the applied diff
+func RevertTask(ctx context.Context, id, version int) ([]*domain.TaskFieldDiff, *code.CustomError) {
+	if customErr := authorize(ctx, permissionWrite); customErr != nil {
+		return nil, customErr
+	}
+
+	versions, customErr := GetTaskVersions(ctx, id)
+	if customErr != nil {
+		return nil, customErr
+	}
+	if version >= 1 && version <= len(versions) && versions[version-1].Task.Status == domain.TaskStatusCompleted {
+		customErr = checkBlockersCompleted(ctx, id)
+		if customErr != nil {
+			return nil, customErr
+		}
+	}
+
+	diffs, customErr := taskRepo.RevertTask(ctx, id, version)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	for _, diff := range diffs {
+		if diff.Field == "status" && diff.To == domain.TaskStatusCompleted {
+			createNextOccurrence(ctx, id)
+		}
+	}
+
+	return diffs, nil
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/openapi"
+)
+
+// the path parameters, for the documentation
+type webhookIDParams struct {
+	ID int `uri:"id"`
+}
+
+type deliveryIDParams struct {
+	webhookIDParams
+	DeliveryID int `uri:"delivery_id"`
+}
+
+// OpenAPIRoutes documents the routes of NewWebhookHandler
+func OpenAPIRoutes() []*openapi.Route {
+	return []*openapi.Route{
+		{Method: http.MethodGet, Path: "/v1/webhooks", Summary: "List the webhooks of the tenant", Response: []*domain.Webhook{}},
+		{Method: http.MethodPost, Path: "/v1/webhooks", Summary: "Register a webhook", Body: createWebhookParams{}, Response: createWebhookResp{}},
+		{Method: http.MethodGet, Path: "/v1/webhooks/dead-letters", Summary: "List the deliveries which failed every attempt",
+			Response: []*domain.WebhookDelivery{}},
+		{Method: http.MethodGet, Path: "/v1/webhooks/:id", Summary: "Get a webhook", Params: webhookIDParams{}, Response: &domain.Webhook{}},
+		{Method: http.MethodPut, Path: "/v1/webhooks/:id", Summary: "Replace a webhook",
+			Params: webhookIDParams{}, Body: updateWebhookParams{}, Response: &domain.Webhook{}},
+		{Method: http.MethodDelete, Path: "/v1/webhooks/:id", Summary: "Delete a webhook", Params: webhookIDParams{}},
+
+		{Method: http.MethodGet, Path: "/v1/webhooks/:id/deliveries", Summary: "List the deliveries of a webhook",
+			Params: webhookIDParams{}, Response: []*domain.WebhookDelivery{}},
+		{Method: http.MethodPost, Path: "/v1/webhooks/:id/deliveries/:delivery_id/redeliver", Summary: "Send a delivery again",
+			Params: deliveryIDParams{}, Response: &domain.WebhookDelivery{}},
+	}
+}
@@ -0,0 +1,189 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/response"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/Yu-Qi/restful_api/usecases/webhook/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler represent the http handler for webhooks
+type WebhookHandler struct{}
+
+// NewWebhookHandler will initialize the webhooks/ resources endpoint
+func NewWebhookHandler(r *gin.RouterGroup) {
+	v1 := r.Group("/v1")
+
+	handler := &WebhookHandler{}
+	v1.GET("/webhooks", handler.GetWebhooks)
+	v1.POST("/webhooks", handler.CreateWebhook)
+	v1.GET("/webhooks/dead-letters", handler.GetDeadLetters)
+	v1.GET("/webhooks/:id", handler.GetWebhook)
+	v1.PUT("/webhooks/:id", handler.UpdateWebhook)
+	v1.DELETE("/webhooks/:id", handler.DeleteWebhook)
+
+	v1.GET("/webhooks/:id/deliveries", handler.GetDeliveries)
+	v1.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", handler.RedeliverDelivery)
+}
+
+type createWebhookParams struct {
+	URL    string                `json:"url" binding:"required,url"`
+	Events []domain.WebhookEvent `json:"events" binding:"required,min=1,dive,oneof=task.created task.updated task.deleted task.completed"`
+}
+
+type updateWebhookParams struct {
+	createWebhookParams
+	Active *bool `json:"active" binding:"required"`
+}
+
+type createWebhookResp struct {
+	*domain.Webhook
+	// Secret signs the payloads, it is only returned once
+	Secret string `json:"secret"`
+}
+
+// GetWebhooks get the webhooks of the tenant
+func (h *WebhookHandler) GetWebhooks(ctx *gin.Context) {
+	webhooks, customErr := usecase.GetWebhooks(ctx)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, webhooks)
+}
+
+// CreateWebhook register a webhook
+func (h *WebhookHandler) CreateWebhook(ctx *gin.Context) {
+	params := createWebhookParams{}
+	customErr := util.ToGinContextExt(ctx).BindJson(&params)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	webhook, secret, customErr := usecase.CreateWebhook(ctx, params.URL, params.Events)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, &createWebhookResp{Webhook: webhook, Secret: secret})
+}
+
+// GetWebhook get a webhook
+func (h *WebhookHandler) GetWebhook(ctx *gin.Context) {
+	id, customErr := paramID(ctx, "id")
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	webhook, customErr := usecase.GetWebhook(ctx, id)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, webhook)
+}
+
+// UpdateWebhook replace the url, the events and the active flag of a webhook
+func (h *WebhookHandler) UpdateWebhook(ctx *gin.Context) {
+	id, customErr := paramID(ctx, "id")
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	params := updateWebhookParams{}
+	customErr = util.ToGinContextExt(ctx).BindJson(&params)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	webhook, customErr := usecase.UpdateWebhook(ctx, &domain.Webhook{
+		ID:     id,
+		URL:    params.URL,
+		Events: params.Events,
+		Active: *params.Active,
+	})
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, webhook)
+}
+
+// DeleteWebhook delete a webhook
+func (h *WebhookHandler) DeleteWebhook(ctx *gin.Context) {
+	id, customErr := paramID(ctx, "id")
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	customErr = usecase.DeleteWebhook(ctx, id)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, nil)
+}
+
+// GetDeliveries get the deliveries of a webhook with their attempts
+func (h *WebhookHandler) GetDeliveries(ctx *gin.Context) {
+	id, customErr := paramID(ctx, "id")
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	deliveries, customErr := usecase.GetDeliveries(ctx, id)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, deliveries)
+}
+
+// GetDeadLetters get the deliveries which gave up retrying
+func (h *WebhookHandler) GetDeadLetters(ctx *gin.Context) {
+	deliveries, customErr := usecase.GetDeadLetters(ctx)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, deliveries)
+}
+
+// RedeliverDelivery send a dead delivery once more
+func (h *WebhookHandler) RedeliverDelivery(ctx *gin.Context) {
+	id, customErr := paramID(ctx, "id")
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	deliveryID, customErr := paramID(ctx, "delivery_id")
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+
+	delivery, customErr := usecase.RedeliverDelivery(ctx, id, deliveryID)
+	if customErr != nil {
+		response.CustomError(ctx, customErr)
+		return
+	}
+	response.OK(ctx, delivery)
+}
+
+func paramID(ctx *gin.Context, name string) (int, *code.CustomError) {
+	id, err := strconv.Atoi(ctx.Param(name))
+	if err != nil {
+		return 0, code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,420 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/api/middleware"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/eventbus"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+	"github.com/Yu-Qi/restful_api/pkg/testutil"
+	"github.com/Yu-Qi/restful_api/pkg/util"
+	"github.com/Yu-Qi/restful_api/pkg/webhook"
+	_taskRepo "github.com/Yu-Qi/restful_api/usecases/task/repository/in_memory"
+	_taskUsecase "github.com/Yu-Qi/restful_api/usecases/task/usecase"
+	_webhookRepo "github.com/Yu-Qi/restful_api/usecases/webhook/repository/in_memory"
+	_webhookUsecase "github.com/Yu-Qi/restful_api/usecases/webhook/usecase"
+)
+
+func TestWebhookSuite(t *testing.T) {
+	suite.Run(t, new(webhookSuite))
+}
+
+type webhookSuite struct {
+	suite.Suite
+	Router   *gin.Engine
+	Ctx      context.Context
+	Cancel   context.CancelFunc
+	HS256Key []byte
+	Receiver *receiver
+}
+
+// receiver is a webhook endpoint answering the statuses in turn, then 200
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []*receivedRequest
+}
+
+type receivedRequest struct {
+	Header http.Header
+	Body   []byte
+}
+
+func newReceiver() *receiver {
+	r := &receiver{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, &receivedRequest{Header: req.Header, Body: body})
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	return r
+}
+
+func (r *receiver) respond(statuses ...int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses = statuses
+}
+
+func (r *receiver) received() []*receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*receivedRequest(nil), r.requests...)
+}
+
+type webhookResp struct {
+	ID     int                   `json:"id"`
+	URL    string                `json:"url"`
+	Events []domain.WebhookEvent `json:"events"`
+	Active bool                  `json:"active"`
+	Secret string                `json:"secret"`
+}
+
+func (s *webhookSuite) SetupSuite() {
+	s.HS256Key = []byte("secret")
+	verifier, err := auth.NewJWTVerifier(&auth.JWTConfig{HS256Secret: s.HS256Key})
+	s.NoError(err)
+
+	s.Router = gin.Default()
+	s.Router.ContextWithFallback = true
+	NewWebhookHandler(s.Router.Group("", middleware.Authenticate(middleware.BearerScheme(verifier))))
+}
+
+func (s *webhookSuite) SetupTest() {
+	taskEvents := eventbus.New[*domain.TaskEvent](100, 10)
+	_taskUsecase.Init(_taskUsecase.InitParam{
+		TaskRepo: _taskRepo.NewInMemoryTaskRepo(_taskRepo.WithEventPublisher(taskEvents.Publish)),
+	})
+	_webhookUsecase.Init(_webhookUsecase.InitParam{
+		WebhookRepo: _webhookRepo.NewInMemoryWebhookRepo(),
+		RetryPolicy: _webhookUsecase.RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: 10 * time.Millisecond,
+			MaxBackoff:     20 * time.Millisecond,
+		},
+		// the receiver listens on the loopback
+		AllowPrivateHosts: true,
+	})
+	s.Ctx, s.Cancel = context.WithCancel(context.Background())
+	_webhookUsecase.StartDispatcher(s.Ctx, taskEvents, 2)
+	s.Receiver = newReceiver()
+}
+
+func (s *webhookSuite) TearDownTest() {
+	s.Cancel()
+	s.Receiver.Close()
+}
+
+func (s *webhookSuite) token(subject string, role auth.Role, tenantID string) string {
+	claims := jwt.MapClaims{
+		"sub":  subject,
+		"exp":  time.Now().Add(time.Hour).Unix(),
+		"role": string(role),
+	}
+	if tenantID != "" {
+		claims["tenant"] = tenantID
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.HS256Key)
+	s.NoError(err)
+	return token
+}
+
+func (s *webhookSuite) serve(method, url, token string, body interface{}) *httptest.ResponseRecorder {
+	return testutil.Serve(s.Router, testutil.NewJSONRequest(s.T(), method, url, body), "Authorization", testutil.Bearer(token))
+}
+
+func (s *webhookSuite) decode(w *httptest.ResponseRecorder, data interface{}) int {
+	response := struct {
+		Code int         `json:"code"`
+		Data interface{} `json:"data"`
+	}{Data: data}
+	s.Nil(json.Unmarshal(w.Body.Bytes(), &response))
+	return response.Code
+}
+
+func (s *webhookSuite) createWebhook(events ...domain.WebhookEvent) *webhookResp {
+	w := s.serve("POST", "/v1/webhooks", s.token("root", auth.RoleAdmin, ""), map[string]interface{}{
+		"url":    s.Receiver.URL + "/hooks",
+		"events": events,
+	})
+	s.Require().Equal(http.StatusOK, w.Code)
+	hook := &webhookResp{}
+	s.Equal(code.OK, s.decode(w, hook))
+	return hook
+}
+
+func (s *webhookSuite) getDeliveries(webhookID int) []*domain.WebhookDelivery {
+	w := s.serve("GET", "/v1/webhooks/"+strconv.Itoa(webhookID)+"/deliveries", s.token("root", auth.RoleAdmin, ""), nil)
+	s.Require().Equal(http.StatusOK, w.Code)
+	deliveries := []*domain.WebhookDelivery{}
+	s.decode(w, &deliveries)
+	return deliveries
+}
+
+// settled waits for the deliveries of a webhook to leave the pending status
+func (s *webhookSuite) settled(webhookID, count int) []*domain.WebhookDelivery {
+	var deliveries []*domain.WebhookDelivery
+	s.Require().Eventually(func() bool {
+		deliveries = s.getDeliveries(webhookID)
+		if len(deliveries) != count {
+			return false
+		}
+		for _, delivery := range deliveries {
+			if delivery.Status == domain.WebhookDeliveryPending {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 10*time.Millisecond)
+	return deliveries
+}
+
+func (s *webhookSuite) TestManageWebhooks() {
+	admin := s.token("root", auth.RoleAdmin, "")
+	hook := s.createWebhook(domain.WebhookEventTaskCreated)
+	s.Equal(1, hook.ID)
+	s.True(hook.Active)
+	s.True(strings.HasPrefix(hook.Secret, "whsec_"))
+
+	for _, body := range []map[string]interface{}{
+		{"url": "ftp://example.com", "events": []string{"task.created"}},
+		{"url": "not a url", "events": []string{"task.created"}},
+		{"url": "https://example.com", "events": []string{"task.renamed"}},
+		{"url": "https://example.com", "events": []string{}},
+	} {
+		w := s.serve("POST", "/v1/webhooks", admin, body)
+		s.Equal(http.StatusBadRequest, w.Code, body)
+	}
+
+	// the secret is only returned once
+	w := s.serve("GET", "/v1/webhooks/1", admin, nil)
+	s.Equal(http.StatusOK, w.Code)
+	s.NotContains(w.Body.String(), "whsec_")
+
+	w = s.serve("PUT", "/v1/webhooks/1", admin, map[string]interface{}{
+		"url":    "https://example.com/hooks",
+		"events": []string{"task.deleted"},
+		"active": false,
+	})
+	s.Equal(http.StatusOK, w.Code)
+	updated := &webhookResp{}
+	s.decode(w, updated)
+	s.Equal("https://example.com/hooks", updated.URL)
+	s.Equal([]domain.WebhookEvent{domain.WebhookEventTaskDeleted}, updated.Events)
+	s.False(updated.Active)
+	s.Equal(http.StatusBadRequest, s.serve("PUT", "/v1/webhooks/1", admin, map[string]interface{}{
+		"url":    "https://example.com/hooks",
+		"events": []string{"task.deleted"},
+	}).Code)
+
+	// the non admins and the other tenants cannot see it
+	editor := s.token("alice", auth.RoleEditor, "")
+	w = s.serve("GET", "/v1/webhooks", editor, nil)
+	s.Equal(http.StatusForbidden, w.Code)
+	s.Equal(code.Forbidden, s.decode(w, nil))
+	w = s.serve("GET", "/v1/webhooks/1", s.token("root", auth.RoleAdmin, "acme"), nil)
+	s.Equal(http.StatusNotFound, w.Code)
+	var webhooks []*webhookResp
+	s.decode(s.serve("GET", "/v1/webhooks", s.token("root", auth.RoleAdmin, "acme"), nil), &webhooks)
+	s.Empty(webhooks)
+
+	s.Equal(http.StatusOK, s.serve("DELETE", "/v1/webhooks/1", admin, nil).Code)
+	s.Equal(http.StatusNotFound, s.serve("GET", "/v1/webhooks/1", admin, nil).Code)
+	s.Equal(http.StatusNotFound, s.serve("DELETE", "/v1/webhooks/1", admin, nil).Code)
+}
+
+func (s *webhookSuite) TestAPIKey() {
+	// the api keys of an admin inherit the role but cannot manage the webhooks
+	scheme := middleware.AuthScheme{
+		Name: "ApiKey",
+		Verify: func(ctx context.Context, key string) (*auth.Principal, *code.CustomError) {
+			return &auth.Principal{Subject: "root", Role: auth.RoleAdmin, APIKeyID: 1, Scopes: []string{auth.ScopeTasksRead}}, nil
+		},
+	}
+	router := gin.Default()
+	router.ContextWithFallback = true
+	NewWebhookHandler(router.Group("", middleware.Authenticate(scheme)))
+
+	hook := s.createWebhook(domain.WebhookEventTaskCreated)
+	for _, req := range []*http.Request{
+		testutil.NewJSONRequest(s.T(), "GET", "/v1/webhooks", nil),
+		testutil.NewJSONRequest(s.T(), "POST", "/v1/webhooks", map[string]interface{}{
+			"url": "https://example.com", "events": []string{"task.created"},
+		}),
+		testutil.NewJSONRequest(s.T(), "DELETE", "/v1/webhooks/"+strconv.Itoa(hook.ID), nil),
+	} {
+		w := testutil.Serve(router, req, "Authorization", "ApiKey key")
+		s.Equal(http.StatusForbidden, w.Code, req.Method)
+		s.Equal(code.Forbidden, testutil.ResponseCode(s.T(), w))
+	}
+}
+
+func (s *webhookSuite) TestPrivateHosts() {
+	_webhookUsecase.Init(_webhookUsecase.InitParam{WebhookRepo: _webhookRepo.NewInMemoryWebhookRepo()})
+	admin := s.token("root", auth.RoleAdmin, "")
+	for _, webhookURL := range []string{
+		s.Receiver.URL,
+		"http://localhost/hooks",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.1/hooks",
+		"http://[::1]:8080/hooks",
+		"http://0.0.0.0/hooks",
+	} {
+		w := s.serve("POST", "/v1/webhooks", admin, map[string]interface{}{"url": webhookURL, "events": []string{"task.created"}})
+		s.Equal(http.StatusBadRequest, w.Code, webhookURL)
+		s.Contains(w.Body.String(), "not a public address", webhookURL)
+	}
+}
+
+func (s *webhookSuite) TestDeliver() {
+	hook := s.createWebhook(domain.WebhookEventTaskCreated, domain.WebhookEventTaskCompleted)
+
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "task"}))
+	s.Nil(_taskUsecase.UpdateTask(s.Ctx, &domain.UpdateTaskParams{ID: 1, Name: util.Ptr("renamed")}))
+	s.Nil(_taskUsecase.UpdateTask(s.Ctx, &domain.UpdateTaskParams{ID: 1, Status: util.Ptr(domain.TaskStatusCompleted)}))
+	// the other tenants are not delivered
+	s.Nil(_taskUsecase.CreateTask(requestctx.WithTenantID(s.Ctx, "acme"), &domain.Task{Name: "task"}))
+
+	deliveries := s.settled(hook.ID, 2)
+	s.Equal(domain.WebhookEventTaskCompleted, deliveries[0].Event)
+	s.Equal(domain.WebhookEventTaskCreated, deliveries[1].Event)
+	for _, delivery := range deliveries {
+		s.Equal(domain.WebhookDeliveryDelivered, delivery.Status)
+		s.Len(delivery.Attempts, 1)
+		s.Equal(http.StatusOK, delivery.Attempts[0].StatusCode)
+	}
+
+	requests := s.Receiver.received()
+	s.Len(requests, 2)
+	for _, req := range requests {
+		timestamp, err := strconv.ParseInt(req.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		s.NoError(err)
+		s.True(webhook.Verify(hook.Secret, timestamp, req.Body, req.Header.Get(webhook.HeaderSignature)))
+		s.Equal("application/json", req.Header.Get("Content-Type"))
+	}
+
+	var completed struct {
+		Type string `json:"type"`
+		Data struct {
+			Task     *domain.Task `json:"task"`
+			Previous *domain.Task `json:"previous"`
+		} `json:"data"`
+	}
+	for _, req := range requests {
+		if req.Header.Get(webhook.HeaderEvent) == string(domain.WebhookEventTaskCompleted) {
+			s.Nil(json.Unmarshal(req.Body, &completed))
+			s.Equal(strconv.Itoa(deliveries[0].ID), req.Header.Get(webhook.HeaderDelivery))
+		}
+	}
+	s.Equal("task.completed", completed.Type)
+	s.Equal(domain.TaskStatusCompleted, completed.Data.Task.Status)
+	s.Equal(domain.TaskStatusIncomplete, completed.Data.Previous.Status)
+}
+
+func (s *webhookSuite) TestInactiveWebhook() {
+	active := s.createWebhook(domain.WebhookEventTaskCreated)
+	inactive := s.createWebhook(domain.WebhookEventTaskCreated)
+	s.Equal(http.StatusOK, s.serve("PUT", "/v1/webhooks/"+strconv.Itoa(inactive.ID), s.token("root", auth.RoleAdmin, ""), map[string]interface{}{
+		"url":    s.Receiver.URL,
+		"events": []string{"task.created"},
+		"active": false,
+	}).Code)
+
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "task"}))
+	s.settled(active.ID, 1)
+	s.Empty(s.getDeliveries(inactive.ID))
+}
+
+func (s *webhookSuite) TestPurgeDeliveries() {
+	hook := s.createWebhook(domain.WebhookEventTaskCreated)
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "task"}))
+	s.settled(hook.ID, 1)
+
+	purged, customErr := _webhookUsecase.PurgeDeliveries(s.Ctx, time.Hour)
+	s.Nil(customErr)
+	s.Zero(purged)
+	purged, customErr = _webhookUsecase.PurgeDeliveries(s.Ctx, -time.Second)
+	s.Nil(customErr)
+	s.Equal(1, purged)
+	s.Empty(s.getDeliveries(hook.ID))
+}
+
+func (s *webhookSuite) TestRetryAndDeadLetter() {
+	admin := s.token("root", auth.RoleAdmin, "")
+	hook := s.createWebhook(domain.WebhookEventTaskCreated)
+
+	// retried until it succeeds
+	s.Receiver.respond(http.StatusInternalServerError, http.StatusServiceUnavailable)
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "retried"}))
+	delivery := s.settled(hook.ID, 1)[0]
+	s.Equal(domain.WebhookDeliveryDelivered, delivery.Status)
+	s.Len(delivery.Attempts, 3)
+	s.Equal(http.StatusInternalServerError, delivery.Attempts[0].StatusCode)
+	s.NotEmpty(delivery.Attempts[0].Error)
+	s.Equal(http.StatusOK, delivery.Attempts[2].StatusCode)
+	s.Empty(delivery.Attempts[2].Error)
+
+	// dead after the max attempts
+	s.Receiver.respond(http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "dead"}))
+	delivery = s.settled(hook.ID, 2)[0]
+	s.Equal(domain.WebhookDeliveryDead, delivery.Status)
+	s.Len(delivery.Attempts, 3)
+	s.Nil(delivery.NextAttemptAt)
+
+	var deadLetters []*domain.WebhookDelivery
+	s.decode(s.serve("GET", "/v1/webhooks/dead-letters", admin, nil), &deadLetters)
+	s.Len(deadLetters, 1)
+	s.Equal(delivery.ID, deadLetters[0].ID)
+
+	redeliverURL := "/v1/webhooks/" + strconv.Itoa(hook.ID) + "/deliveries/" + strconv.Itoa(delivery.ID) + "/redeliver"
+	w := s.serve("POST", redeliverURL, admin, nil)
+	s.Equal(http.StatusOK, w.Code)
+	redelivered := &domain.WebhookDelivery{}
+	s.decode(w, redelivered)
+	s.Equal(domain.WebhookDeliveryDelivered, redelivered.Status)
+	s.Len(redelivered.Attempts, 4)
+
+	deadLetters = nil
+	s.decode(s.serve("GET", "/v1/webhooks/dead-letters", admin, nil), &deadLetters)
+	s.Empty(deadLetters)
+	w = s.serve("POST", redeliverURL, admin, nil)
+	s.Equal(http.StatusConflict, w.Code)
+	s.Equal(code.Conflict, s.decode(w, nil))
+	s.Equal(http.StatusNotFound, s.serve("POST", "/v1/webhooks/"+strconv.Itoa(hook.ID)+"/deliveries/100/redeliver", admin, nil).Code)
+}
+
+func (s *webhookSuite) TestUnreachableReceiver() {
+	hook := s.createWebhook(domain.WebhookEventTaskCreated)
+	s.Receiver.Close()
+
+	s.Nil(_taskUsecase.CreateTask(s.Ctx, &domain.Task{Name: "task"}))
+	delivery := s.settled(hook.ID, 1)[0]
+	s.Equal(domain.WebhookDeliveryDead, delivery.Status)
+	s.Len(delivery.Attempts, 3)
+	s.Zero(delivery.Attempts[0].StatusCode)
+	s.NotEmpty(delivery.Attempts[0].Error)
+}
@@ -0,0 +1,252 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/domain/model"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+)
+
+type inMemoryWebhookRepo struct {
+	WebhookMap  sync.Map // key: webhook id, value: *model.Webhook
+	DeliveryMap sync.Map // key: delivery id, value: *model.WebhookDelivery
+	// WriteLock serializes the writes, the stored models are replaced instead of mutated
+	WriteLock  sync.Mutex
+	WebhookID  int
+	DeliveryID int
+}
+
+// NewInMemoryWebhookRepo will create an object that represent the webhook.Repository interface
+func NewInMemoryWebhookRepo() domain.WebhookRepository {
+	return &inMemoryWebhookRepo{}
+}
+
+// CreateWebhook will create a webhook
+func (i *inMemoryWebhookRepo) CreateWebhook(ctx context.Context, webhook *domain.Webhook) *code.CustomError {
+	i.WriteLock.Lock()
+	defer i.WriteLock.Unlock()
+
+	i.WebhookID++
+	webhook.ID = i.WebhookID
+	i.WebhookMap.Store(webhook.ID, toModelWebhook(webhook))
+	return nil
+}
+
+// GetWebhook will get a webhook by id
+func (i *inMemoryWebhookRepo) GetWebhook(ctx context.Context, id int) (*domain.Webhook, *code.CustomError) {
+	value, ok := i.WebhookMap.Load(id)
+	if !ok {
+		return nil, newWebhookNotFoundError()
+	}
+	return toDomainWebhook(value.(*model.Webhook)), nil
+}
+
+// GetWebhooksByTenant will get the webhooks of a tenant, ordered by id
+func (i *inMemoryWebhookRepo) GetWebhooksByTenant(ctx context.Context, tenantID string) ([]*domain.Webhook, *code.CustomError) {
+	webhooks := []*domain.Webhook{}
+	i.WebhookMap.Range(func(_, value interface{}) bool {
+		modelWebhook := value.(*model.Webhook)
+		if modelWebhook.TenantId == tenantID {
+			webhooks = append(webhooks, toDomainWebhook(modelWebhook))
+		}
+		return true
+	})
+	sort.Slice(webhooks, func(a, b int) bool { return webhooks[a].ID < webhooks[b].ID })
+	return webhooks, nil
+}
+
+// UpdateWebhook will replace a webhook
+func (i *inMemoryWebhookRepo) UpdateWebhook(ctx context.Context, webhook *domain.Webhook) *code.CustomError {
+	i.WriteLock.Lock()
+	defer i.WriteLock.Unlock()
+
+	if _, ok := i.WebhookMap.Load(webhook.ID); !ok {
+		return newWebhookNotFoundError()
+	}
+	i.WebhookMap.Store(webhook.ID, toModelWebhook(webhook))
+	return nil
+}
+
+// DeleteWebhook will delete a webhook and its deliveries
+func (i *inMemoryWebhookRepo) DeleteWebhook(ctx context.Context, id int) *code.CustomError {
+	i.WriteLock.Lock()
+	defer i.WriteLock.Unlock()
+
+	if _, ok := i.WebhookMap.LoadAndDelete(id); !ok {
+		return newWebhookNotFoundError()
+	}
+	i.DeliveryMap.Range(func(key, value interface{}) bool {
+		if value.(*model.WebhookDelivery).WebhookId == id {
+			i.DeliveryMap.Delete(key)
+		}
+		return true
+	})
+	return nil
+}
+
+// CreateDelivery will create a delivery of an existing webhook
+func (i *inMemoryWebhookRepo) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) *code.CustomError {
+	i.WriteLock.Lock()
+	defer i.WriteLock.Unlock()
+
+	if _, ok := i.WebhookMap.Load(delivery.WebhookID); !ok {
+		return newWebhookNotFoundError()
+	}
+	i.DeliveryID++
+	delivery.ID = i.DeliveryID
+	i.DeliveryMap.Store(delivery.ID, toModelWebhookDelivery(delivery))
+	return nil
+}
+
+// GetDelivery will get a delivery by id
+func (i *inMemoryWebhookRepo) GetDelivery(ctx context.Context, id int) (*domain.WebhookDelivery, *code.CustomError) {
+	value, ok := i.DeliveryMap.Load(id)
+	if !ok {
+		return nil, newDeliveryNotFoundError()
+	}
+	return toDomainWebhookDelivery(value.(*model.WebhookDelivery)), nil
+}
+
+// UpdateDelivery will replace a delivery
+func (i *inMemoryWebhookRepo) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) *code.CustomError {
+	i.WriteLock.Lock()
+	defer i.WriteLock.Unlock()
+
+	if _, ok := i.DeliveryMap.Load(delivery.ID); !ok {
+		return newDeliveryNotFoundError()
+	}
+	i.DeliveryMap.Store(delivery.ID, toModelWebhookDelivery(delivery))
+	return nil
+}
+
+// GetDeliveries will get the deliveries of a webhook, newest first
+func (i *inMemoryWebhookRepo) GetDeliveries(ctx context.Context, webhookID int) ([]*domain.WebhookDelivery, *code.CustomError) {
+	return i.filterDeliveries(func(delivery *model.WebhookDelivery) bool {
+		return delivery.WebhookId == webhookID
+	}), nil
+}
+
+// GetDeliveriesByStatus will get the deliveries of a tenant in a status, newest first
+func (i *inMemoryWebhookRepo) GetDeliveriesByStatus(ctx context.Context, tenantID string, status domain.WebhookDeliveryStatus) ([]*domain.WebhookDelivery, *code.CustomError) {
+	return i.filterDeliveries(func(delivery *model.WebhookDelivery) bool {
+		return delivery.TenantId == tenantID && delivery.Status == status
+	}), nil
+}
+
+// DeleteDeliveries will delete the settled deliveries created before the given time
+func (i *inMemoryWebhookRepo) DeleteDeliveries(ctx context.Context, before time.Time) (int, *code.CustomError) {
+	i.WriteLock.Lock()
+	defer i.WriteLock.Unlock()
+
+	deleted := 0
+	i.DeliveryMap.Range(func(key, value interface{}) bool {
+		modelDelivery := value.(*model.WebhookDelivery)
+		if modelDelivery.Status != domain.WebhookDeliveryPending && modelDelivery.CreatedAt.Before(before) {
+			i.DeliveryMap.Delete(key)
+			deleted++
+		}
+		return true
+	})
+	return deleted, nil
+}
+
+func (i *inMemoryWebhookRepo) filterDeliveries(match func(*model.WebhookDelivery) bool) []*domain.WebhookDelivery {
+	deliveries := []*domain.WebhookDelivery{}
+	i.DeliveryMap.Range(func(_, value interface{}) bool {
+		modelDelivery := value.(*model.WebhookDelivery)
+		if match(modelDelivery) {
+			deliveries = append(deliveries, toDomainWebhookDelivery(modelDelivery))
+		}
+		return true
+	})
+	sort.Slice(deliveries, func(a, b int) bool { return deliveries[a].ID > deliveries[b].ID })
+	return deliveries
+}
+
+func newWebhookNotFoundError() *code.CustomError {
+	return code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("webhook not found"))
+}
+
+func newDeliveryNotFoundError() *code.CustomError {
+	return code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("webhook delivery not found"))
+}
+
+func toModelWebhook(webhook *domain.Webhook) *model.Webhook {
+	return &model.Webhook{
+		Id:        webhook.ID,
+		Url:       webhook.URL,
+		Events:    append([]domain.WebhookEvent(nil), webhook.Events...),
+		Active:    webhook.Active,
+		Secret:    webhook.Secret,
+		TenantId:  webhook.TenantID,
+		CreatedBy: webhook.CreatedBy,
+		CreatedAt: webhook.CreatedAt,
+		UpdatedAt: webhook.UpdatedAt,
+	}
+}
+
+func toDomainWebhook(modelWebhook *model.Webhook) *domain.Webhook {
+	return &domain.Webhook{
+		ID:        modelWebhook.Id,
+		URL:       modelWebhook.Url,
+		Events:    append([]domain.WebhookEvent(nil), modelWebhook.Events...),
+		Active:    modelWebhook.Active,
+		Secret:    modelWebhook.Secret,
+		TenantID:  modelWebhook.TenantId,
+		CreatedBy: modelWebhook.CreatedBy,
+		CreatedAt: modelWebhook.CreatedAt,
+		UpdatedAt: modelWebhook.UpdatedAt,
+	}
+}
+
+func toModelWebhookDelivery(delivery *domain.WebhookDelivery) *model.WebhookDelivery {
+	attempts := make([]model.WebhookAttempt, 0, len(delivery.Attempts))
+	for _, attempt := range delivery.Attempts {
+		attempts = append(attempts, model.WebhookAttempt{
+			AttemptedAt: attempt.AttemptedAt,
+			StatusCode:  attempt.StatusCode,
+			Error:       attempt.Error,
+			DurationMs:  attempt.DurationMs,
+		})
+	}
+	return &model.WebhookDelivery{
+		Id:            delivery.ID,
+		WebhookId:     delivery.WebhookID,
+		TenantId:      delivery.TenantID,
+		Event:         delivery.Event,
+		Payload:       append([]byte(nil), delivery.Payload...),
+		Status:        delivery.Status,
+		Attempts:      attempts,
+		NextAttemptAt: delivery.NextAttemptAt,
+		CreatedAt:     delivery.CreatedAt,
+	}
+}
+
+func toDomainWebhookDelivery(modelDelivery *model.WebhookDelivery) *domain.WebhookDelivery {
+	attempts := make([]*domain.WebhookAttempt, 0, len(modelDelivery.Attempts))
+	for _, attempt := range modelDelivery.Attempts {
+		attempts = append(attempts, &domain.WebhookAttempt{
+			AttemptedAt: attempt.AttemptedAt,
+			StatusCode:  attempt.StatusCode,
+			Error:       attempt.Error,
+			DurationMs:  attempt.DurationMs,
+		})
+	}
+	return &domain.WebhookDelivery{
+		ID:            modelDelivery.Id,
+		WebhookID:     modelDelivery.WebhookId,
+		TenantID:      modelDelivery.TenantId,
+		Event:         modelDelivery.Event,
+		Payload:       append([]byte(nil), modelDelivery.Payload...),
+		Status:        modelDelivery.Status,
+		Attempts:      attempts,
+		NextAttemptAt: modelDelivery.NextAttemptAt,
+		CreatedAt:     modelDelivery.CreatedAt,
+	}
+}
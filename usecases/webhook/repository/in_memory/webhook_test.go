@@ -0,0 +1,109 @@
+package inmemory
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/stretchr/testify/suite"
+)
+
+type webhookSuite struct {
+	suite.Suite
+	webhookRepo domain.WebhookRepository
+}
+
+func TestWebhookSuite(t *testing.T) {
+	suite.Run(t, new(webhookSuite))
+}
+
+func (s *webhookSuite) SetupTest() {
+	s.webhookRepo = NewInMemoryWebhookRepo()
+
+	ctx := context.Background()
+	s.Nil(s.webhookRepo.CreateWebhook(ctx, &domain.Webhook{URL: "https://a.example.com", TenantID: "default", Events: []domain.WebhookEvent{domain.WebhookEventTaskCreated}}))
+	s.Nil(s.webhookRepo.CreateWebhook(ctx, &domain.Webhook{URL: "https://b.example.com", TenantID: "acme"}))
+}
+
+func (s *webhookSuite) TestWebhooks() {
+	ctx := context.Background()
+	webhooks, customErr := s.webhookRepo.GetWebhooksByTenant(ctx, "default")
+	s.Nil(customErr)
+	s.Equal(1, len(webhooks))
+	s.Equal("https://a.example.com", webhooks[0].URL)
+
+	webhook := webhooks[0]
+	webhook.Events[0] = domain.WebhookEventTaskDeleted
+	stored, customErr := s.webhookRepo.GetWebhook(ctx, webhook.ID)
+	s.Nil(customErr)
+	s.Equal(domain.WebhookEventTaskCreated, stored.Events[0])
+
+	s.Nil(s.webhookRepo.UpdateWebhook(ctx, webhook))
+	stored, customErr = s.webhookRepo.GetWebhook(ctx, webhook.ID)
+	s.Nil(customErr)
+	s.Equal(domain.WebhookEventTaskDeleted, stored.Events[0])
+
+	customErr = s.webhookRepo.UpdateWebhook(ctx, &domain.Webhook{ID: 3})
+	s.NotNil(customErr)
+	s.Equal(http.StatusNotFound, customErr.HttpStatus)
+}
+
+func (s *webhookSuite) TestDeliveries() {
+	ctx := context.Background()
+	for _, webhookID := range []int{1, 1, 2} {
+		s.Nil(s.webhookRepo.CreateDelivery(ctx, &domain.WebhookDelivery{WebhookID: webhookID, Status: domain.WebhookDeliveryPending}))
+	}
+	customErr := s.webhookRepo.CreateDelivery(ctx, &domain.WebhookDelivery{WebhookID: 3})
+	s.NotNil(customErr)
+	s.Equal(http.StatusNotFound, customErr.HttpStatus)
+
+	delivery, customErr := s.webhookRepo.GetDelivery(ctx, 2)
+	s.Nil(customErr)
+	delivery.TenantID = "default"
+	delivery.Status = domain.WebhookDeliveryDead
+	delivery.Attempts = append(delivery.Attempts, &domain.WebhookAttempt{StatusCode: http.StatusInternalServerError})
+	s.Nil(s.webhookRepo.UpdateDelivery(ctx, delivery))
+
+	deliveries, customErr := s.webhookRepo.GetDeliveries(ctx, 1)
+	s.Nil(customErr)
+	s.Equal(2, len(deliveries))
+	s.Equal(2, deliveries[0].ID)
+	s.Equal(http.StatusInternalServerError, deliveries[0].Attempts[0].StatusCode)
+
+	dead, customErr := s.webhookRepo.GetDeliveriesByStatus(ctx, "default", domain.WebhookDeliveryDead)
+	s.Nil(customErr)
+	s.Equal(1, len(dead))
+	s.Equal(2, dead[0].ID)
+
+	// the deliveries are deleted with the webhook
+	s.Nil(s.webhookRepo.DeleteWebhook(ctx, 1))
+	_, customErr = s.webhookRepo.GetDelivery(ctx, 2)
+	s.NotNil(customErr)
+	s.Equal(http.StatusNotFound, customErr.HttpStatus)
+	_, customErr = s.webhookRepo.GetDelivery(ctx, 3)
+	s.Nil(customErr)
+}
+
+func (s *webhookSuite) TestDeleteDeliveries() {
+	ctx := context.Background()
+	now := time.Now()
+	for _, delivery := range []*domain.WebhookDelivery{
+		{WebhookID: 1, Status: domain.WebhookDeliveryDelivered, CreatedAt: now.Add(-2 * time.Hour)},
+		{WebhookID: 1, Status: domain.WebhookDeliveryDead, CreatedAt: now.Add(-2 * time.Hour)},
+		{WebhookID: 1, Status: domain.WebhookDeliveryPending, CreatedAt: now.Add(-2 * time.Hour)},
+		{WebhookID: 1, Status: domain.WebhookDeliveryDelivered, CreatedAt: now},
+	} {
+		s.Nil(s.webhookRepo.CreateDelivery(ctx, delivery))
+	}
+
+	deleted, customErr := s.webhookRepo.DeleteDeliveries(ctx, now.Add(-time.Hour))
+	s.Nil(customErr)
+	s.Equal(2, deleted)
+	deliveries, customErr := s.webhookRepo.GetDeliveries(ctx, 1)
+	s.Nil(customErr)
+	s.Equal(2, len(deliveries))
+	s.Equal(4, deliveries[0].ID)
+	s.Equal(3, deliveries[1].ID)
+}
@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/webhook"
+)
+
+// deliveryTimeout bounds an attempt to deliver a payload
+const deliveryTimeout = 10 * time.Second
+
+var (
+	webhookRepo       domain.WebhookRepository
+	httpClient        *http.Client
+	retryPolicy       RetryPolicy
+	allowPrivateHosts bool
+)
+
+// RetryPolicy decides when a failed delivery is retried, a delivery failing MaxAttempts times is dead
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy retries for about an hour
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    8,
+	InitialBackoff: 30 * time.Second,
+	MaxBackoff:     30 * time.Minute,
+}
+
+// backoff returns the delay after the n-th failed attempt, it doubles from InitialBackoff up to MaxBackoff
+func (p RetryPolicy) backoff(n int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < n && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return delay
+}
+
+// InitParam defines the parameters for initializing the service.
+type InitParam struct {
+	WebhookRepo domain.WebhookRepository
+	// HTTPClient sends the deliveries, when it is nil the redirects are not followed
+	// and only the public addresses are reached
+	HTTPClient  *http.Client
+	RetryPolicy RetryPolicy
+	// AllowPrivateHosts lets the webhooks reach the loopback and private addresses, for the tests
+	AllowPrivateHosts bool
+}
+
+// Init injects implementations into the service.
+func Init(param InitParam) {
+	webhookRepo = param.WebhookRepo
+	allowPrivateHosts = param.AllowPrivateHosts
+	httpClient = param.HTTPClient
+	switch {
+	case httpClient != nil:
+	case allowPrivateHosts:
+		httpClient = &http.Client{
+			Timeout: deliveryTimeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	default:
+		httpClient = webhook.NewPublicClient(deliveryTimeout)
+	}
+	retryPolicy = param.RetryPolicy
+	if retryPolicy.MaxAttempts == 0 {
+		retryPolicy = DefaultRetryPolicy
+	}
+}
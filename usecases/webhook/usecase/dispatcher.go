@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	customlog "github.com/Yu-Qi/restful_api/pkg/custom_log"
+	"github.com/Yu-Qi/restful_api/pkg/eventbus"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+	"github.com/Yu-Qi/restful_api/pkg/webhook"
+	"github.com/samber/lo"
+)
+
+// deliveryQueueSize is how many deliveries wait for a worker before the dispatcher stops taking task events
+const deliveryQueueSize = 1024
+
+// maxResponseBody is how much of a response is read, so the connection can be reused
+const maxResponseBody = 64 << 10
+
+// payload is the body of a webhook request
+type payload struct {
+	EventID    uint64              `json:"event_id"`
+	Type       domain.WebhookEvent `json:"type"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	Actor      string              `json:"actor"`
+	Data       payloadData         `json:"data"`
+}
+
+type payloadData struct {
+	Task *domain.Task `json:"task"`
+	// Previous is the task before an update
+	Previous *domain.Task `json:"previous,omitempty"`
+}
+
+// dispatcher turns the task events into deliveries and sends them, it keeps the dependencies it is started with
+type dispatcher struct {
+	ctx    context.Context
+	repo   domain.WebhookRepository
+	client *http.Client
+	policy RetryPolicy
+	queue  chan int // delivery ids
+}
+
+// StartDispatcher deliver the task events of the bus to the webhooks with the given number of workers until ctx is done
+func StartDispatcher(ctx context.Context, taskEvents *eventbus.Bus[*domain.TaskEvent], workers int) {
+	d := &dispatcher{
+		ctx:    ctx,
+		repo:   webhookRepo,
+		client: httpClient,
+		policy: retryPolicy,
+		queue:  make(chan int, deliveryQueueSize),
+	}
+	go d.consume(taskEvents)
+	for i := 0; i < workers; i++ {
+		go d.work()
+	}
+}
+
+// consume dispatches the task events, resubscribing from the last dispatched one when it falls behind
+func (d *dispatcher) consume(taskEvents *eventbus.Bus[*domain.TaskEvent]) {
+	var lastID uint64
+	for {
+		replay, events, cancel := taskEvents.Subscribe(lastID)
+		for _, event := range replay {
+			d.dispatch(event)
+			lastID = event.ID
+		}
+		var done bool
+		lastID, done = d.receive(events, lastID)
+		cancel()
+		if done {
+			return
+		}
+	}
+}
+
+// receive dispatches the events until the channel is closed or ctx is done
+func (d *dispatcher) receive(events <-chan eventbus.Event[*domain.TaskEvent], lastID uint64) (uint64, bool) {
+	for {
+		select {
+		case <-d.ctx.Done():
+			return lastID, true
+		case event, ok := <-events:
+			if !ok {
+				return lastID, false
+			}
+			d.dispatch(event)
+			lastID = event.ID
+		}
+	}
+}
+
+// dispatch creates a delivery for each active webhook of the tenant subscribing the event
+func (d *dispatcher) dispatch(event eventbus.Event[*domain.TaskEvent]) {
+	types := webhookEvents(event.Data)
+	if len(types) == 0 {
+		return
+	}
+	ctx := requestctx.WithTenantID(d.ctx, event.Data.TenantID)
+	webhooks, customErr := d.repo.GetWebhooksByTenant(ctx, event.Data.TenantID)
+	if customErr != nil {
+		customlog.ErrorfCtx(ctx, "get webhooks of tenant %s: %v", event.Data.TenantID, customErr.Error)
+		return
+	}
+
+	for _, hook := range webhooks {
+		if !hook.Active {
+			continue
+		}
+		for _, eventType := range types {
+			if !lo.Contains(hook.Events, eventType) {
+				continue
+			}
+			body, err := json.Marshal(&payload{
+				EventID:    event.ID,
+				Type:       eventType,
+				OccurredAt: event.Data.OccurredAt,
+				Actor:      event.Data.Actor,
+				Data:       payloadData{Task: event.Data.Task, Previous: event.Data.Before},
+			})
+			if err != nil {
+				customlog.ErrorfCtx(ctx, "marshal webhook payload: %v", err)
+				continue
+			}
+
+			delivery := &domain.WebhookDelivery{
+				WebhookID: hook.ID,
+				TenantID:  hook.TenantID,
+				Event:     eventType,
+				Payload:   body,
+				Status:    domain.WebhookDeliveryPending,
+				CreatedAt: time.Now(),
+			}
+			if customErr = d.repo.CreateDelivery(ctx, delivery); customErr != nil {
+				// the webhook is deleted meanwhile
+				continue
+			}
+			d.enqueue(delivery.ID)
+		}
+	}
+}
+
+func (d *dispatcher) enqueue(deliveryID int) {
+	select {
+	case d.queue <- deliveryID:
+	case <-d.ctx.Done():
+	}
+}
+
+func (d *dispatcher) work() {
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case deliveryID := <-d.queue:
+			d.deliver(deliveryID)
+		}
+	}
+}
+
+// deliver makes an attempt of a pending delivery and schedules the retry when it fails
+func (d *dispatcher) deliver(deliveryID int) {
+	delivery, customErr := d.repo.GetDelivery(d.ctx, deliveryID)
+	if customErr != nil || delivery.Status != domain.WebhookDeliveryPending {
+		return
+	}
+	ctx := requestctx.WithTenantID(d.ctx, delivery.TenantID)
+	hook, customErr := d.repo.GetWebhook(ctx, delivery.WebhookID)
+	if customErr != nil {
+		return
+	}
+
+	attempt := send(ctx, d.client, hook, delivery)
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	delivery.NextAttemptAt = nil
+	var backoff time.Duration
+	switch {
+	case attempt.Error == "":
+		delivery.Status = domain.WebhookDeliveryDelivered
+	case len(delivery.Attempts) >= d.policy.MaxAttempts:
+		delivery.Status = domain.WebhookDeliveryDead
+		customlog.ErrorfCtx(ctx, "webhook delivery %d is dead after %d attempts: %s", delivery.ID, len(delivery.Attempts), attempt.Error)
+	default:
+		backoff = d.policy.backoff(len(delivery.Attempts))
+		nextAttemptAt := attempt.AttemptedAt.Add(backoff)
+		delivery.NextAttemptAt = &nextAttemptAt
+	}
+	if customErr = d.repo.UpdateDelivery(ctx, delivery); customErr != nil {
+		return
+	}
+
+	if delivery.Status == domain.WebhookDeliveryPending {
+		time.AfterFunc(backoff, func() {
+			d.enqueue(delivery.ID)
+		})
+	}
+}
+
+// send posts the payload of a delivery to a webhook, the attempt fails unless the response status is 2xx
+func send(ctx context.Context, client *http.Client, hook *domain.Webhook, delivery *domain.WebhookDelivery) *domain.WebhookAttempt {
+	attempt := &domain.WebhookAttempt{AttemptedAt: time.Now()}
+	defer func() {
+		attempt.DurationMs = time.Since(attempt.AttemptedAt).Milliseconds()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := attempt.AttemptedAt.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.HeaderEvent, string(delivery.Event))
+	req.Header.Set(webhook.HeaderDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(hook.Secret, timestamp, delivery.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("unexpected response status %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// webhookEvents maps a task change to the webhook events, the purge of a deleted task is not sent
+func webhookEvents(event *domain.TaskEvent) []domain.WebhookEvent {
+	switch event.Action {
+	case domain.TaskActionCreate:
+		return []domain.WebhookEvent{domain.WebhookEventTaskCreated}
+	case domain.TaskActionUpdate, domain.TaskActionRevert, domain.TaskActionRestore:
+		types := []domain.WebhookEvent{domain.WebhookEventTaskUpdated}
+		if event.Before != nil && event.Before.Status != domain.TaskStatusCompleted && event.Task.Status == domain.TaskStatusCompleted {
+			types = append(types, domain.WebhookEventTaskCompleted)
+		}
+		return types
+	case domain.TaskActionDelete:
+		return []domain.WebhookEvent{domain.WebhookEventTaskDeleted}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	customlog "github.com/Yu-Qi/restful_api/pkg/custom_log"
+)
+
+// PurgeDeliveries delete the delivered and dead deliveries of every tenant older than retention
+func PurgeDeliveries(ctx context.Context, retention time.Duration) (int, *code.CustomError) {
+	return webhookRepo.DeleteDeliveries(ctx, time.Now().Add(-retention))
+}
+
+// StartDeliveryPurger purge the deliveries every interval in the background until ctx is done
+func StartDeliveryPurger(ctx context.Context, retention, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purged, customErr := PurgeDeliveries(ctx, retention)
+				if customErr != nil {
+					customlog.ErrorfCtx(ctx, "purge webhook deliveries failed: %v", customErr.Error)
+					continue
+				}
+				if purged > 0 {
+					customlog.InfofCtx(ctx, "purged %d webhook deliveries", purged)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Yu-Qi/restful_api/domain"
+	"github.com/Yu-Qi/restful_api/pkg/auth"
+	"github.com/Yu-Qi/restful_api/pkg/code"
+	"github.com/Yu-Qi/restful_api/pkg/requestctx"
+	"github.com/Yu-Qi/restful_api/pkg/webhook"
+)
+
+// a secret looks like whsec_<secret>, the secret is hex encoded random bytes
+const (
+	secretTag   = "whsec_"
+	secretBytes = 32
+)
+
+// CreateWebhook register a webhook of the tenant, the returned secret is never shown again
+func CreateWebhook(ctx context.Context, webhookURL string, events []domain.WebhookEvent) (*domain.Webhook, string, *code.CustomError) {
+	if customErr := requireAdmin(ctx); customErr != nil {
+		return nil, "", customErr
+	}
+	if customErr := validateURL(ctx, webhookURL); customErr != nil {
+		return nil, "", customErr
+	}
+
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", code.NewCustomError(code.InternalUnknownError, http.StatusInternalServerError, err)
+	}
+	now := time.Now()
+	webhook := &domain.Webhook{
+		URL:       webhookURL,
+		Events:    events,
+		Active:    true,
+		Secret:    secretTag + hex.EncodeToString(secret),
+		TenantID:  requestctx.GetTenantID(ctx),
+		CreatedBy: requestctx.GetActor(ctx),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if customErr := webhookRepo.CreateWebhook(ctx, webhook); customErr != nil {
+		return nil, "", customErr
+	}
+	return webhook, webhook.Secret, nil
+}
+
+// GetWebhooks get the webhooks of the tenant
+func GetWebhooks(ctx context.Context) ([]*domain.Webhook, *code.CustomError) {
+	if customErr := requireAdmin(ctx); customErr != nil {
+		return nil, customErr
+	}
+	return webhookRepo.GetWebhooksByTenant(ctx, requestctx.GetTenantID(ctx))
+}
+
+// GetWebhook get a webhook of the tenant
+func GetWebhook(ctx context.Context, id int) (*domain.Webhook, *code.CustomError) {
+	if customErr := requireAdmin(ctx); customErr != nil {
+		return nil, customErr
+	}
+	return getTenantWebhook(ctx, id)
+}
+
+// UpdateWebhook replace the url, the events and the active flag of a webhook
+func UpdateWebhook(ctx context.Context, params *domain.Webhook) (*domain.Webhook, *code.CustomError) {
+	if customErr := requireAdmin(ctx); customErr != nil {
+		return nil, customErr
+	}
+	if customErr := validateURL(ctx, params.URL); customErr != nil {
+		return nil, customErr
+	}
+
+	webhook, customErr := getTenantWebhook(ctx, params.ID)
+	if customErr != nil {
+		return nil, customErr
+	}
+	webhook.URL = params.URL
+	webhook.Events = params.Events
+	webhook.Active = params.Active
+	webhook.UpdatedAt = time.Now()
+	if customErr = webhookRepo.UpdateWebhook(ctx, webhook); customErr != nil {
+		return nil, customErr
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook delete a webhook and its deliveries, the pending deliveries are dropped
+func DeleteWebhook(ctx context.Context, id int) *code.CustomError {
+	if customErr := requireAdmin(ctx); customErr != nil {
+		return customErr
+	}
+	if _, customErr := getTenantWebhook(ctx, id); customErr != nil {
+		return customErr
+	}
+	return webhookRepo.DeleteWebhook(ctx, id)
+}
+
+// GetDeliveries get the deliveries of a webhook with their attempts, newest first
+func GetDeliveries(ctx context.Context, webhookID int) ([]*domain.WebhookDelivery, *code.CustomError) {
+	if customErr := requireAdmin(ctx); customErr != nil {
+		return nil, customErr
+	}
+	if _, customErr := getTenantWebhook(ctx, webhookID); customErr != nil {
+		return nil, customErr
+	}
+	return webhookRepo.GetDeliveries(ctx, webhookID)
+}
+
+// GetDeadLetters get the deliveries of the tenant which gave up retrying, newest first
+func GetDeadLetters(ctx context.Context) ([]*domain.WebhookDelivery, *code.CustomError) {
+	if customErr := requireAdmin(ctx); customErr != nil {
+		return nil, customErr
+	}
+	return webhookRepo.GetDeliveriesByStatus(ctx, requestctx.GetTenantID(ctx), domain.WebhookDeliveryDead)
+}
+
+// RedeliverDelivery send a dead delivery once more, it leaves the dead letters when the attempt succeeds
+func RedeliverDelivery(ctx context.Context, webhookID, deliveryID int) (*domain.WebhookDelivery, *code.CustomError) {
+	if customErr := requireAdmin(ctx); customErr != nil {
+		return nil, customErr
+	}
+	webhook, customErr := getTenantWebhook(ctx, webhookID)
+	if customErr != nil {
+		return nil, customErr
+	}
+	delivery, customErr := webhookRepo.GetDelivery(ctx, deliveryID)
+	if customErr != nil {
+		return nil, customErr
+	}
+	if delivery.WebhookID != webhook.ID {
+		return nil, code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("webhook delivery not found"))
+	}
+	if delivery.Status != domain.WebhookDeliveryDead {
+		return nil, code.NewCustomError(code.Conflict, http.StatusConflict, fmt.Errorf("only dead deliveries can be redelivered"))
+	}
+
+	attempt := send(ctx, httpClient, webhook, delivery)
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	if attempt.Error == "" {
+		delivery.Status = domain.WebhookDeliveryDelivered
+	}
+	if customErr = webhookRepo.UpdateDelivery(ctx, delivery); customErr != nil {
+		return nil, customErr
+	}
+	return delivery, nil
+}
+
+// requireAdmin checks the caller can manage the webhooks of the tenant, the api keys cannot whatever their scopes
+// and the role of their owner. The requests without a principal are allowed like they are on the tasks.
+func requireAdmin(ctx context.Context) *code.CustomError {
+	principal := auth.GetPrincipal(ctx)
+	if principal != nil && (principal.APIKeyID != 0 || principal.Role != auth.RoleAdmin) {
+		return code.NewCustomError(code.Forbidden, http.StatusForbidden, fmt.Errorf("admin role is required to manage webhooks"))
+	}
+	return nil
+}
+
+// getTenantWebhook get a webhook and check it belongs to the tenant, the webhooks of the others are not found
+func getTenantWebhook(ctx context.Context, id int) (*domain.Webhook, *code.CustomError) {
+	webhook, customErr := webhookRepo.GetWebhook(ctx, id)
+	if customErr != nil {
+		return nil, customErr
+	}
+	if webhook.TenantID != requestctx.GetTenantID(ctx) {
+		return nil, code.NewCustomError(code.NotFound, http.StatusNotFound, fmt.Errorf("webhook not found"))
+	}
+	return webhook, nil
+}
+
+// validateURL checks the url is an absolute http or https url whose host resolves to public addresses,
+// the deliveries check the address again as the host may resolve elsewhere by then
+func validateURL(ctx context.Context, webhookURL string) *code.CustomError {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, fmt.Errorf("url must be an absolute http or https url"))
+	}
+	if allowPrivateHosts {
+		return nil
+	}
+	if err = webhook.CheckPublicHost(ctx, parsed.Hostname()); err != nil {
+		return code.NewCustomError(code.ParamIncorrect, http.StatusBadRequest, err)
+	}
+	return nil
+}